@@ -1,9 +1,14 @@
 package backup
 
 import (
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
 )
 
+// manager runs the systemd operations of every command in this package.
+// Tests replace it with a systemd.Fake.
+var manager systemd.Manager = systemd.NewManager()
+
 var BackupCmd = &cobra.Command{
 	Use:   "backup",
 	Short: "Manage custom systemd backup services",
@@ -19,8 +24,14 @@ func init() {
 	BackupCmd.AddCommand(verifyCmd)
 	BackupCmd.AddCommand(testCmd)
 	BackupCmd.AddCommand(statusCmd)
+	BackupCmd.AddCommand(historyCmd)
 	BackupCmd.AddCommand(logsCmd)
 	BackupCmd.AddCommand(editCmd)
 	BackupCmd.AddCommand(notifyCmd)
+	BackupCmd.AddCommand(digestCmd)
 	BackupCmd.AddCommand(cleanupCmd)
+	BackupCmd.AddCommand(pruneCmd)
+	BackupCmd.AddCommand(restoreCmd)
+	BackupCmd.AddCommand(snapshotsCmd)
+	BackupCmd.AddCommand(lsCmd)
 }
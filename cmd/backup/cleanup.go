@@ -2,16 +2,31 @@ package backup
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/output"
 	"github.com/mufeedali/quadlet-helper/internal/shared"
 	"github.com/spf13/cobra"
 )
 
+var cleanupDryRun bool
+
 var cleanupCmd = &cobra.Command{
-	Use:               "cleanup [backup-name]",
-	Short:             "Run retention cleanup (used by systemd)",
+	Use:   "cleanup [backup-name]",
+	Short: "Run retention cleanup (used by systemd)",
+	Long: `Apply the retention rules of a backup, removing the snapshots they do not keep.
+
+restic backups run "restic forget" and, unless retention.prune_schedule gives
+pruning a timer of its own, prune the repository in the same go. rsync
+snapshot backups delete old snapshot directories, and rclone backups delete
+remote files older than keep_within or keep_days.
+
+--dry-run lists the snapshots that would be kept and removed without touching
+anything.`,
 	Args:              cobra.ExactArgs(1),
 	ValidArgsFunction: getBackupNameCompletions(),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -22,7 +37,18 @@ var cleanupCmd = &cobra.Command{
 			return err
 		}
 
-		if err := internalbackup.Cleanup(config); err != nil {
+		if cleanupDryRun {
+			return previewCleanup(config)
+		}
+
+		if err := config.Validate(); err != nil {
+			return cmdutil.Wrap(err, "invalid configuration")
+		}
+
+		start := time.Now()
+		err = internalbackup.Cleanup(config)
+		recordHistory(backupName, internalbackup.NewHistoryEntry(internalbackup.HistoryCleanup, start, time.Now(), "", err))
+		if err != nil {
 			return cmdutil.Wrap(err, "cleanup failed")
 		}
 
@@ -30,3 +56,68 @@ var cleanupCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// previewCleanup prints what cleanup would remove.
+func previewCleanup(config *internalbackup.Config) error {
+	if err := config.Validate(); err != nil {
+		return cmdutil.Wrap(err, "invalid configuration")
+	}
+	if !config.IsSnapshotMode() {
+		if within := config.Retention.Within(); within != "" {
+			fmt.Printf("%s has no snapshots; cleanup deletes files in %s older than %s.\n", config.Name, config.Destination.Remote, within)
+		} else {
+			fmt.Printf("%s has no retention rules; cleanup does nothing.\n", config.Name)
+		}
+		return nil
+	}
+
+	plan, err := internalbackup.PlanCleanup(config)
+	if err != nil {
+		return cmdutil.Wrap(err, "planning cleanup")
+	}
+
+	switch format := output.Current(); format {
+	case output.JSON, output.YAML:
+		if plan.Keep == nil {
+			plan.Keep = []internalbackup.Snapshot{}
+		}
+		if plan.Remove == nil {
+			plan.Remove = []internalbackup.Snapshot{}
+		}
+		return output.Encode(os.Stdout, format, plan)
+	case output.Plain:
+		var rows [][]string
+		for _, s := range plan.Keep {
+			rows = append(rows, []string{"keep", s.ID, s.Time.Format(time.RFC3339), strings.Join(s.Tags, ",")})
+		}
+		for _, s := range plan.Remove {
+			rows = append(rows, []string{"remove", s.ID, s.Time.Format(time.RFC3339), strings.Join(s.Tags, ",")})
+		}
+		return output.WritePlain(os.Stdout, rows)
+	}
+
+	fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("Cleanup plan for backup: %s (dry-run)", config.Name)))
+	fmt.Println()
+	if len(plan.Remove) == 0 {
+		fmt.Printf("Nothing to remove; all %d snapshot(s) are kept.\n", len(plan.Keep))
+		return nil
+	}
+
+	rows := make([][]string, 0, len(plan.Keep)+len(plan.Remove))
+	for _, s := range plan.Remove {
+		rows = append(rows, []string{"✗ remove", s.ShortID, s.Time.Local().Format(timeLayout), strings.Join(s.Tags, " ")})
+	}
+	for _, s := range plan.Keep {
+		rows = append(rows, []string{"✓ keep", s.ShortID, s.Time.Local().Format(timeLayout), strings.Join(s.Tags, " ")})
+	}
+	shared.PrintTable([]string{"Action", "ID", "Time", "Tags"}, rows)
+	fmt.Printf("\n%d snapshot(s) would be removed and %d kept.\n", len(plan.Remove), len(plan.Keep))
+	if config.Type == internalbackup.BackupTypeRestic && config.Retention.PruneSchedule != "" {
+		fmt.Printf("Their data is freed by the next prune (%s).\n", config.Retention.PruneSchedule)
+	}
+	return nil
+}
+
+func init() {
+	cleanupCmd.Flags().BoolVar(&cleanupDryRun, "dry-run", false, "List the snapshots that would be removed without removing them")
+}
@@ -3,7 +3,11 @@ package backup
 import (
 	"strings"
 
+	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // getBackupNameCompletions returns a ValidArgsFunction that provides backup name completions
@@ -44,3 +48,51 @@ func getNotifyCompletions() func(cmd *cobra.Command, args []string, toComplete s
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 }
+
+// getSnapshotCompletions completes --snapshot with "latest" and the snapshot
+// IDs of the snapshot backup named by the first argument.
+func getSnapshotCompletions() func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		completions := []string{"latest"}
+		if len(args) > 0 {
+			if config, err := internalbackup.LoadConfig(args[0]); err == nil && config.IsSnapshotMode() {
+				snapshots, _ := internalbackup.ListSnapshots(config)
+				for i := len(snapshots) - 1; i >= 0; i-- {
+					completions = append(completions, snapshots[i].ShortID+"\t"+snapshots[i].Time.Local().Format(timeLayout))
+				}
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+}
+
+// getSourceCompletions completes backup sources with podman volumes and
+// quadlet .volume files, falling back to paths.
+func getSourceCompletions() func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var completions []string
+		if volumes, err := internalbackup.ListVolumes(); err == nil {
+			for _, name := range volumes {
+				completions = append(completions, internalbackup.VolumeSourcePrefix+name)
+			}
+		}
+		if units, err := quadlet.Discover(shared.ResolveContainersDir(viper.GetString("containers-path"))); err == nil {
+			for _, u := range units {
+				if u.UnitType() == "volume" {
+					completions = append(completions, internalbackup.QuadletVolumeSourcePrefix+u.Name()+"\t"+u.VolumeName())
+				}
+			}
+		}
+
+		filtered := completions[:0]
+		for _, c := range completions {
+			if strings.HasPrefix(c, toComplete) {
+				filtered = append(filtered, c)
+			}
+		}
+		if len(filtered) > 0 {
+			return filtered, cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+}
@@ -4,20 +4,27 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/mufeedali/quadlet-helper/internal/backup"
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	qhconfig "github.com/mufeedali/quadlet-helper/internal/config"
 	"github.com/mufeedali/quadlet-helper/internal/shared"
 	"github.com/spf13/cobra"
 )
 
+var createSources []string
+
 var createCmd = &cobra.Command{
 	Use:   "create [backup-name]",
 	Short: "Create a new backup configuration",
-	Long:  `Interactive wizard to create a new backup configuration for rsync, restic, or rclone.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Interactive wizard to create a new backup configuration for rsync, restic, or rclone.
+
+Sources are paths, podman volumes given as volume:<name>, or the volumes of
+quadlet .volume files given as quadlet-volume:<file>.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println(shared.TitleStyle.Render("Create New Backup Configuration"))
 		fmt.Println()
@@ -80,12 +87,22 @@ var createCmd = &cobra.Command{
 		}
 
 		// Get source paths
-		fmt.Println("\nSource paths (comma-separated):")
-		fmt.Print("Sources: ")
-		sources, _ := reader.ReadString('\n')
-		config.Source = strings.Split(strings.TrimSpace(sources), ",")
-		for i, s := range config.Source {
-			config.Source[i] = strings.TrimSpace(s)
+		if len(createSources) > 0 {
+			config.Source = createSources
+		} else {
+			fmt.Println("\nSource paths (comma-separated):")
+			if volumes, err := backup.ListVolumes(); err == nil && len(volumes) > 0 {
+				fmt.Println("  Podman volumes can be given as volume:<name>: " + strings.Join(volumes, ", "))
+			}
+			fmt.Print("Sources: ")
+			sources, _ := reader.ReadString('\n')
+			config.Source = strings.Split(strings.TrimSpace(sources), ",")
+			for i, s := range config.Source {
+				config.Source[i] = strings.TrimSpace(s)
+			}
+		}
+		if slices.ContainsFunc(config.Source, backup.IsVolumeSource) {
+			config.Options.ExportVolumes = askYesNo(reader, "Export volumes with podman volume export instead of reading their files? (y/n): ")
 		}
 
 		// Get destination based on type
@@ -122,6 +139,9 @@ var createCmd = &cobra.Command{
 			config.Options.Archive = askYesNo(reader, "Use archive mode (-a)? (y/n): ")
 			config.Options.Compress = askYesNo(reader, "Use compression (-z)? (y/n): ")
 			config.Options.Delete = askYesNo(reader, "Delete extraneous files (--delete)? (y/n): ")
+			if askYesNo(reader, "Keep point-in-time snapshots instead of a single mirror? (y/n): ") {
+				config.Options.Mode = backup.RsyncModeSnapshot
+			}
 		case backup.BackupTypeRclone:
 			fmt.Print("Number of transfers (default 4): ")
 			transfers, _ := reader.ReadString('\n')
@@ -160,19 +180,29 @@ var createCmd = &cobra.Command{
 
 		// Retention
 		fmt.Println("\nRetention:")
-		switch config.Type {
-		case backup.BackupTypeRestic:
+		switch {
+		case config.IsSnapshotMode():
 			fmt.Print("Keep daily snapshots (0 to disable): ")
 			daily, _ := reader.ReadString('\n')
 			if d, err := strconv.Atoi(strings.TrimSpace(daily)); err == nil {
-				config.Options.KeepDaily = d
+				config.Retention.KeepDaily = d
 			}
 			fmt.Print("Keep weekly snapshots (0 to disable): ")
 			weekly, _ := reader.ReadString('\n')
 			if w, err := strconv.Atoi(strings.TrimSpace(weekly)); err == nil {
-				config.Options.KeepWeekly = w
+				config.Retention.KeepWeekly = w
 			}
-		case backup.BackupTypeRclone:
+			fmt.Print("Keep monthly snapshots (0 to disable): ")
+			monthly, _ := reader.ReadString('\n')
+			if m, err := strconv.Atoi(strings.TrimSpace(monthly)); err == nil {
+				config.Retention.KeepMonthly = m
+			}
+			if config.Type == backup.BackupTypeRestic && config.Retention.IsSet() {
+				fmt.Print("Prune schedule, if pruning should not run after every cleanup (e.g. weekly, empty to prune every time): ")
+				schedule, _ := reader.ReadString('\n')
+				config.Retention.PruneSchedule = strings.TrimSpace(schedule)
+			}
+		case config.Type == backup.BackupTypeRclone:
 			fmt.Print("Keep files for days (0 to disable): ")
 			days, _ := reader.ReadString('\n')
 			if d, err := strconv.Atoi(strings.TrimSpace(days)); err == nil {
@@ -187,9 +217,11 @@ var createCmd = &cobra.Command{
 			config.Notifications.OnFailure = askYesNo(reader, "Notify on failure? (y/n): ")
 			config.Notifications.OnSuccess = askYesNo(reader, "Notify on success? (y/n): ")
 
-			fmt.Print("Email to (optional, overrides global setting): ")
+			fmt.Print("Email to, comma-separated (optional, overrides global setting): ")
 			to, _ := reader.ReadString('\n')
-			config.Notifications.Email.To = strings.TrimSpace(to)
+			if to = strings.TrimSpace(to); to != "" {
+				config.Notifications.Email.To = qhconfig.AddressList{to}
+			}
 
 			fmt.Print("Email from (optional, overrides global setting): ")
 			from, _ := reader.ReadString('\n')
@@ -216,6 +248,11 @@ var createCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	createCmd.Flags().StringSliceVar(&createSources, "source", nil, "Source path, volume:<name> or quadlet-volume:<file> (repeatable)")
+	_ = createCmd.RegisterFlagCompletionFunc("source", getSourceCompletions())
+}
+
 func askYesNo(reader *bufio.Reader, prompt string) bool {
 	fmt.Print(prompt)
 	response, _ := reader.ReadString('\n')
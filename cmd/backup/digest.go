@@ -0,0 +1,177 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
+	"github.com/spf13/cobra"
+)
+
+var (
+	digestPreview  bool
+	digestPart     string
+	digestSince    string
+	digestSchedule string
+)
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Email a summary of every backup's recent runs",
+	Long: `Email a summary of every backup's recent runs to the global email
+recipients, either now or on a schedule.
+
+The digest is rendered from digest-subject.tmpl, digest.txt.tmpl and
+digest.html.tmpl in the templates directory, or from the built-in templates.`,
+}
+
+var digestSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Send the digest now (used by systemd)",
+	Long: `Send the digest now.
+
+The digest covers the runs since the last digest was sent, or the last day if
+none was. --since covers a fixed period instead, such as 12h or 7d.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		now := time.Now()
+		since := now.Add(-24 * time.Hour)
+		if digestSince != "" {
+			var err error
+			since, err = internalbackup.DigestSince(digestSince, now)
+			if err != nil {
+				return cmdutil.Wrap(err, "parsing --since")
+			}
+		} else if last, ok := internalbackup.LastDigest(); ok {
+			since = last
+		}
+
+		data, err := internalbackup.NewDigest(since, now)
+		if err != nil {
+			return cmdutil.Wrap(err, "collecting digest")
+		}
+
+		if digestPreview {
+			rendered, err := internalbackup.RenderDigest(data)
+			if err != nil {
+				return cmdutil.Wrap(err, "rendering digest")
+			}
+			return printRendered(rendered, digestPart)
+		}
+
+		warnings, err := internalbackup.SendDigest(data)
+		for _, warning := range warnings {
+			fmt.Fprintln(os.Stderr, shared.WarningStyle.Render("Warning: "+warning.Error()))
+		}
+		if err != nil {
+			return cmdutil.Wrap(err, "sending digest")
+		}
+		if err := internalbackup.RecordDigest(now); err != nil {
+			return cmdutil.Wrap(err, "recording digest")
+		}
+
+		fmt.Println(shared.SuccessStyle.Render("✓ Digest sent"))
+		return nil
+	},
+}
+
+var digestInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install a timer that sends the digest on a schedule",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if manager.HasUnitFile(internalbackup.BackupDigestTimerName) {
+			return cmdutil.Errorf("the digest timer is already installed\n\nTo reinstall, first uninstall it with:\n  qh backup digest uninstall")
+		}
+
+		fmt.Println(shared.TitleStyle.Render("Installing backup digest..."))
+
+		executablePath, err := os.Executable()
+		if err != nil {
+			return cmdutil.Wrap(err, "finding executable")
+		}
+
+		timerContent, err := internalbackup.GetDigestTimerTemplate(digestSchedule)
+		if err != nil {
+			return cmdutil.Wrap(err, "creating timer template")
+		}
+
+		paths, err := systemd.InstallUserUnits(manager, []systemd.UserUnitFile{
+			{Name: internalbackup.BackupDigestServiceName, Content: internalbackup.GetDigestServiceTemplate(executablePath), Mode: 0644},
+			{Name: internalbackup.BackupDigestTimerName, Content: timerContent, Mode: 0644},
+		}, []string{internalbackup.BackupDigestTimerName})
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Println(shared.CheckMark + " Created " + shared.FilePathStyle.Render(path))
+		}
+
+		fmt.Println(shared.SuccessStyle.Render("\n✓ Installation complete!"))
+		fmt.Println(shared.TitleStyle.Render("Timer status:"))
+		output, err := manager.Status(internalbackup.BackupDigestTimerName)
+		fmt.Println(output)
+		if err != nil {
+			return cmdutil.Wrap(err, "getting timer status")
+		}
+		active, err := manager.IsActive(internalbackup.BackupDigestTimerName)
+		if err != nil {
+			return cmdutil.Wrap(err, "checking timer active state")
+		}
+		if !active {
+			return cmdutil.Errorf("timer %s did not become active after installation", internalbackup.BackupDigestTimerName)
+		}
+		return nil
+	},
+}
+
+var digestUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the digest timer",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !manager.HasUnitFile(internalbackup.BackupDigestTimerName) {
+			return cmdutil.Errorf("the digest timer is not installed")
+		}
+
+		fmt.Println(shared.TitleStyle.Render("Uninstalling backup digest..."))
+
+		result, err := systemd.UninstallUserUnits(
+			manager,
+			[]string{internalbackup.BackupDigestTimerName, internalbackup.BackupDigestServiceName},
+			[]string{internalbackup.BackupDigestTimerName},
+			[]string{internalbackup.BackupDigestServiceName, internalbackup.BackupDigestTimerName},
+		)
+		if err != nil {
+			return err
+		}
+		for _, warning := range result.Warnings {
+			fmt.Println(shared.WarningStyle.Render("Warning: " + warning.Error()))
+		}
+		for _, path := range result.RemovedPaths {
+			fmt.Println(shared.CheckMark + " Removed " + shared.FilePathStyle.Render(path))
+		}
+
+		fmt.Println(shared.SuccessStyle.Render("\n✓ Uninstallation complete!"))
+		return nil
+	},
+}
+
+func init() {
+	digestSendCmd.Flags().BoolVar(&digestPreview, "preview", false, "Print the rendered digest instead of sending it")
+	digestSendCmd.Flags().StringVar(&digestPart, "part", "", "With --preview, only print this part (subject, text or html)")
+	digestSendCmd.Flags().StringVar(&digestSince, "since", "", "Cover this period instead of the time since the last digest (e.g. 12h, 7d)")
+	_ = digestSendCmd.RegisterFlagCompletionFunc("part", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{"subject", "text", "html"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	digestInstallCmd.Flags().StringVar(&digestSchedule, "schedule", "daily 08:00", "When to send the digest (e.g. daily, weekly, \"weekly mon 08:00\")")
+
+	digestCmd.AddCommand(digestSendCmd)
+	digestCmd.AddCommand(digestInstallCmd)
+	digestCmd.AddCommand(digestUninstallCmd)
+}
@@ -1,14 +1,21 @@
 package backup
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
 	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func loadBackupConfig(backupName string) (*internalbackup.Config, error) {
@@ -16,9 +23,49 @@ func loadBackupConfig(backupName string) (*internalbackup.Config, error) {
 	if err != nil {
 		return nil, cmdutil.Wrap(err, "loading config")
 	}
+	for _, warning := range config.Warnings() {
+		fmt.Fprintln(os.Stderr, shared.WarningStyle.Render("Warning: "+warning))
+	}
 	return config, nil
 }
 
+// resolveQuiesce resolves the backup's quiesce section against the quadlet
+// files in the containers directory.
+func resolveQuiesce(config *internalbackup.Config) (*internalbackup.QuiescePlan, error) {
+	if len(config.Quiesce.Stop) == 0 && len(config.Quiesce.Pause) == 0 {
+		return nil, nil
+	}
+	units, err := quadlet.Discover(shared.ResolveContainersDir(viper.GetString("containers-path")))
+	if err != nil {
+		return nil, cmdutil.Wrap(err, "finding quadlet units to quiesce")
+	}
+	plan, err := internalbackup.ResolveQuiesce(config.Quiesce, units)
+	if err != nil {
+		return nil, cmdutil.Wrap(err, "resolving quiesce units")
+	}
+	return plan, nil
+}
+
+// resolveQuadletVolumes replaces the backup's "quadlet-volume:" sources with
+// the volumes the .volume files in the containers directory create.
+func resolveQuadletVolumes(config *internalbackup.Config) error {
+	if !slices.ContainsFunc(config.Source, func(s string) bool {
+		return strings.HasPrefix(s, internalbackup.QuadletVolumeSourcePrefix)
+	}) {
+		return nil
+	}
+	units, err := quadlet.Discover(shared.ResolveContainersDir(viper.GetString("containers-path")))
+	if err != nil {
+		return cmdutil.Wrap(err, "finding quadlet volumes")
+	}
+	sources, err := internalbackup.ResolveQuadletVolumes(config.Source, units)
+	if err != nil {
+		return cmdutil.Wrap(err, "resolving quadlet volumes")
+	}
+	config.Source = sources
+	return nil
+}
+
 func backupCompletionFunc(filter func(string) bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) > 0 {
@@ -46,11 +93,102 @@ func backupCompletionFunc(filter func(string) bool) func(*cobra.Command, []strin
 }
 
 func isInstalledBackup(backupName string) bool {
-	timerFilePath, err := internalbackup.GetTimerFilePath(backupName)
+	return manager.HasUnitFile(internalbackup.BackupTimerName(backupName))
+}
+
+// notifyTemplateInUse reports whether an installed backup other than except
+// sends notifications through the shared notify template. Configs that fail
+// to load count as using it, so it is never removed from under them.
+func notifyTemplateInUse(except string) bool {
+	configs, err := internalbackup.ListConfigs()
+	if err != nil {
+		return true
+	}
+	for _, name := range configs {
+		if name == except || !isInstalledBackup(name) {
+			continue
+		}
+		if config, err := internalbackup.LoadConfig(name); err != nil || config.Notifications.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// recordHistory saves an entry to the backup's run history. Failing to do so
+// only warns, so it never changes the outcome of the operation itself.
+func recordHistory(backupName string, entry internalbackup.HistoryEntry) {
+	if err := internalbackup.RecordHistory(backupName, entry); err != nil {
+		fmt.Fprintln(os.Stderr, shared.WarningStyle.Render(fmt.Sprintf("Warning: could not record history: %v", err)))
+	}
+}
+
+// timeLayout matches how systemctl prints timestamps.
+const timeLayout = "Mon 2006-01-02 15:04:05 MST"
+
+// backupStatus collects the configuration and timer state of a backup. Problems
+// are reported through Status.Error so listings can carry on.
+func backupStatus(backupName string) internalbackup.Status {
+	status := internalbackup.Status{Name: backupName}
+
+	config, err := internalbackup.LoadConfig(backupName)
+	if err != nil {
+		status.Error = fmt.Sprintf("loading config: %v", err)
+		return status
+	}
+	status.Type = config.Type
+	status.Schedule = config.Schedule
+	status.Destination = config.GetDestination()
+
+	status.Installed = isInstalledBackup(backupName)
+	if !status.Installed {
+		return status
+	}
+
+	timer, err := manager.ShowProperties(internalbackup.BackupTimerName(backupName), "ActiveState", "LastTriggerUSec", "NextElapseUSecRealtime")
 	if err != nil {
-		return false
+		status.Error = fmt.Sprintf("checking timer: %v", err)
+		return status
+	}
+	status.TimerState = timer["ActiveState"]
+	if t, ok := systemd.ParseTimestamp(timer["LastTriggerUSec"]); ok {
+		status.LastRun = &t
+	}
+	if t, ok := systemd.ParseTimestamp(timer["NextElapseUSecRealtime"]); ok {
+		status.NextRun = &t
+	}
+
+	service, err := manager.ShowProperties(internalbackup.BackupServiceName(backupName), "ActiveState", "Result")
+	if err != nil {
+		status.Error = fmt.Sprintf("checking service: %v", err)
+		return status
+	}
+	status.ServiceState = service["ActiveState"]
+	if status.LastRun != nil {
+		status.LastResult = service["Result"]
+	}
+	return status
+}
+
+// backupStatusRow flattens a status for --output plain.
+func backupStatusRow(s internalbackup.Status) []string {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	return []string{
+		s.Name,
+		string(s.Type),
+		s.Schedule,
+		s.Destination,
+		strconv.FormatBool(s.Installed),
+		s.TimerState,
+		s.LastResult,
+		formatTime(s.LastRun),
+		formatTime(s.NextRun),
 	}
-	return shared.FileExists(timerFilePath)
 }
 
 func runJournalctl(args []string) error {
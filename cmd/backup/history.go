@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/output"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+var historyLimit int
+var historyKind string
+
+// backupHistory is the machine-readable output of the history command.
+type backupHistory struct {
+	Name    string                        `json:"name" yaml:"name"`
+	Summary internalbackup.HistorySummary `json:"summary" yaml:"summary"`
+	Entries []internalbackup.HistoryEntry `json:"entries" yaml:"entries"`
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history [backup-name]",
+	Short: "Show past runs, verifications, cleanups, prunes and restores of a backup",
+	Long: `Show the recorded runs, verifications, cleanups, prunes and restores of a backup, newest
+first, together with its success rate, streaks and how the duration and size
+of recent runs compare with the runs before them.
+
+History is kept under $XDG_STATE_HOME/quadlet-helper/backups.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: getBackupNameCompletions(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backupName := args[0]
+
+		if _, err := loadBackupConfig(backupName); err != nil {
+			return err
+		}
+
+		entries, err := internalbackup.LoadHistory(backupName)
+		if err != nil {
+			return cmdutil.Wrap(err, "loading history")
+		}
+		summary := internalbackup.Summarize(entries)
+
+		// Newest first, filtered and limited.
+		var shown []internalbackup.HistoryEntry
+		for i := len(entries) - 1; i >= 0; i-- {
+			if historyKind != "" && string(entries[i].Kind) != historyKind {
+				continue
+			}
+			if historyLimit > 0 && len(shown) == historyLimit {
+				break
+			}
+			shown = append(shown, entries[i])
+		}
+
+		switch format := output.Current(); format {
+		case output.JSON, output.YAML:
+			if shown == nil {
+				shown = []internalbackup.HistoryEntry{}
+			}
+			return output.Encode(os.Stdout, format, backupHistory{Name: backupName, Summary: summary, Entries: shown})
+		case output.Plain:
+			rows := make([][]string, len(shown))
+			for i, e := range shown {
+				rows[i] = []string{
+					e.Start.Format(time.RFC3339),
+					string(e.Kind),
+					strconv.FormatBool(e.Success),
+					strconv.FormatFloat(e.DurationSeconds, 'f', 1, 64),
+					strconv.FormatInt(e.BytesTransferred, 10),
+					strconv.FormatInt(e.FilesChanged, 10),
+					strconv.Itoa(e.ExitCode),
+					e.Error,
+				}
+			}
+			return output.WritePlain(os.Stdout, rows)
+		}
+
+		fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("History for backup: %s", backupName)))
+		fmt.Println()
+
+		if len(entries) == 0 {
+			fmt.Println("No runs recorded yet.")
+			fmt.Printf("\nRun the backup with: qh backup run %s\n", backupName)
+			return nil
+		}
+
+		printHistorySummary(summary)
+
+		if len(shown) == 0 {
+			return nil
+		}
+		fmt.Println()
+		rows := make([][]string, len(shown))
+		for i, e := range shown {
+			result := "✓ ok"
+			if !e.Success {
+				result = fmt.Sprintf("✗ exit %d", e.ExitCode)
+			}
+			transferred, files := "-", "-"
+			if e.BytesTransferred > 0 {
+				transferred = internalbackup.FormatBytes(e.BytesTransferred)
+			}
+			if e.FilesChanged > 0 {
+				files = strconv.FormatInt(e.FilesChanged, 10)
+			}
+			rows[i] = []string{
+				e.Start.Local().Format(timeLayout),
+				string(e.Kind),
+				result,
+				e.Duration().Round(time.Second).String(),
+				transferred,
+				files,
+			}
+		}
+		shared.PrintTable([]string{"Started", "Kind", "Result", "Duration", "Transferred", "Files"}, rows)
+		return nil
+	},
+}
+
+func printHistorySummary(s internalbackup.HistorySummary) {
+	if s.Runs == 0 {
+		fmt.Println("No backup runs recorded yet.")
+		return
+	}
+
+	fmt.Printf("  Runs: %d (%d succeeded, %d failed)\n", s.Runs, s.Successes, s.Failures)
+	if s.LastSuccess != nil {
+		fmt.Printf("  Last success: %s\n", s.LastSuccess.Local().Format(timeLayout))
+	}
+	if s.LastFailure != nil {
+		fmt.Printf("  Last failure: %s\n", s.LastFailure.Local().Format(timeLayout))
+	}
+
+	streak := shared.SuccessStyle.Render(fmt.Sprintf("%d successful run(s)", s.Streak))
+	if !s.StreakSuccess {
+		streak = shared.ErrorStyle.Render(fmt.Sprintf("%d failed run(s)", s.Streak))
+	}
+	fmt.Printf("  Current streak: %s\n", streak)
+	if s.LongestFailureStreak > 1 {
+		fmt.Printf("  Longest failure streak: %d\n", s.LongestFailureStreak)
+	}
+
+	seconds := func(v float64) string {
+		return (time.Duration(v * float64(time.Second))).Round(time.Second).String()
+	}
+	duration := seconds(s.AvgDurationSeconds)
+	transferred := internalbackup.FormatBytes(s.AvgBytes)
+	if s.PrevDurationSeconds > 0 {
+		duration += fmt.Sprintf(" (previously %s)", seconds(s.PrevDurationSeconds))
+		transferred += fmt.Sprintf(" (previously %s)", internalbackup.FormatBytes(s.PrevAvgBytes))
+	}
+	fmt.Printf("  Average duration of last %d runs: %s\n", min(s.Runs, internalbackup.HistoryTrendWindow), duration)
+	fmt.Printf("  Average transferred per run: %s\n", transferred)
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of entries to show (0 for all)")
+	historyCmd.Flags().StringVar(&historyKind, "kind", "", "Only show entries of this kind (run, verify, cleanup, prune, restore)")
+	_ = historyCmd.RegisterFlagCompletionFunc("kind", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(internalbackup.HistoryRun), string(internalbackup.HistoryVerify), string(internalbackup.HistoryCleanup), string(internalbackup.HistoryPrune), string(internalbackup.HistoryRestore)}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
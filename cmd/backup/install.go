@@ -40,10 +40,40 @@ var installCmd = &cobra.Command{
 			return cmdutil.Wrap(err, "creating timer template")
 		}
 
-		paths, err := systemd.InstallUserUnits([]systemd.UserUnitFile{
+		files := []systemd.UserUnitFile{
 			{Name: internalbackup.BackupServiceName(backupName), Content: internalbackup.GetServiceTemplate(executablePath, backupName, config), Mode: 0644},
 			{Name: internalbackup.BackupTimerName(backupName), Content: timerContent, Mode: 0644},
-		}, []string{internalbackup.BackupTimerName(backupName)})
+		}
+		if config.Notifications.Enabled {
+			// The notify template is shared by every backup. It is refreshed in
+			// case the executable moved, but a failed install must not take an
+			// existing one with it.
+			template := internalbackup.GetNotificationServiceTemplate(executablePath)
+			if manager.HasUnitFile(internalbackup.BackupNotifyTemplateName) {
+				if _, err := manager.WriteUnitFile(internalbackup.BackupNotifyTemplateName, template, 0644); err != nil {
+					return cmdutil.Wrap(err, "updating %s", internalbackup.BackupNotifyTemplateName)
+				}
+			} else {
+				files = append(files, systemd.UserUnitFile{Name: internalbackup.BackupNotifyTemplateName, Content: template, Mode: 0644})
+			}
+			if len(config.Environment) > 0 {
+				files = append(files, systemd.UserUnitFile{Name: internalbackup.BackupNotifyDropInName(backupName), Content: internalbackup.GetNotificationDropInTemplate(config), Mode: 0644})
+			}
+		}
+		timers := []string{internalbackup.BackupTimerName(backupName)}
+		if config.Type == internalbackup.BackupTypeRestic && config.Retention.PruneSchedule != "" {
+			pruneTimerContent, err := internalbackup.GetPruneTimerTemplate(backupName, config.Retention.PruneSchedule)
+			if err != nil {
+				return cmdutil.Wrap(err, "creating prune timer template")
+			}
+			files = append(files,
+				systemd.UserUnitFile{Name: internalbackup.BackupPruneServiceName(backupName), Content: internalbackup.GetPruneServiceTemplate(executablePath, backupName, config), Mode: 0644},
+				systemd.UserUnitFile{Name: internalbackup.BackupPruneTimerName(backupName), Content: pruneTimerContent, Mode: 0644},
+			)
+			timers = append(timers, internalbackup.BackupPruneTimerName(backupName))
+		}
+
+		paths, err := systemd.InstallUserUnits(manager, files, timers)
 		if err != nil {
 			return err
 		}
@@ -55,12 +85,12 @@ var installCmd = &cobra.Command{
 
 		fmt.Println(shared.SuccessStyle.Render("\n✓ Installation complete!"))
 		fmt.Println(shared.TitleStyle.Render("Timer status:"))
-		output, err := systemd.Status(timerName)
+		output, err := manager.Status(timerName)
 		fmt.Println(output)
 		if err != nil {
 			return cmdutil.Wrap(err, "getting timer status")
 		}
-		active, err := systemd.IsActive(timerName)
+		active, err := manager.IsActive(timerName)
 		if err != nil {
 			return cmdutil.Wrap(err, "checking timer active state")
 		}
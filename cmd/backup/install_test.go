@@ -0,0 +1,227 @@
+package backup
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
+	"github.com/mufeedali/quadlet-helper/internal/systemd/systemdtest"
+)
+
+func saveTestConfig(t *testing.T, name string) {
+	t.Helper()
+	config := &internalbackup.Config{
+		Name:        name,
+		Type:        internalbackup.BackupTypeRsync,
+		Schedule:    "daily",
+		Source:      []string{"/srv/data"},
+		Destination: internalbackup.Destination{Path: "/mnt/backup"},
+	}
+	if err := internalbackup.SaveConfig(config); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+}
+
+func TestInstallAndUninstall(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	fake := systemdtest.UseFake(t, &manager)
+	saveTestConfig(t, "docs")
+
+	if isInstalledBackup("docs") {
+		t.Fatal("isInstalledBackup() = true before install")
+	}
+	if err := installCmd.RunE(installCmd, []string{"docs"}); err != nil {
+		t.Fatalf("install error = %v", err)
+	}
+
+	service := internalbackup.BackupServiceName("docs")
+	timer := internalbackup.BackupTimerName("docs")
+	wantFiles := []string{service, timer}
+	if got := fake.UnitFiles(); !slices.Equal(got, wantFiles) {
+		t.Fatalf("unit files = %v, want %v", got, wantFiles)
+	}
+	if !strings.Contains(fake.Files[service], `backup run "docs"`) {
+		t.Errorf("service does not run the backup:\n%s", fake.Files[service])
+	}
+	if !fake.Enabled[timer] || !fake.Active[timer] {
+		t.Error("timer was not enabled and started")
+	}
+	if !isInstalledBackup("docs") {
+		t.Error("isInstalledBackup() = false after install")
+	}
+
+	status := backupStatus("docs")
+	if !status.Installed || status.TimerState != "active" || status.Error != "" {
+		t.Errorf("backupStatus() = %+v, want installed with an active timer", status)
+	}
+
+	if err := installCmd.RunE(installCmd, []string{"docs"}); err == nil {
+		t.Error("second install error = nil, want already installed")
+	}
+
+	if err := uninstallCmd.RunE(uninstallCmd, []string{"docs"}); err != nil {
+		t.Fatalf("uninstall error = %v", err)
+	}
+	if got := fake.UnitFiles(); len(got) != 0 {
+		t.Errorf("unit files after uninstall = %v, want none", got)
+	}
+	if fake.Enabled[timer] || fake.Active[timer] {
+		t.Error("timer is still enabled or active after uninstall")
+	}
+	if isInstalledBackup("docs") {
+		t.Error("isInstalledBackup() = true after uninstall")
+	}
+}
+
+func TestInstallFailureLeavesNothingBehind(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	fake := systemdtest.UseFake(t, &manager)
+	saveTestConfig(t, "docs")
+	fake.Fail["enable "+internalbackup.BackupTimerName("docs")] = errors.New("exit status 1")
+
+	if err := installCmd.RunE(installCmd, []string{"docs"}); err == nil {
+		t.Fatal("install error = nil, want enable failure")
+	}
+	if got := fake.UnitFiles(); len(got) != 0 {
+		t.Errorf("unit files after failed install = %v, want none", got)
+	}
+}
+
+func TestInstallWithPruneSchedule(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	fake := systemdtest.UseFake(t, &manager)
+	config := &internalbackup.Config{
+		Name:        "photos",
+		Type:        internalbackup.BackupTypeRestic,
+		Schedule:    "daily",
+		Source:      []string{"/srv/photos"},
+		Destination: internalbackup.Destination{Repository: "/mnt/restic"},
+		Retention:   internalbackup.Retention{KeepDaily: 7, PruneSchedule: "weekly"},
+	}
+	if err := internalbackup.SaveConfig(config); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	if err := installCmd.RunE(installCmd, []string{"photos"}); err != nil {
+		t.Fatalf("install error = %v", err)
+	}
+	pruneService := internalbackup.BackupPruneServiceName("photos")
+	pruneTimer := internalbackup.BackupPruneTimerName("photos")
+	if !fake.HasUnitFile(pruneService) || !fake.HasUnitFile(pruneTimer) {
+		t.Fatalf("unit files = %v, want the prune service and timer", fake.UnitFiles())
+	}
+	if !fake.Enabled[pruneTimer] || !fake.Active[pruneTimer] {
+		t.Error("prune timer was not enabled and started")
+	}
+	if !strings.Contains(fake.Files[pruneService], `backup prune "photos"`) {
+		t.Errorf("prune service does not run prune:\n%s", fake.Files[pruneService])
+	}
+
+	if err := uninstallCmd.RunE(uninstallCmd, []string{"photos"}); err != nil {
+		t.Fatalf("uninstall error = %v", err)
+	}
+	if got := fake.UnitFiles(); len(got) != 0 {
+		t.Errorf("unit files after uninstall = %v, want none", got)
+	}
+	if fake.Enabled[pruneTimer] || fake.Active[pruneTimer] {
+		t.Error("prune timer is still enabled or active after uninstall")
+	}
+}
+
+func TestInstallWithNotifications(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	fake := systemdtest.UseFake(t, &manager)
+	// A template left by an older install is brought up to date.
+	fake.Files[internalbackup.BackupNotifyTemplateName] = "[Service]\nExecStart=/old/qh backup notify %I\n"
+	for _, name := range []string{"docs", "photos"} {
+		config := &internalbackup.Config{
+			Name:          name,
+			Type:          internalbackup.BackupTypeRsync,
+			Schedule:      "daily",
+			Source:        []string{"/srv/" + name},
+			Destination:   internalbackup.Destination{Path: "/mnt/backup"},
+			Notifications: internalbackup.Notifications{Enabled: true, OnFailure: true},
+		}
+		if name == "docs" {
+			config.Environment = []string{"SMTP_PASSWORD=hunter2"}
+		}
+		if err := internalbackup.SaveConfig(config); err != nil {
+			t.Fatalf("SaveConfig() error = %v", err)
+		}
+		if err := installCmd.RunE(installCmd, []string{name}); err != nil {
+			t.Fatalf("install %s error = %v", name, err)
+		}
+	}
+
+	template := internalbackup.BackupNotifyTemplateName
+	if !strings.Contains(fake.Files[template], `backup notify "%I"`) || strings.Contains(fake.Files[template], "/old/qh") {
+		t.Errorf("notify template does not run notify:\n%s", fake.Files[template])
+	}
+	dropIn := internalbackup.BackupNotifyDropInName("docs")
+	if !strings.Contains(fake.Files[dropIn], `Environment="SMTP_PASSWORD=hunter2"`) {
+		t.Errorf("notify drop-in does not pass on the environment:\n%s", fake.Files[dropIn])
+	}
+	if fake.HasUnitFile(internalbackup.BackupNotifyDropInName("photos")) {
+		t.Error("notify drop-in written for a backup without environment")
+	}
+	service := fake.Files[internalbackup.BackupServiceName("docs")]
+	for _, check := range []string{
+		"OnFailure=qh-backup-notify@docs.service",
+		"OnSuccess=qh-backup-notify@docs.service",
+		`backup run --no-notify "docs"`,
+	} {
+		if !strings.Contains(service, check) {
+			t.Errorf("service missing %q:\n%s", check, service)
+		}
+	}
+
+	if err := uninstallCmd.RunE(uninstallCmd, []string{"docs"}); err != nil {
+		t.Fatalf("uninstall error = %v", err)
+	}
+	if fake.HasUnitFile(dropIn) {
+		t.Error("notify drop-in left after uninstall")
+	}
+	if !fake.HasUnitFile(template) {
+		t.Error("notify template removed while photos still uses it")
+	}
+	if err := uninstallCmd.RunE(uninstallCmd, []string{"photos"}); err != nil {
+		t.Fatalf("uninstall error = %v", err)
+	}
+	if got := fake.UnitFiles(); len(got) != 0 {
+		t.Errorf("unit files after uninstall = %v, want none", got)
+	}
+}
+
+func TestDigestInstallAndUninstall(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	fake := systemdtest.UseFake(t, &manager)
+	old := digestSchedule
+	digestSchedule = "weekly"
+	t.Cleanup(func() { digestSchedule = old })
+
+	if err := digestInstallCmd.RunE(digestInstallCmd, nil); err != nil {
+		t.Fatalf("digest install error = %v", err)
+	}
+	service, timer := internalbackup.BackupDigestServiceName, internalbackup.BackupDigestTimerName
+	if !strings.Contains(fake.Files[service], "backup digest send") {
+		t.Errorf("digest service does not send the digest:\n%s", fake.Files[service])
+	}
+	if !strings.Contains(fake.Files[timer], "OnCalendar=Mon *-*-* 02:00:00") {
+		t.Errorf("digest timer does not follow --schedule:\n%s", fake.Files[timer])
+	}
+	if !fake.Enabled[timer] || !fake.Active[timer] {
+		t.Error("digest timer was not enabled and started")
+	}
+	if err := digestInstallCmd.RunE(digestInstallCmd, nil); err == nil {
+		t.Error("second digest install error = nil, want already installed")
+	}
+
+	if err := digestUninstallCmd.RunE(digestUninstallCmd, nil); err != nil {
+		t.Fatalf("digest uninstall error = %v", err)
+	}
+	if got := fake.UnitFiles(); len(got) != 0 {
+		t.Errorf("unit files after uninstall = %v, want none", got)
+	}
+}
@@ -2,11 +2,12 @@ package backup
 
 import (
 	"fmt"
+	"os"
 
 	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/output"
 	"github.com/mufeedali/quadlet-helper/internal/shared"
-	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
 )
 
@@ -14,44 +15,51 @@ var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all backup configurations",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println(shared.TitleStyle.Render("Backup Configurations"))
-		fmt.Println()
-
 		configs, err := internalbackup.ListConfigs()
 		if err != nil {
 			return cmdutil.Wrap(err, "listing configs")
 		}
 
-		if len(configs) == 0 {
+		statuses := make([]internalbackup.Status, 0, len(configs))
+		for _, name := range configs {
+			statuses = append(statuses, backupStatus(name))
+		}
+
+		switch format := output.Current(); format {
+		case output.JSON, output.YAML:
+			return output.Encode(os.Stdout, format, statuses)
+		case output.Plain:
+			rows := make([][]string, len(statuses))
+			for i, s := range statuses {
+				rows[i] = backupStatusRow(s)
+			}
+			return output.WritePlain(os.Stdout, rows)
+		}
+
+		fmt.Println(shared.TitleStyle.Render("Backup Configurations"))
+		fmt.Println()
+
+		if len(statuses) == 0 {
 			fmt.Println("No backup configurations found.")
 			fmt.Println("\nCreate a new backup with:")
 			fmt.Println("  qh backup create")
 			return nil
 		}
 
-		for _, name := range configs {
-			config, err := loadBackupConfig(name)
-			if err != nil {
-				fmt.Printf("%s %s (error loading: %v)\n", shared.ErrorStyle.Render("✗"), name, err)
+		for _, s := range statuses {
+			if s.Type == "" {
+				fmt.Printf("%s %s (%s)\n", shared.ErrorStyle.Render("✗"), s.Name, s.Error)
 				continue
 			}
-
-			installed := isInstalledBackup(name)
-
-			active := false
-			timerName := internalbackup.BackupTimerName(name)
-			if installed {
-				active, err = systemd.IsActive(timerName)
-				if err != nil {
-					fmt.Printf("%s %s (error checking status: %v)\n", shared.WarningStyle.Render("!"), name, err)
-					continue
-				}
+			if s.Error != "" {
+				fmt.Printf("%s %s (%s)\n", shared.WarningStyle.Render("!"), s.Name, s.Error)
+				continue
 			}
 
 			status := "not installed"
 			statusStyle := shared.WarningStyle
-			if installed {
-				if active {
+			if s.Installed {
+				if s.TimerState == "active" {
 					status = "active"
 					statusStyle = shared.SuccessStyle
 				} else {
@@ -62,17 +70,17 @@ var listCmd = &cobra.Command{
 
 			fmt.Printf("%s %s [%s] - %s → %s\n",
 				statusStyle.Render("●"),
-				shared.TitleStyle.Render(name),
+				shared.TitleStyle.Render(s.Name),
 				statusStyle.Render(status),
-				config.Type,
-				config.GetDestination())
-			fmt.Printf("  Schedule: %s\n", config.Schedule)
+				s.Type,
+				s.Destination)
+			fmt.Printf("  Schedule: %s\n", s.Schedule)
 
-			if active {
-				// Show next run time
-				if output, err := systemd.Show(timerName, "NextElapseUSecRealtime"); err == nil && len(output) > 0 {
-					fmt.Printf("  Next run: %s", output)
-				}
+			if s.LastRun != nil {
+				fmt.Printf("  Last run: %s (%s)\n", s.LastRun.Format(timeLayout), s.LastResult)
+			}
+			if s.TimerState == "active" && s.NextRun != nil {
+				fmt.Printf("  Next run: %s\n", s.NextRun.Format(timeLayout))
 			}
 
 			fmt.Println()
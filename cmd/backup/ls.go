@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/output"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+var lsSnapshot string
+
+var lsCmd = &cobra.Command{
+	Use:   "ls [backup-name] [path]",
+	Short: "List the files in a backup",
+	Long: `List the files in a backup, or below a path inside it, with their sizes and
+modification times.
+
+For restic and rsync snapshot backups this lists a snapshot (the latest one
+unless --snapshot is given). For rsync mirrors and rclone it lists the backup
+destination.`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: getBackupNameCompletions(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backupName := args[0]
+		var dir string
+		if len(args) > 1 {
+			dir = args[1]
+		}
+
+		config, err := loadBackupConfig(backupName)
+		if err != nil {
+			return err
+		}
+		if lsSnapshot != "" && !config.IsSnapshotMode() {
+			return cmdutil.Errorf("--snapshot is only supported for restic backups and rsync backups in snapshot mode")
+		}
+
+		entries, err := internalbackup.ListFiles(config, lsSnapshot, dir)
+		if err != nil {
+			return cmdutil.Wrap(err, "listing files")
+		}
+
+		switch format := output.Current(); format {
+		case output.JSON, output.YAML:
+			if entries == nil {
+				entries = []internalbackup.FileEntry{}
+			}
+			return output.Encode(os.Stdout, format, entries)
+		case output.Plain:
+			rows := make([][]string, len(entries))
+			for i, e := range entries {
+				rows[i] = []string{e.Type, strconv.FormatInt(e.Size, 10), e.ModTime.Format(time.RFC3339), e.Path}
+			}
+			return output.WritePlain(os.Stdout, rows)
+		}
+
+		location := config.GetDestination()
+		if config.IsSnapshotMode() {
+			snapshot := lsSnapshot
+			if snapshot == "" {
+				snapshot = "latest"
+			}
+			location += " @ " + snapshot
+		}
+		fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("Files in backup: %s", backupName)) + " " + shared.FilePathStyle.Render(location))
+		fmt.Println()
+		if len(entries) == 0 {
+			fmt.Println("No files found.")
+			return nil
+		}
+
+		var total int64
+		rows := make([][]string, len(entries))
+		for i, e := range entries {
+			size := "-"
+			if e.Type != "dir" {
+				size = internalbackup.FormatBytes(e.Size)
+				total += e.Size
+			}
+			rows[i] = []string{e.Type, size, e.ModTime.Local().Format(timeLayout), e.Path}
+		}
+		shared.PrintTable([]string{"Type", "Size", "Modified", "Path"}, rows)
+		fmt.Printf("\n%d entries, %s in files\n", len(entries), internalbackup.FormatBytes(total))
+		return nil
+	},
+}
+
+func init() {
+	lsCmd.Flags().StringVar(&lsSnapshot, "snapshot", "", "Snapshot to list: latest or a snapshot ID (default latest)")
+	_ = lsCmd.RegisterFlagCompletionFunc("snapshot", getSnapshotCompletions())
+}
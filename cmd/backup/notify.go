@@ -1,34 +1,82 @@
 package backup
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
 	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
 )
 
+var (
+	notifyPreview bool
+	notifyPart    string
+)
+
 var notifyCmd = &cobra.Command{
-	Use:               "notify [backup-name] [status]",
-	Short:             "Send email notification (used by systemd)",
-	Args:              cobra.ExactArgs(2),
+	Use:   "notify [backup-name] [status]",
+	Short: "Send notifications about a backup (used by systemd)",
+	Long: `Send notifications about a backup's last run.
+
+The status is success or failure. Run from the qh-backup-notify@.service unit
+that installed backups start through OnSuccess= and OnFailure=, it may be left
+out and is then taken from the result systemd reports for the backup service.
+
+With --preview, the notification is rendered with the templates in the
+templates directory and printed instead of sent. Without a status, the last
+recorded run is previewed.`,
+	Args:              cobra.RangeArgs(1, 2),
 	ValidArgsFunction: getNotifyCompletions(),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		backupName := args[0]
-		status := args[1] // "success" or "failure"
 
 		config, err := loadBackupConfig(backupName)
 		if err != nil {
 			return err
 		}
 
-		logs, err := internalbackup.GetLastBackupLog(backupName)
-		if err != nil {
-			logs = fmt.Sprintf("Failed to retrieve logs: %v", err)
+		status, summary, ok := internalbackup.MonitorResult(os.Getenv)
+		if len(args) > 1 {
+			status = args[1] // "success" or "failure"
 		}
 
-		if err := internalbackup.SendNotification(config, status, logs); err != nil {
+		var logs string
+		var result *internalbackup.RunResult
+		if notifyPreview && status == "" {
+			entry, err := lastRun(backupName)
+			if err != nil {
+				return err
+			}
+			status, logs, result = "failure", entry.Output, &internalbackup.RunResult{StartTime: entry.Start, EndTime: entry.End}
+			if entry.Success {
+				status = "success"
+			}
+			if entry.Error != "" {
+				result.Error = errors.New(entry.Error)
+			}
+		} else {
+			logs, err = internalbackup.GetLastBackupLog(backupName)
+			if err != nil {
+				logs = fmt.Sprintf("Failed to retrieve logs: %v", err)
+			}
+			if ok {
+				logs = summary + "\n\n" + logs
+				result = serviceRun(backupName)
+			}
+		}
+		if status == "" {
+			return cmdutil.Errorf("status is required outside of the notify unit")
+		}
+
+		if notifyPreview {
+			return previewNotification(config, status, logs, result)
+		}
+
+		if err := internalbackup.SendNotification(config, status, logs, result); err != nil {
 			return cmdutil.Wrap(err, "sending notification")
 		}
 
@@ -36,3 +84,72 @@ var notifyCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// lastRun returns the last recorded run of a backup.
+func lastRun(backupName string) (internalbackup.HistoryEntry, error) {
+	entries, err := internalbackup.LoadHistory(backupName)
+	if err != nil {
+		return internalbackup.HistoryEntry{}, cmdutil.Wrap(err, "loading history")
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Kind == internalbackup.HistoryRun {
+			return entries[i], nil
+		}
+	}
+	return internalbackup.HistoryEntry{}, cmdutil.Errorf("backup %s has no recorded runs; give a status to preview", backupName)
+}
+
+// serviceRun returns when the backup service's last run started and ended, as
+// far as systemd knows.
+func serviceRun(backupName string) *internalbackup.RunResult {
+	props, err := manager.ShowProperties(internalbackup.BackupServiceName(backupName), "ExecMainStartTimestamp", "ExecMainExitTimestamp")
+	if err != nil {
+		return nil
+	}
+	var result internalbackup.RunResult
+	result.StartTime, _ = systemd.ParseTimestamp(props["ExecMainStartTimestamp"])
+	result.EndTime, _ = systemd.ParseTimestamp(props["ExecMainExitTimestamp"])
+	return &result
+}
+
+// previewNotification prints the rendered notification, or the part of it
+// selected with --part.
+func previewNotification(config *internalbackup.Config, status, logs string, result *internalbackup.RunResult) error {
+	rendered, err := internalbackup.RenderNotification(config, status, logs, result)
+	if err != nil {
+		return cmdutil.Wrap(err, "rendering notification")
+	}
+	return printRendered(rendered, notifyPart)
+}
+
+// printRendered prints a rendered notification or digest, or only its part
+// named by part if that is not empty.
+func printRendered(rendered internalbackup.RenderedNotification, part string) error {
+	switch part {
+	case "subject":
+		fmt.Println(rendered.Subject)
+	case "text":
+		fmt.Print(rendered.Text)
+	case "html":
+		fmt.Println(rendered.HTML)
+	case "":
+		fmt.Println(shared.TitleStyle.Render("Subject"))
+		fmt.Println(rendered.Subject)
+		fmt.Println()
+		fmt.Println(shared.TitleStyle.Render("Text"))
+		fmt.Println(rendered.Text)
+		fmt.Println(shared.TitleStyle.Render("HTML"))
+		fmt.Println(rendered.HTML)
+	default:
+		return cmdutil.Errorf("invalid --part: %q (must be subject, text or html)", part)
+	}
+	return nil
+}
+
+func init() {
+	notifyCmd.Flags().BoolVar(&notifyPreview, "preview", false, "Print the rendered notification instead of sending it")
+	notifyCmd.Flags().StringVar(&notifyPart, "part", "", "With --preview, only print this part (subject, text or html)")
+	_ = notifyCmd.RegisterFlagCompletionFunc("part", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{"subject", "text", "html"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
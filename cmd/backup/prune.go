@@ -0,0 +1,49 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune [backup-name]",
+	Short: "Prune unreferenced data from a restic repository",
+	Long: `Remove data no longer referenced by any snapshot from a restic repository.
+
+cleanup prunes after every forget by default. Setting retention.prune_schedule
+makes cleanup only forget snapshots and installs a separate timer that runs
+this command on that schedule instead.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: getBackupNameCompletions(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backupName := args[0]
+
+		config, err := loadBackupConfig(backupName)
+		if err != nil {
+			return err
+		}
+		if config.Type != internalbackup.BackupTypeRestic {
+			return cmdutil.Errorf("prune is only supported for restic backups, %s is a %s backup", backupName, config.Type)
+		}
+
+		fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("Pruning backup: %s", backupName)))
+		fmt.Println()
+
+		start := time.Now()
+		output, err := internalbackup.Prune(config)
+		end := time.Now()
+		recordHistory(backupName, internalbackup.NewHistoryEntry(internalbackup.HistoryPrune, start, end, output, err))
+		if err != nil {
+			return cmdutil.Wrap(err, "prune failed")
+		}
+
+		fmt.Println()
+		fmt.Println(shared.SuccessStyle.Render(fmt.Sprintf("✓ Prune completed in %.2f seconds", end.Sub(start).Seconds())))
+		return nil
+	},
+}
@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"fmt"
+
+	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+var restoreSnapshot string
+var restoreTarget string
+var restoreInclude []string
+var restoreDryRun bool
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [backup-name]",
+	Short: "Restore files from a backup",
+	Long: `Restore files from a backup.
+
+restic and rsync snapshot backups restore a snapshot (the latest one unless
+--snapshot is given), rsync mirrors copy the backup destination back and
+rclone copies from the remote. Nothing in the target is deleted.
+
+Unless --target is given, files are restored into a new staging directory under
+$XDG_STATE_HOME/quadlet-helper/backups/restore, so live data is never
+overwritten by accident. Move what you need from there, or pass the live
+directory as --target once you are sure.
+
+--include limits the restore to the given paths: relative to each source for
+rsync and rclone, and as restic --include patterns for restic.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: getBackupNameCompletions(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backupName := args[0]
+
+		config, err := loadBackupConfig(backupName)
+		if err != nil {
+			return err
+		}
+		if err := resolveQuadletVolumes(config); err != nil {
+			return err
+		}
+		if err := config.Validate(); err != nil {
+			return cmdutil.Wrap(err, "invalid configuration")
+		}
+		if restoreSnapshot != "" && !config.IsSnapshotMode() {
+			return cmdutil.Errorf("--snapshot is only supported for restic backups and rsync backups in snapshot mode")
+		}
+
+		target := restoreTarget
+		if target == "" {
+			if target, err = internalbackup.DefaultRestoreTarget(backupName); err != nil {
+				return cmdutil.Wrap(err, "choosing staging directory")
+			}
+		}
+
+		title := fmt.Sprintf("Restoring backup: %s", backupName)
+		if restoreDryRun {
+			title += " (dry-run)"
+		}
+		fmt.Println(shared.TitleStyle.Render(title))
+		fmt.Println("  Target: " + shared.FilePathStyle.Render(target))
+		fmt.Println()
+
+		result, err := internalbackup.Restore(config, internalbackup.RestoreOptions{
+			Snapshot: restoreSnapshot,
+			Target:   target,
+			Include:  restoreInclude,
+			DryRun:   restoreDryRun,
+		})
+		if !restoreDryRun {
+			recordHistory(backupName, internalbackup.NewHistoryEntry(internalbackup.HistoryRestore, result.StartTime, result.EndTime, result.Output, result.Error))
+		}
+		if err != nil {
+			return cmdutil.Wrap(err, "restore failed")
+		}
+
+		fmt.Println()
+		if restoreDryRun {
+			fmt.Println(shared.SuccessStyle.Render("✓ Dry-run completed, nothing was restored"))
+			return nil
+		}
+		fmt.Println(shared.SuccessStyle.Render(fmt.Sprintf("✓ Restored into %s in %.2f seconds", target, result.EndTime.Sub(result.StartTime).Seconds())))
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().StringVar(&restoreSnapshot, "snapshot", "", "Snapshot to restore: latest or a snapshot ID (default latest)")
+	restoreCmd.Flags().StringVar(&restoreTarget, "target", "", "Directory to restore into (default: a new staging directory)")
+	restoreCmd.Flags().StringSliceVar(&restoreInclude, "include", nil, "Only restore these paths (repeatable)")
+	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "Show what would be restored without writing anything")
+	_ = restoreCmd.MarkFlagDirname("target")
+	_ = restoreCmd.RegisterFlagCompletionFunc("snapshot", getSnapshotCompletions())
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var runNoNotify bool
+
 var runCmd = &cobra.Command{
 	Use:               "run [backup-name]",
 	Short:             "Run a backup immediately",
@@ -25,10 +27,20 @@ var runCmd = &cobra.Command{
 			return err
 		}
 
-		result, err := internalbackup.Run(config, false)
+		if err := resolveQuadletVolumes(config); err != nil {
+			return err
+		}
+
+		quiesce, err := resolveQuiesce(config)
+		if err != nil {
+			return err
+		}
+
+		result, err := internalbackup.Run(config, internalbackup.RunOptions{Quiesce: quiesce, Manager: manager})
+		recordHistory(backupName, internalbackup.RunEntry(config, result))
 		if err != nil {
-			if config.Notifications.Enabled && config.Notifications.OnFailure {
-				_ = internalbackup.SendNotification(config, "failure", fmt.Sprintf("Error: %v\n\nOutput:\n%s", err, result.Output))
+			if !runNoNotify {
+				_ = internalbackup.SendNotification(config, "failure", fmt.Sprintf("Error: %v\n\nOutput:\n%s", err, result.Output), result)
 			}
 			return cmdutil.Wrap(err, "backup failed")
 		}
@@ -36,10 +48,14 @@ var runCmd = &cobra.Command{
 		fmt.Println()
 		fmt.Println(shared.SuccessStyle.Render(fmt.Sprintf("✓ Backup completed successfully in %.2f seconds", result.EndTime.Sub(result.StartTime).Seconds())))
 
-		if config.Notifications.Enabled && config.Notifications.OnSuccess {
-			_ = internalbackup.SendNotification(config, "success", result.Output)
+		if !runNoNotify {
+			_ = internalbackup.SendNotification(config, "success", result.Output, result)
 		}
 
 		return nil
 	},
 }
+
+func init() {
+	runCmd.Flags().BoolVar(&runNoNotify, "no-notify", false, "Do not send notifications (installed services leave them to their notify unit)")
+}
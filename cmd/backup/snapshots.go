@@ -0,0 +1,66 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/output"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+var snapshotsCmd = &cobra.Command{
+	Use:               "snapshots [backup-name]",
+	Short:             "List the snapshots of a restic backup or rsync snapshot backup",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: getBackupNameCompletions(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backupName := args[0]
+
+		config, err := loadBackupConfig(backupName)
+		if err != nil {
+			return err
+		}
+		if !config.IsSnapshotMode() {
+			return cmdutil.Errorf("%s is a %s mirror, which has no snapshots; use qh backup ls %s to browse it", backupName, config.Type, backupName)
+		}
+
+		snapshots, err := internalbackup.ListSnapshots(config)
+		if err != nil {
+			return cmdutil.Wrap(err, "listing snapshots")
+		}
+
+		switch format := output.Current(); format {
+		case output.JSON, output.YAML:
+			if snapshots == nil {
+				snapshots = []internalbackup.Snapshot{}
+			}
+			return output.Encode(os.Stdout, format, snapshots)
+		case output.Plain:
+			rows := make([][]string, len(snapshots))
+			for i, s := range snapshots {
+				rows[i] = []string{s.ID, s.Time.Format(time.RFC3339), s.Hostname, strings.Join(s.Paths, ","), strings.Join(s.Tags, ",")}
+			}
+			return output.WritePlain(os.Stdout, rows)
+		}
+
+		fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("Snapshots for backup: %s", backupName)))
+		fmt.Println()
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots yet.")
+			return nil
+		}
+
+		rows := make([][]string, len(snapshots))
+		for i, s := range snapshots {
+			rows[i] = []string{s.ShortID, s.Time.Local().Format(timeLayout), s.Hostname, strings.Join(s.Paths, " "), strings.Join(s.Tags, " ")}
+		}
+		shared.PrintTable([]string{"ID", "Time", "Host", "Paths", "Tags"}, rows)
+		fmt.Printf("\n%d snapshot(s). Browse one with: qh backup ls %s --snapshot <id>\n", len(snapshots), backupName)
+		return nil
+	},
+}
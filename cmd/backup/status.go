@@ -2,11 +2,12 @@ package backup
 
 import (
 	"fmt"
+	"os"
 
 	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/output"
 	"github.com/mufeedali/quadlet-helper/internal/shared"
-	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +23,13 @@ var statusCmd = &cobra.Command{
 			return err
 		}
 
+		switch format := output.Current(); format {
+		case output.JSON, output.YAML:
+			return output.Encode(os.Stdout, format, backupStatus(backupName))
+		case output.Plain:
+			return output.WritePlain(os.Stdout, [][]string{backupStatusRow(backupStatus(backupName))})
+		}
+
 		fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("Status for backup: %s", backupName)))
 		fmt.Println()
 
@@ -35,18 +43,18 @@ var statusCmd = &cobra.Command{
 		serviceName := internalbackup.BackupServiceName(backupName)
 
 		fmt.Println(shared.TitleStyle.Render("Timer:"))
-		output, _ := systemd.Status(timerName)
+		output, _ := manager.Status(timerName)
 		fmt.Println(output)
 
 		fmt.Println()
 		fmt.Println(shared.TitleStyle.Render("Service:"))
-		output, _ = systemd.Status(serviceName)
+		output, _ = manager.Status(serviceName)
 		fmt.Println(output)
 
 		// Show next run time
 		fmt.Println()
 		fmt.Println(shared.TitleStyle.Render("Schedule:"))
-		listOutput, err := systemd.ListTimers(timerName)
+		listOutput, err := manager.ListTimers(timerName)
 		fmt.Print(listOutput)
 		if err != nil {
 			return cmdutil.Wrap(err, "getting timer schedule")
@@ -2,6 +2,7 @@ package backup
 
 import (
 	"fmt"
+	"strings"
 
 	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
@@ -32,7 +33,25 @@ var testCmd = &cobra.Command{
 		fmt.Println(shared.SuccessStyle.Render("✓ Configuration is valid"))
 		fmt.Println()
 
-		_, err = internalbackup.Run(config, true)
+		if err := resolveQuadletVolumes(config); err != nil {
+			return err
+		}
+
+		quiesce, err := resolveQuiesce(config)
+		if err != nil {
+			return err
+		}
+		if !quiesce.IsEmpty() {
+			if len(quiesce.Services) > 0 {
+				fmt.Println("Would stop during the backup: " + strings.Join(quiesce.Services, ", "))
+			}
+			if len(quiesce.Containers) > 0 {
+				fmt.Println("Would pause during the backup: " + strings.Join(quiesce.Containers, ", "))
+			}
+			fmt.Println()
+		}
+
+		_, err = internalbackup.Run(config, internalbackup.RunOptions{DryRun: true})
 		if err != nil {
 			return cmdutil.Wrap(err, "dry-run failed")
 		}
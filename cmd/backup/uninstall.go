@@ -24,15 +24,28 @@ var uninstallCmd = &cobra.Command{
 
 		fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("Uninstalling backup: %s", backupName)))
 
-		result, err := systemd.UninstallUserUnits(
-			[]string{internalbackup.BackupTimerName(backupName), internalbackup.BackupServiceName(backupName)},
-			[]string{internalbackup.BackupTimerName(backupName)},
-			[]string{
-				internalbackup.BackupServiceName(backupName),
-				internalbackup.BackupTimerName(backupName),
-				fmt.Sprintf("backup-notify@%s.service", backupName),
-			},
-		)
+		stopUnits := []string{internalbackup.BackupTimerName(backupName), internalbackup.BackupServiceName(backupName)}
+		disableUnits := []string{internalbackup.BackupTimerName(backupName)}
+		removeFiles := []string{
+			internalbackup.BackupServiceName(backupName),
+			internalbackup.BackupTimerName(backupName),
+			internalbackup.BackupNotifyDropInName(backupName),
+		}
+		// The notify template is shared, so it stays while another installed
+		// backup still sends notifications.
+		if !notifyTemplateInUse(backupName) {
+			removeFiles = append(removeFiles, internalbackup.BackupNotifyTemplateName)
+		}
+		// The prune timer only exists if retention.prune_schedule was set at
+		// install time, and the config may have changed since.
+		if pruneTimer := internalbackup.BackupPruneTimerName(backupName); manager.HasUnitFile(pruneTimer) {
+			pruneService := internalbackup.BackupPruneServiceName(backupName)
+			stopUnits = append(stopUnits, pruneTimer, pruneService)
+			disableUnits = append(disableUnits, pruneTimer)
+			removeFiles = append(removeFiles, pruneService, pruneTimer)
+		}
+
+		result, err := systemd.UninstallUserUnits(manager, stopUnits, disableUnits, removeFiles)
 		if err != nil {
 			return err
 		}
@@ -1,7 +1,10 @@
 package backup
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	internalbackup "github.com/mufeedali/quadlet-helper/internal/backup"
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
@@ -24,7 +27,13 @@ var verifyCmd = &cobra.Command{
 			return err
 		}
 
+		if err := resolveQuadletVolumes(config); err != nil {
+			return err
+		}
+
+		start := time.Now()
 		result, err := internalbackup.Verify(config)
+		recordHistory(backupName, verifyHistoryEntry(start, result, err))
 		if err != nil {
 			return cmdutil.Wrap(err, "verification error")
 		}
@@ -46,3 +55,16 @@ var verifyCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// verifyHistoryEntry records the outcome of a verification that started at
+// start. A verification that ran but found problems counts as a failure.
+func verifyHistoryEntry(start time.Time, result *internalbackup.VerifyResult, err error) internalbackup.HistoryEntry {
+	var output string
+	if result != nil {
+		output = strings.TrimSpace(result.Message + "\n" + result.Details)
+		if err == nil && !result.Success {
+			err = errors.New(result.Message)
+		}
+	}
+	return internalbackup.NewHistoryEntry(internalbackup.HistoryVerify, start, time.Now(), output, err)
+}
@@ -1,9 +1,14 @@
 package cloudflare
 
 import (
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
 )
 
+// manager runs the systemd operations of every command in this package.
+// Tests replace it with a systemd.Fake.
+var manager systemd.Manager = systemd.NewManager()
+
 var CloudflareCmd = &cobra.Command{
 	Use:   "cloudflare",
 	Short: "Manage the Cloudflare IP updater service",
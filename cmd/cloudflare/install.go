@@ -49,7 +49,7 @@ var installCmd = &cobra.Command{
 			return cmdutil.Wrap(err, "finding executable path")
 		}
 
-		paths, err := systemd.InstallUserUnits([]systemd.UserUnitFile{
+		paths, err := systemd.InstallUserUnits(manager, []systemd.UserUnitFile{
 			{Name: "cloudflare-ip-updater.service", Content: fmt.Sprintf(serviceTemplate, executablePath), Mode: 0644},
 			{Name: "cloudflare-ip-updater.timer", Content: timerTemplate, Mode: 0644},
 		}, []string{"cloudflare-ip-updater.timer"})
@@ -62,12 +62,12 @@ var installCmd = &cobra.Command{
 
 		fmt.Println(shared.SuccessStyle.Render("\n✓ Installation complete!"))
 		fmt.Println(shared.TitleStyle.Render("Timer status:"))
-		output, err := systemd.Status("cloudflare-ip-updater.timer")
+		output, err := manager.Status("cloudflare-ip-updater.timer")
 		fmt.Println(output)
 		if err != nil {
 			return cmdutil.Wrap(err, "getting timer status")
 		}
-		active, err := systemd.IsActive("cloudflare-ip-updater.timer")
+		active, err := manager.IsActive("cloudflare-ip-updater.timer")
 		if err != nil {
 			return cmdutil.Wrap(err, "checking timer active state")
 		}
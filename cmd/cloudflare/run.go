@@ -12,7 +12,6 @@ import (
 
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
 	"github.com/mufeedali/quadlet-helper/internal/shared"
-	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.yaml.in/yaml/v3"
@@ -223,7 +222,7 @@ func valueAsStringSlice(value any) ([]string, bool) {
 
 func restartTraefik() error {
 	fmt.Println(shared.TitleStyle.Render("Restarting Traefik container..."))
-	if _, err := systemd.Restart("traefik.container"); err != nil {
+	if _, err := manager.Restart("traefik.container"); err != nil {
 		fmt.Println(shared.CrossMark + " " + shared.ErrorStyle.Render(fmt.Sprintf("Failed to restart Traefik: %v", err)))
 		fmt.Println(shared.InfoMark + " Please restart manually: " + "systemctl --user restart traefik.container")
 		return cmdutil.Wrap(err, "restarting Traefik")
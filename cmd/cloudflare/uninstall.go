@@ -16,6 +16,7 @@ var uninstallCmd = &cobra.Command{
 		fmt.Println(shared.TitleStyle.Render("Uninstalling Cloudflare IP Updater..."))
 
 		result, err := systemd.UninstallUserUnits(
+			manager,
 			[]string{"cloudflare-ip-updater.timer", "cloudflare-ip-updater.service"},
 			[]string{"cloudflare-ip-updater.timer"},
 			[]string{"cloudflare-ip-updater.service", "cloudflare-ip-updater.timer"},
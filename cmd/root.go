@@ -8,9 +8,12 @@ import (
 	"github.com/mufeedali/quadlet-helper/cmd/backup"
 	"github.com/mufeedali/quadlet-helper/cmd/cloudflare"
 	"github.com/mufeedali/quadlet-helper/cmd/generate"
+	"github.com/mufeedali/quadlet-helper/cmd/stack"
 	"github.com/mufeedali/quadlet-helper/cmd/unit"
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/output"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -20,12 +23,28 @@ var rootCmd = &cobra.Command{
 	Long:          `qh is a tool for managing quadlet units and related systemd helpers.`,
 	SilenceErrors: true,
 	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		applyLegacyEmailTLS(cmd.Flags())
+		_, err := output.ParseFormat(viper.GetString("output"))
+		return err
+	},
+}
+
+// applyLegacyEmailTLS passes the deprecated boolean --email-tls on as
+// email.tls, unless --email-tls-mode is given too.
+func applyLegacyEmailTLS(flags *pflag.FlagSet) {
+	if !flags.Changed("email-tls") || flags.Changed("email-tls-mode") {
+		return
+	}
+	if enabled, err := flags.GetBool("email-tls"); err == nil {
+		viper.Set("email.tls", enabled)
+	}
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		cmdutil.PrintError(err)
-		os.Exit(1)
+		os.Exit(cmdutil.ExitCode(err))
 	}
 }
 
@@ -44,20 +63,43 @@ func init() {
 		os.Exit(1)
 	}
 
+	rootCmd.PersistentFlags().StringP("output", "o", "table", "Output format for listing and status commands (table, json, yaml, plain)")
+	if err := viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output")); err != nil {
+		fmt.Println("Error binding flag:", err)
+		os.Exit(1)
+	}
+	_ = rootCmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return output.Formats, cobra.ShellCompDirectiveNoFileComp
+	})
+
 	// Email configuration
 	rootCmd.PersistentFlags().String("email-host", "", "SMTP host for email notifications")
 	rootCmd.PersistentFlags().Int("email-port", 587, "SMTP port for email notifications")
 	rootCmd.PersistentFlags().String("email-user", "", "SMTP username for email notifications")
+	rootCmd.PersistentFlags().String("email-auth", "", "SMTP authentication: plain, login or cram-md5 (default plain)")
 	rootCmd.PersistentFlags().String("email-password-file", "", "Path to file containing SMTP password")
-	rootCmd.PersistentFlags().Bool("email-tls", true, "Use TLS for SMTP connection")
+	rootCmd.PersistentFlags().String("email-password-credential", "", "Name of the systemd credential containing the SMTP password")
+	rootCmd.PersistentFlags().String("email-password-env", "", "Environment variable containing the SMTP password")
+	rootCmd.PersistentFlags().String("email-password-command", "", "Command printing the SMTP password, e.g. \"pass show smtp\"")
+	rootCmd.PersistentFlags().String("email-tls-mode", "", "SMTP TLS mode: starttls, implicit or none (default implicit on port 465, starttls otherwise)")
+	rootCmd.PersistentFlags().Bool("email-tls", false, "Use TLS for SMTP, picking the mode by port")
+	_ = rootCmd.PersistentFlags().MarkDeprecated("email-tls", "use --email-tls-mode instead")
+	rootCmd.PersistentFlags().String("email-tls-ca-file", "", "PEM file with a CA to trust for the SMTP server")
+	rootCmd.PersistentFlags().Bool("email-tls-insecure-skip-verify", false, "Do not verify the SMTP server's certificate")
 	rootCmd.PersistentFlags().String("email-from", "", "Default from address for email notifications")
-	rootCmd.PersistentFlags().String("email-to", "", "Default to address for email notifications")
+	rootCmd.PersistentFlags().String("email-to", "", "Default to addresses for email notifications, comma-separated")
 
 	_ = viper.BindPFlag("email.host", rootCmd.PersistentFlags().Lookup("email-host"))
 	_ = viper.BindPFlag("email.port", rootCmd.PersistentFlags().Lookup("email-port"))
 	_ = viper.BindPFlag("email.user", rootCmd.PersistentFlags().Lookup("email-user"))
+	_ = viper.BindPFlag("email.auth", rootCmd.PersistentFlags().Lookup("email-auth"))
 	_ = viper.BindPFlag("email.passwordfile", rootCmd.PersistentFlags().Lookup("email-password-file"))
-	_ = viper.BindPFlag("email.tls", rootCmd.PersistentFlags().Lookup("email-tls"))
+	_ = viper.BindPFlag("email.password_credential", rootCmd.PersistentFlags().Lookup("email-password-credential"))
+	_ = viper.BindPFlag("email.password_env", rootCmd.PersistentFlags().Lookup("email-password-env"))
+	_ = viper.BindPFlag("email.password_command", rootCmd.PersistentFlags().Lookup("email-password-command"))
+	_ = viper.BindPFlag("email.tls", rootCmd.PersistentFlags().Lookup("email-tls-mode"))
+	_ = viper.BindPFlag("email.tls_ca_file", rootCmd.PersistentFlags().Lookup("email-tls-ca-file"))
+	_ = viper.BindPFlag("email.tls_insecure_skip_verify", rootCmd.PersistentFlags().Lookup("email-tls-insecure-skip-verify"))
 	_ = viper.BindPFlag("email.from", rootCmd.PersistentFlags().Lookup("email-from"))
 	_ = viper.BindPFlag("email.to", rootCmd.PersistentFlags().Lookup("email-to"))
 
@@ -65,6 +107,7 @@ func init() {
 	rootCmd.AddCommand(backup.BackupCmd)
 	rootCmd.AddCommand(cloudflare.CloudflareCmd)
 	rootCmd.AddCommand(generate.GenerateCmd)
+	rootCmd.AddCommand(stack.StackCmd)
 	rootCmd.AddCommand(unit.UnitCmd)
 }
 
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func TestEmailTLSFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want any
+	}{
+		{nil, nil},
+		{[]string{"--email-tls"}, true},
+		{[]string{"--email-tls=false"}, false},
+		{[]string{"--email-tls-mode", "starttls"}, "starttls"},
+		{[]string{"--email-tls", "--email-tls-mode=implicit"}, "implicit"},
+	}
+	for _, tt := range tests {
+		viper.Reset()
+		flags := pflag.NewFlagSet("qh", pflag.ContinueOnError)
+		flags.Bool("email-tls", false, "")
+		flags.String("email-tls-mode", "", "")
+		_ = viper.BindPFlag("email.tls", flags.Lookup("email-tls-mode"))
+		args := append(tt.args, "mybackup")
+		if err := flags.Parse(args); err != nil {
+			t.Fatalf("Parse(%v) error = %v", args, err)
+		}
+		applyLegacyEmailTLS(flags)
+
+		if flags.NArg() != 1 || flags.Arg(0) != "mybackup" {
+			t.Errorf("Parse(%v) args = %v, want [mybackup]", args, flags.Args())
+		}
+		got := viper.Get("email.tls")
+		if tt.want == nil {
+			if got != "" {
+				t.Errorf("Parse(%v) email.tls = %#v, want unset", args, got)
+			}
+		} else if got != tt.want {
+			t.Errorf("Parse(%v) email.tls = %#v, want %#v", args, got, tt.want)
+		}
+	}
+	viper.Reset()
+}
@@ -0,0 +1,27 @@
+package stack
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// stackCompletionFunc completes the stack name argument.
+func stackCompletionFunc(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	stacks, err := loadStacks()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var completions []string
+	for _, s := range stacks {
+		if strings.HasPrefix(s.Name, toComplete) {
+			completions = append(completions, s.Name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
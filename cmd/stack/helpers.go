@@ -0,0 +1,80 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/viper"
+)
+
+// loadStacks discovers the quadlet files under --containers-path and groups
+// them into stacks.
+func loadStacks() ([]quadlet.Stack, error) {
+	containersPath := viper.GetString("containers-path")
+	realContainersPath := shared.ResolveContainersDir(containersPath)
+
+	units, err := quadlet.Discover(realContainersPath)
+	if err != nil {
+		return nil, cmdutil.Wrap(err, "reading quadlet files")
+	}
+	return quadlet.Stacks(realContainersPath, units), nil
+}
+
+// findStack returns the stack with the given name.
+func findStack(name string) (quadlet.Stack, error) {
+	stacks, err := loadStacks()
+	if err != nil {
+		return quadlet.Stack{}, err
+	}
+	for _, s := range stacks {
+		if s.Name == name {
+			for _, u := range s.Units {
+				if u.Err != nil {
+					fmt.Fprintln(os.Stderr, shared.WarningStyle.Render(fmt.Sprintf("Warning: %s: %v", u.Path, u.Err)))
+				}
+			}
+			return s, nil
+		}
+	}
+	return quadlet.Stack{}, cmdutil.Errorf("stack %q not found", name)
+}
+
+// runStackAction applies action to every service of the named stack in a
+// single systemctl call, so systemd orders them by their dependencies.
+func runStackAction(name string, title string, noReload bool, reload bool, action func([]string) (string, error), failure string, success string) error {
+	s, err := findStack(name)
+	if err != nil {
+		return err
+	}
+	services := s.Services()
+
+	fmt.Println(shared.TitleStyle.Render(fmt.Sprintf(title, name)))
+	fmt.Println("  Units: " + strings.Join(services, " "))
+	if reload {
+		if !noReload {
+			if err := cmdutil.ReloadDaemon(manager); err != nil {
+				return err
+			}
+		} else {
+			cmdutil.PrintSkipReload()
+		}
+	}
+
+	output, err := action(services)
+	if err != nil {
+		if strings.TrimSpace(output) == "" {
+			return cmdutil.Wrap(err, failure)
+		}
+		return fmt.Errorf("%s: %w\n%s", failure, err, output)
+	}
+
+	if strings.TrimSpace(output) != "" {
+		fmt.Println(output)
+	}
+	fmt.Println(shared.SuccessStyle.Render(fmt.Sprintf(success, name, len(services))))
+	return nil
+}
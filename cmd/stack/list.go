@@ -0,0 +1,93 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mufeedali/quadlet-helper/internal/output"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+// stackSummary is the machine-readable form of a row in "qh stack list".
+type stackSummary struct {
+	Name    string   `json:"name" yaml:"name"`
+	Units   []string `json:"units" yaml:"units"`
+	Running int      `json:"running" yaml:"running"`
+	// Runnable counts the units that have a running state; volumes, images
+	// and builds are one-shot units and are not included.
+	Runnable int `json:"runnable" yaml:"runnable"`
+	// Unknown is set when the running state could not be queried.
+	Unknown bool `json:"unknown,omitempty" yaml:"unknown,omitempty"`
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stacks and how many of their units are running",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stacks, err := loadStacks()
+		if err != nil {
+			return err
+		}
+
+		format := output.Current()
+		if len(stacks) == 0 && !format.Structured() {
+			if format == output.Table {
+				fmt.Println(shared.WarningStyle.Render("No stacks found."))
+			}
+			return nil
+		}
+
+		summaries := make([]stackSummary, 0, len(stacks))
+		for _, s := range stacks {
+			summary := stackSummary{Name: s.Name, Units: make([]string, 0, len(s.Units))}
+			var services []string
+			for _, u := range s.Units {
+				summary.Units = append(summary.Units, u.Name())
+				switch u.UnitType() {
+				case "volume", "image", "build":
+				default:
+					services = append(services, u.ServiceName())
+				}
+			}
+
+			summary.Runnable = len(services)
+			if len(services) > 0 {
+				active, err := manager.IsActiveMultiple(services)
+				summary.Unknown = err != nil
+				for _, a := range active {
+					if a {
+						summary.Running++
+					}
+				}
+			}
+			summaries = append(summaries, summary)
+		}
+
+		switch format {
+		case output.JSON, output.YAML:
+			return output.Encode(os.Stdout, format, summaries)
+		case output.Plain:
+			rows := make([][]string, len(summaries))
+			for i, s := range summaries {
+				rows[i] = []string{s.Name, strings.Join(s.Units, ","), strconv.Itoa(s.Running), strconv.Itoa(s.Runnable)}
+			}
+			return output.WritePlain(os.Stdout, rows)
+		}
+
+		rows := make([][]string, len(summaries))
+		for i, s := range summaries {
+			running := "-"
+			if s.Unknown {
+				running = "?"
+			} else if s.Runnable > 0 {
+				running = fmt.Sprintf("%d/%d", s.Running, s.Runnable)
+			}
+			rows[i] = []string{s.Name, strings.Join(s.Units, ", "), running}
+		}
+		shared.PrintTable([]string{"Stack", "Units", "Running"}, rows)
+		return nil
+	},
+}
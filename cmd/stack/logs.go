@@ -0,0 +1,53 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:               "logs <stack>",
+	Short:             "View the interleaved logs of every unit in a stack",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: stackCompletionFunc,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := findStack(args[0])
+		if err != nil {
+			return err
+		}
+
+		follow, _ := cmd.Flags().GetBool("follow")
+		lines, _ := cmd.Flags().GetInt("lines")
+
+		fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("Logs for stack %s...", s.Name)))
+
+		argsList := []string{"--user"}
+		for _, svc := range s.Services() {
+			argsList = append(argsList, "-u", svc)
+		}
+		if lines > 0 {
+			argsList = append(argsList, "-n", fmt.Sprint(lines))
+		}
+		if follow {
+			argsList = append(argsList, "-f")
+		}
+
+		c := exec.Command("journalctl", argsList...)
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			return cmdutil.Wrap(err, "getting logs")
+		}
+		return nil
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolP("follow", "f", false, "Follow log output")
+	logsCmd.Flags().IntP("lines", "n", 0, "Number of recent lines to show (0 for all)")
+}
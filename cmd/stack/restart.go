@@ -0,0 +1,21 @@
+package stack
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var restartNoReload bool
+
+var restartCmd = &cobra.Command{
+	Use:               "restart <stack>",
+	Short:             "Restart every unit in a stack",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: stackCompletionFunc,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStackAction(args[0], "Restarting stack %s...", restartNoReload, true, manager.RestartMultiple, "restarting stack", "✓ Successfully restarted %s (%d unit(s))")
+	},
+}
+
+func init() {
+	restartCmd.Flags().BoolVar(&restartNoReload, "no-reload", false, "Skip systemctl daemon-reload step")
+}
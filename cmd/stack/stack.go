@@ -0,0 +1,27 @@
+package stack
+
+import (
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
+	"github.com/spf13/cobra"
+)
+
+// manager runs the systemd operations of every command in this package.
+// Tests replace it with a systemd.Fake.
+var manager systemd.Manager = systemd.NewManager()
+
+var StackCmd = &cobra.Command{
+	Use:   "stack",
+	Short: "Manage groups of quadlet units",
+	Long: `A stack is a group of quadlet units that are managed together. Each
+subdirectory of --containers-path is a stack; a unit can join a different stack
+by setting X-Qh-Stack=<name> in its [Unit] section.`,
+}
+
+func init() {
+	StackCmd.AddCommand(listCmd)
+	StackCmd.AddCommand(logsCmd)
+	StackCmd.AddCommand(restartCmd)
+	StackCmd.AddCommand(startCmd)
+	StackCmd.AddCommand(statusCmd)
+	StackCmd.AddCommand(stopCmd)
+}
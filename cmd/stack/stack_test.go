@@ -0,0 +1,59 @@
+package stack
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mufeedali/quadlet-helper/internal/systemd/systemdtest"
+)
+
+func TestStackStartAndStop(t *testing.T) {
+	fake := systemdtest.UseFake(t, &manager)
+	systemdtest.UseContainersDir(t, map[string]string{
+		"media/jellyfin.container": "[Container]\nImage=jellyfin\n",
+		"media/media.network":      "[Network]\n",
+		"proxy/traefik.container":  "[Unit]\nX-Qh-Stack=media\n\n[Container]\nImage=traefik\n",
+		"proxy/proxy.network":      "[Network]\n",
+	})
+
+	s, err := findStack("media")
+	if err != nil {
+		t.Fatalf("findStack() error = %v", err)
+	}
+	want := []string{"jellyfin.service", "media-network.service", "traefik.service"}
+	if got := s.Services(); !slices.Equal(got, want) {
+		t.Fatalf("Services() = %v, want %v", got, want)
+	}
+
+	if err := startCmd.RunE(startCmd, []string{"media"}); err != nil {
+		t.Fatalf("start error = %v", err)
+	}
+	wantCalls := []string{"daemon-reload"}
+	for _, service := range want {
+		wantCalls = append(wantCalls, "start "+service)
+	}
+	if !slices.Equal(fake.Calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", fake.Calls, wantCalls)
+	}
+	if fake.Active["proxy-network.service"] {
+		t.Error("proxy.network started, but it belongs to the proxy stack")
+	}
+
+	fake.Calls = nil
+	if err := stopCmd.RunE(stopCmd, []string{"media"}); err != nil {
+		t.Fatalf("stop error = %v", err)
+	}
+	for _, service := range want {
+		if fake.Active[service] {
+			t.Errorf("%s still active after stop", service)
+		}
+	}
+	if got := strings.Join(fake.Calls, ","); strings.Contains(got, "daemon-reload") {
+		t.Errorf("stop reloaded the daemon: %v", fake.Calls)
+	}
+
+	if err := startCmd.RunE(startCmd, []string{"missing"}); err == nil {
+		t.Error("start of a missing stack error = nil")
+	}
+}
@@ -0,0 +1,21 @@
+package stack
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var startNoReload bool
+
+var startCmd = &cobra.Command{
+	Use:               "start <stack>",
+	Short:             "Start every unit in a stack",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: stackCompletionFunc,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStackAction(args[0], "Starting stack %s...", startNoReload, true, manager.StartMultiple, "starting stack", "✓ Successfully started %s (%d unit(s))")
+	},
+}
+
+func init() {
+	startCmd.Flags().BoolVar(&startNoReload, "no-reload", false, "Skip systemctl daemon-reload step")
+}
@@ -0,0 +1,63 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/output"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:               "status <stack>",
+	Short:             "Get the status of every unit in a stack",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: stackCompletionFunc,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := findStack(args[0])
+		if err != nil {
+			return err
+		}
+
+		if format := output.Current(); format != output.Table {
+			return printStackStatus(s, format)
+		}
+
+		statusText, err := manager.Status(s.Services()...)
+		fmt.Println(statusText)
+		// systemctl status fails for inactive units too, so a failure is
+		// only reported if it printed nothing.
+		if statusText == "" {
+			if err != nil {
+				return cmdutil.Wrap(err, "getting stack status")
+			}
+			return cmdutil.Errorf("no status output returned")
+		}
+		return nil
+	},
+}
+
+// printStackStatus prints the state of every unit in the stack as JSON, YAML
+// or tab-separated text.
+func printStackStatus(s quadlet.Stack, format output.Format) error {
+	statuses := make([]quadlet.UnitStatus, len(s.Units))
+	for i, u := range s.Units {
+		props, err := manager.ShowProperties(u.ServiceName(), "ActiveState", "SubState")
+		if err != nil {
+			return cmdutil.Wrap(err, "getting status of %s", u.ServiceName())
+		}
+		statuses[i] = u.Info(props["ActiveState"], props["SubState"])
+	}
+
+	if format.Structured() {
+		return output.Encode(os.Stdout, format, statuses)
+	}
+	rows := make([][]string, len(statuses))
+	for i, st := range statuses {
+		rows[i] = []string{st.Name, st.Type, st.Path, strconv.FormatBool(st.Enabled), st.ActiveState, st.SubState}
+	}
+	return output.WritePlain(os.Stdout, rows)
+}
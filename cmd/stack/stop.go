@@ -0,0 +1,15 @@
+package stack
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var stopCmd = &cobra.Command{
+	Use:               "stop <stack>",
+	Short:             "Stop every unit in a stack",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: stackCompletionFunc,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStackAction(args[0], "Stopping stack %s...", false, false, manager.StopMultiple, "stopping stack", "✓ Successfully stopped %s (%d unit(s))")
+	},
+}
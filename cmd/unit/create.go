@@ -0,0 +1,269 @@
+package unit
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var validUnitName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_\-.]*$`)
+
+// createTypes lists the quadlet types that "unit create" can scaffold.
+var createTypes = []string{"container", "pod", "network", "volume"}
+
+// unitSpec holds everything the create wizard collects for a new unit.
+type unitSpec struct {
+	Name            string
+	Type            string
+	Description     string
+	Image           string
+	PublishPorts    []string
+	Volumes         []string
+	Networks        []string
+	EnvironmentFile []string
+	Labels          []string
+	Enable          bool
+}
+
+var createCmd = &cobra.Command{
+	Use:   "create <unit-name>",
+	Short: "Create a new quadlet unit file",
+	Long: `Create a new quadlet unit file under --containers-path.
+
+Without any content flags an interactive wizard asks for each value. Passing
+flags such as --image or --publish skips the wizard and writes the file directly.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		spec := &unitSpec{Name: args[0]}
+		spec.Type, _ = cmd.Flags().GetString("type")
+		spec.Description, _ = cmd.Flags().GetString("description")
+		spec.Image, _ = cmd.Flags().GetString("image")
+		spec.PublishPorts, _ = cmd.Flags().GetStringSlice("publish")
+		spec.Volumes, _ = cmd.Flags().GetStringSlice("volume")
+		spec.Networks, _ = cmd.Flags().GetStringSlice("network")
+		spec.EnvironmentFile, _ = cmd.Flags().GetStringSlice("env-file")
+		spec.Labels, _ = cmd.Flags().GetStringSlice("label")
+		spec.Enable, _ = cmd.Flags().GetBool("enable")
+		validate, _ := cmd.Flags().GetBool("validate")
+		reload, _ := cmd.Flags().GetBool("reload")
+
+		if !validUnitName.MatchString(spec.Name) {
+			return cmdutil.Errorf("unit name %q contains invalid characters (only alphanumerics, hyphens, underscores, and dots are allowed)", spec.Name)
+		}
+		if !slices.Contains(createTypes, spec.Type) {
+			return cmdutil.Errorf("unsupported unit type %q (must be one of %s)", spec.Type, strings.Join(createTypes, ", "))
+		}
+		// "web.container" names the same unit as "web".
+		spec.Name = strings.TrimSuffix(spec.Name, "."+spec.Type)
+		for _, t := range createTypes {
+			if strings.HasSuffix(spec.Name, "."+t) {
+				return cmdutil.Errorf("unit name %q ends in .%s, but the unit type is %s (set it with --type)", args[0], t, spec.Type)
+			}
+		}
+
+		containersPath := viper.GetString("containers-path")
+		realContainersPath := shared.ResolveContainersDir(containersPath)
+		fileName := spec.Name + "." + spec.Type
+
+		existing, err := findQuadletFile(realContainersPath, fileName)
+		if err != nil {
+			return cmdutil.Wrap(err, "searching for existing units")
+		}
+		if existing != "" {
+			return cmdutil.Errorf("unit %s already exists at %s", fileName, existing)
+		}
+
+		interactive := true
+		for _, name := range []string{"description", "image", "publish", "volume", "network", "env-file", "label", "enable"} {
+			if cmd.Flags().Changed(name) {
+				interactive = false
+				break
+			}
+		}
+
+		reader := bufio.NewReader(cmd.InOrStdin())
+		if interactive {
+			fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("Create New %s Unit", spec.Type)))
+			fmt.Println()
+			askUnitSpec(reader, spec)
+		}
+
+		if spec.Type == "container" && spec.Image == "" {
+			return cmdutil.Errorf("an image is required for container units")
+		}
+
+		path := filepath.Join(realContainersPath, spec.Name, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return cmdutil.Wrap(err, "creating unit directory")
+		}
+		if err := os.WriteFile(path, buildUnitFile(spec).Bytes(), 0644); err != nil {
+			return cmdutil.Wrap(err, "writing unit file")
+		}
+
+		fmt.Println()
+		fmt.Println(shared.SuccessStyle.Render(fmt.Sprintf("✓ Created %s", fileName)))
+		fmt.Println(shared.FilePathStyle.Render(path))
+
+		// --validate and --reload answer the wizard's questions in advance.
+		if interactive && !cmd.Flags().Changed("validate") {
+			fmt.Println()
+			validate = askYesNo(reader, "Validate the new unit now? (y/n): ")
+		}
+		if interactive && !cmd.Flags().Changed("reload") {
+			reload = askYesNo(reader, "Reload the systemd daemon now? (y/n): ")
+		}
+
+		if validate {
+			serviceName := quadlet.ServiceName(spec.Name, spec.Type)
+			fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("Validating %s...", spec.Name)))
+			ok, output := runValidate(serviceName)
+			if !ok {
+				fmt.Println(output)
+				return cmdutil.Errorf("validation failed for %s", spec.Name)
+			}
+			fmt.Println(shared.SuccessStyle.Render(fmt.Sprintf("✓ %s is valid.", spec.Name)))
+		}
+
+		if reload {
+			if err := cmdutil.ReloadDaemon(manager); err != nil {
+				return err
+			}
+		}
+
+		fmt.Println()
+		fmt.Println("Next steps:")
+		fmt.Printf("  Start the unit: %s\n", shared.FilePathStyle.Render(fmt.Sprintf("qh unit start %s", spec.Name)))
+		return nil
+	},
+}
+
+// askUnitSpec fills spec interactively, in the order the values appear in the file.
+func askUnitSpec(reader *bufio.Reader, spec *unitSpec) {
+	spec.Description = askString(reader, "Description (optional): ")
+
+	switch spec.Type {
+	case "container":
+		spec.Image = askString(reader, "Image (e.g., docker.io/library/nginx:latest): ")
+		spec.PublishPorts = askList(reader, "Published ports, comma-separated (e.g., 8080:80): ")
+		spec.Volumes = askList(reader, "Volumes, comma-separated (e.g., ./data:/data:Z, db.volume:/var/lib/db): ")
+		spec.Networks = askList(reader, "Networks, comma-separated (e.g., proxy.network): ")
+		spec.EnvironmentFile = askList(reader, "Environment files, comma-separated (e.g., .env): ")
+	case "pod":
+		spec.PublishPorts = askList(reader, "Published ports, comma-separated (e.g., 8080:80): ")
+		spec.Networks = askList(reader, "Networks, comma-separated (e.g., proxy.network): ")
+	}
+
+	spec.Labels = askList(reader, "Labels, comma-separated (e.g., io.containers.autoupdate=registry): ")
+
+	if spec.Type == "container" || spec.Type == "pod" {
+		spec.Enable = askYesNo(reader, "Start on boot? (y/n): ")
+	}
+}
+
+// buildUnitFile renders a unitSpec as a quadlet file.
+func buildUnitFile(spec *unitSpec) *quadlet.File {
+	file := &quadlet.File{}
+
+	unit := file.AddSection("Unit")
+	description := spec.Description
+	if description == "" {
+		description = spec.Name
+	}
+	unit.Set("Description", description)
+
+	main := file.AddSection(quadlet.MainSection(spec.Type))
+	if spec.Image != "" {
+		main.Set("Image", spec.Image)
+	}
+	for _, port := range spec.PublishPorts {
+		main.Add("PublishPort", port)
+	}
+	for _, volume := range spec.Volumes {
+		main.Add("Volume", volume)
+	}
+	for _, network := range spec.Networks {
+		main.Add("Network", network)
+	}
+	for _, envFile := range spec.EnvironmentFile {
+		main.Add("EnvironmentFile", envFile)
+	}
+	for _, label := range spec.Labels {
+		main.Add("Label", label)
+	}
+
+	if spec.Enable {
+		file.AddSection("Install").Set("WantedBy", "multi-user.target default.target")
+	}
+	return file
+}
+
+// findQuadletFile looks for a file with the given name anywhere under root and
+// returns its path, or "" if there is none.
+func findQuadletFile(root, fileName string) (string, error) {
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	var found string
+	err := shared.WalkWithSymlinks(root, func(path string, d fs.DirEntry) error {
+		if found == "" && !d.IsDir() && d.Name() == fileName {
+			found = path
+		}
+		return nil
+	})
+	return found, err
+}
+
+func askString(reader *bufio.Reader, prompt string) string {
+	fmt.Print(prompt)
+	response, _ := reader.ReadString('\n')
+	return strings.TrimSpace(response)
+}
+
+func askList(reader *bufio.Reader, prompt string) []string {
+	var values []string
+	for value := range strings.SplitSeq(askString(reader, prompt), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func askYesNo(reader *bufio.Reader, prompt string) bool {
+	response := strings.ToLower(askString(reader, prompt))
+	return response == "y" || response == "yes"
+}
+
+func init() {
+	addCreateFlags(createCmd)
+}
+
+// addCreateFlags defines the flags of the create command on cmd.
+func addCreateFlags(cmd *cobra.Command) {
+	cmd.Flags().String("type", "container", "Quadlet unit type to create")
+	cmd.Flags().String("description", "", "Unit description")
+	cmd.Flags().String("image", "", "Container image")
+	cmd.Flags().StringSliceP("publish", "p", nil, "Published port (repeatable)")
+	cmd.Flags().StringSliceP("volume", "v", nil, "Volume mount (repeatable)")
+	cmd.Flags().StringSlice("network", nil, "Network to join (repeatable)")
+	cmd.Flags().StringSlice("env-file", nil, "Environment file (repeatable)")
+	cmd.Flags().StringSlice("label", nil, "Label as key=value (repeatable)")
+	cmd.Flags().Bool("enable", false, "Add an [Install] section so the unit starts on boot")
+	cmd.Flags().Bool("validate", false, "Validate the unit after creating it")
+	cmd.Flags().Bool("reload", false, "Run systemctl daemon-reload after creating the unit")
+	_ = cmd.RegisterFlagCompletionFunc("type", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return createTypes, cobra.ShellCompDirectiveNoFileComp
+	})
+}
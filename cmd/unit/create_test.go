@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mufeedali/quadlet-helper/internal/systemd/systemdtest"
+	"github.com/spf13/cobra"
+)
+
+// newCreateCmd returns a create command with fresh flags, reading the wizard's
+// answers from input.
+func newCreateCmd(input string, args ...string) *cobra.Command {
+	cmd := &cobra.Command{Use: createCmd.Use, Args: createCmd.Args, RunE: createCmd.RunE}
+	addCreateFlags(cmd)
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetArgs(args)
+	cmd.SilenceUsage = true
+	return cmd
+}
+
+func TestBuildUnitFile(t *testing.T) {
+	tests := []struct {
+		name string
+		spec unitSpec
+		want string
+	}{
+		{
+			name: "container",
+			spec: unitSpec{
+				Name:            "web",
+				Type:            "container",
+				Description:     "Web server",
+				Image:           "docker.io/library/nginx:latest",
+				PublishPorts:    []string{"8080:80", "8443:443"},
+				Volumes:         []string{"./data:/data:Z"},
+				Networks:        []string{"proxy.network"},
+				EnvironmentFile: []string{".env"},
+				Labels:          []string{"io.containers.autoupdate=registry"},
+				Enable:          true,
+			},
+			want: "[Unit]\nDescription=Web server\n\n[Container]\nImage=docker.io/library/nginx:latest\nPublishPort=8080:80\nPublishPort=8443:443\nVolume=./data:/data:Z\nNetwork=proxy.network\nEnvironmentFile=.env\nLabel=io.containers.autoupdate=registry\n\n[Install]\nWantedBy=multi-user.target default.target\n",
+		},
+		{
+			name: "network without description",
+			spec: unitSpec{Name: "proxy", Type: "network"},
+			want: "[Unit]\nDescription=proxy\n\n[Network]\n",
+		},
+		{
+			name: "pod",
+			spec: unitSpec{Name: "immich", Type: "pod", PublishPorts: []string{"2283:2283"}},
+			want: "[Unit]\nDescription=immich\n\n[Pod]\nPublishPort=2283:2283\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(buildUnitFile(&tt.spec).Bytes()); got != tt.want {
+				t.Errorf("buildUnitFile() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateWithFlags(t *testing.T) {
+	fake := systemdtest.UseFake(t, &manager)
+	dir := systemdtest.UseContainersDir(t, nil)
+
+	cmd := newCreateCmd("", "web", "--image", "nginx", "-p", "8080:80", "-p", "8443:443", "--enable", "--reload")
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "web", "web.container"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{"Image=nginx\n", "PublishPort=8080:80\nPublishPort=8443:443\n", "[Install]\n"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("unit file does not contain %q:\n%s", want, content)
+		}
+	}
+	if fake.Reloads != 1 {
+		t.Errorf("Reloads = %d, want 1", fake.Reloads)
+	}
+
+	if err := newCreateCmd("", "web", "--image", "nginx").Execute(); err == nil {
+		t.Error("second create error = nil, want already exists")
+	}
+	if err := newCreateCmd("", "web.container", "--image", "nginx").Execute(); err == nil {
+		t.Error("second create as web.container error = nil, want already exists")
+	}
+	if err := newCreateCmd("", "proxy.network", "--image", "nginx").Execute(); err == nil {
+		t.Error("create of proxy.network as a container error = nil, want a type mismatch")
+	}
+	if err := newCreateCmd("", "db.container", "--image", "postgres").Execute(); err != nil {
+		t.Fatalf("create db.container error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "db", "db.container")); err != nil {
+		t.Errorf("db.container was not written as db/db.container: %v", err)
+	}
+	if err := newCreateCmd("", "db", "--description", "no image").Execute(); err == nil {
+		t.Error("create without an image error = nil, want image required")
+	}
+}
+
+func TestCreateWizardKeepsFlags(t *testing.T) {
+	fake := systemdtest.UseFake(t, &manager)
+	dir := systemdtest.UseContainersDir(t, nil)
+
+	// Description, labels and validation are asked; --reload answers the
+	// reload question.
+	cmd := newCreateCmd("Proxy network\n\nn\n", "proxy", "--type", "network", "--reload")
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "proxy", "proxy.network"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(content), "Description=Proxy network\n") {
+		t.Errorf("unit file does not use the wizard's description:\n%s", content)
+	}
+	if fake.Reloads != 1 {
+		t.Errorf("Reloads = %d, want 1 from --reload", fake.Reloads)
+	}
+}
@@ -0,0 +1,162 @@
+package unit
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+)
+
+var withDepsTitles = map[string]string{
+	"start":   "Starting",
+	"stop":    "Stopping",
+	"restart": "Restarting",
+}
+
+// runWithDeps runs start, stop or restart on the named units together with
+// everything they depend on (networks, volumes, pods, images and [Unit]
+// dependencies). Stop and restart also take in the units that depend on the
+// named ones. Dependencies are started first and stopped last; a unit is
+// skipped when a unit it waits on has failed, and a dependency is kept
+// running while a unit outside the set still uses it. Restart is a stop pass
+// followed by a start pass.
+func runWithDeps(unitNames []string, types []string, verb string, noReload bool, reload bool) error {
+	graph, err := loadGraph()
+	if err != nil {
+		return err
+	}
+
+	roots, err := resolveGraphNames(graph, unitNames, types)
+	if err != nil {
+		return cmdutil.Wrap(err, "resolving units")
+	}
+	units := graph.Closure(roots)
+	var dependentSet []string
+	if verb != "start" {
+		dependentSet = graph.DependentClosure(roots)
+		for _, name := range dependentSet {
+			if !slices.Contains(units, name) {
+				units = append(units, name)
+			}
+		}
+	}
+	order, err := graph.TopoSort(units)
+	if err != nil {
+		return err
+	}
+	reverse := slices.Clone(order)
+	slices.Reverse(reverse)
+
+	services := make([]string, len(order))
+	for i, name := range order {
+		services[i] = graph.Units[name].ServiceName()
+	}
+	fmt.Println(shared.TitleStyle.Render(fmt.Sprintf("%s %s with dependencies...", withDepsTitles[verb], strings.Join(unitNames, " "))))
+	fmt.Println("  Order: " + strings.Join(services, " → "))
+
+	if reload {
+		if !noReload {
+			if err := cmdutil.ReloadDaemon(manager); err != nil {
+				return err
+			}
+		} else {
+			cmdutil.PrintSkipReload()
+		}
+	}
+
+	results := make(map[string]string, len(order))
+	failed := make(map[string]bool, len(order))
+
+	// A dependency that is not being stopped for a dependent's sake stays up
+	// while anything outside the set, or a unit that stays up, still uses it.
+	kept := make(map[string]bool)
+	if verb != "start" {
+		for _, name := range reverse {
+			if slices.Contains(dependentSet, name) {
+				continue
+			}
+			for _, user := range graph.Dependents(name) {
+				if kept[user] || !slices.Contains(order, user) && isActive(graph.Units[user].ServiceName()) {
+					kept[name] = true
+					results[name] = fmt.Sprintf("kept (used by %s)", graph.Units[user].ServiceName())
+					break
+				}
+			}
+		}
+	}
+
+	// runPass applies action to each unit in order, unless the unit already
+	// failed or one of its blockers did.
+	runPass := func(units []string, gerund, done string, action func(string) (string, error), blockers func(string) []string) {
+		for _, name := range units {
+			if failed[name] || kept[name] {
+				continue
+			}
+			if blocker := slices.IndexFunc(blockers(name), func(b string) bool { return failed[b] }); blocker >= 0 {
+				failed[name] = true
+				results[name] = fmt.Sprintf("skipped (%s failed)", blockers(name)[blocker])
+				continue
+			}
+
+			service := graph.Units[name].ServiceName()
+			fmt.Printf("  %s %s...\n", gerund, service)
+			output, err := action(service)
+			if err != nil {
+				failed[name] = true
+				results[name] = "✗ failed"
+				fmt.Println(shared.ErrorStyle.Render(err.Error()))
+				if strings.TrimSpace(output) != "" {
+					fmt.Println(strings.TrimSpace(output))
+				}
+				continue
+			}
+			results[name] = "✓ " + done
+		}
+	}
+
+	// Only units inside the closure can block each other.
+	within := func(names []string) []string {
+		return slices.DeleteFunc(names, func(n string) bool { return !slices.Contains(order, n) })
+	}
+	dependencies := func(name string) []string { return within(graph.Dependencies(name)) }
+	dependents := func(name string) []string { return within(graph.Dependents(name)) }
+
+	switch verb {
+	case "start":
+		runPass(order, "Starting", "started", manager.Start, dependencies)
+	case "stop":
+		runPass(reverse, "Stopping", "stopped", manager.Stop, dependents)
+	case "restart":
+		runPass(reverse, "Stopping", "stopped", manager.Stop, dependents)
+		runPass(order, "Starting", "restarted", manager.Start, dependencies)
+	default:
+		return cmdutil.Errorf("unsupported action %q", verb)
+	}
+
+	rows := make([][]string, 0, len(order))
+	for _, name := range order {
+		u := graph.Units[name]
+		rows = append(rows, []string{u.BaseName(), u.UnitType(), u.ServiceName(), results[name]})
+	}
+	fmt.Println()
+	shared.PrintTable([]string{"Unit", "Type", "Service", "Result"}, rows)
+
+	failures := 0
+	for _, name := range order {
+		if failed[name] {
+			failures++
+		}
+	}
+	if failures > 0 {
+		return cmdutil.Errorf("%d of %d unit(s) failed to %s", failures, len(order), verb)
+	}
+	return nil
+}
+
+// isActive reports whether a service is active, treating errors as inactive.
+func isActive(service string) bool {
+	active, err := manager.IsActive(service)
+	return err == nil && active
+}
@@ -0,0 +1,46 @@
+package unit
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mufeedali/quadlet-helper/internal/systemd/systemdtest"
+)
+
+func TestStopWithDeps(t *testing.T) {
+	fake := systemdtest.UseFake(t, &manager)
+	systemdtest.UseContainersDir(t, map[string]string{
+		"app/web.container":     "[Container]\nImage=nginx\nNetwork=proxy.network\nVolume=data.volume:/data\n",
+		"app/api.container":     "[Unit]\nRequires=web.service\nAfter=web.service\n\n[Container]\nImage=api\n",
+		"app/data.volume":       "[Volume]\n",
+		"proxy/proxy.network":   "[Network]\n",
+		"other/other.container": "[Container]\nImage=other\nNetwork=proxy.network\n",
+	})
+	for _, service := range []string{"web.service", "api.service", "data-volume.service", "proxy-network.service", "other.service"} {
+		fake.Active[service] = true
+	}
+
+	if err := runWithDeps([]string{"web"}, nil, "stop", false, false); err != nil {
+		t.Fatalf("runWithDeps(stop) error = %v", err)
+	}
+	// The dependent goes first, and the network other still uses stays up.
+	want := []string{"stop api.service", "stop web.service", "stop data-volume.service"}
+	if !slices.Equal(fake.Calls, want) {
+		t.Errorf("calls = %v, want %v", fake.Calls, want)
+	}
+	if !fake.Active["proxy-network.service"] || !fake.Active["other.service"] {
+		t.Error("shared network or its other user was stopped")
+	}
+
+	// Once nothing else uses it, the network is stopped last.
+	fake.Calls = nil
+	fake.Active["other.service"] = false
+	fake.Active["web.service"] = true
+	if err := runWithDeps([]string{"web"}, nil, "stop", false, false); err != nil {
+		t.Fatalf("runWithDeps(stop) error = %v", err)
+	}
+	want = []string{"stop api.service", "stop web.service", "stop proxy-network.service", "stop data-volume.service"}
+	if !slices.Equal(fake.Calls, want) {
+		t.Errorf("calls = %v, want %v", fake.Calls, want)
+	}
+}
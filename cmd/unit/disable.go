@@ -2,8 +2,8 @@ package unit
 
 import (
 	"fmt"
-	"strings"
 
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
 	"github.com/spf13/cobra"
 )
 
@@ -19,33 +19,6 @@ var disableCmd = &cobra.Command{
 	},
 }
 
-func removeInstallSection(content string) (string, bool) {
-	lines := strings.SplitAfter(content, "\n")
-	var builder strings.Builder
-	removed := false
-	inInstall := false
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(strings.TrimRight(line, "\r\n"))
-		if len(trimmed) > 1 && strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
-			if trimmed == "[Install]" {
-				removed = true
-				inInstall = true
-				continue
-			}
-			inInstall = false
-		}
-
-		if inInstall {
-			continue
-		}
-
-		builder.WriteString(line)
-	}
-
-	if !removed {
-		return content, false
-	}
-
-	return builder.String(), true
+func removeInstallSection(file *quadlet.File) bool {
+	return file.RemoveSection("Install")
 }
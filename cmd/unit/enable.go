@@ -2,8 +2,8 @@ package unit
 
 import (
 	"fmt"
-	"strings"
 
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
 	"github.com/spf13/cobra"
 )
 
@@ -19,13 +19,10 @@ var enableCmd = &cobra.Command{
 	},
 }
 
-func addInstallSection(content string) (string, bool) {
-	if strings.Contains(content, "[Install]") {
-		return content, false
+func addInstallSection(file *quadlet.File) bool {
+	if file.HasSection("Install") {
+		return false
 	}
-	installSection := `
-[Install]
-WantedBy=multi-user.target default.target
-`
-	return content + installSection, true
+	file.AddSection("Install").Set("WantedBy", "multi-user.target default.target")
+	return true
 }
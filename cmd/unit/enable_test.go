@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mufeedali/quadlet-helper/internal/systemd/systemdtest"
+)
+
+func TestEnableAndDisable(t *testing.T) {
+	fake := systemdtest.UseFake(t, &manager)
+	dir := systemdtest.UseContainersDir(t, map[string]string{
+		"web/web.container": "[Container]\nImage=nginx\n",
+	})
+	path := filepath.Join(dir, "web", "web.container")
+
+	if err := enableCmd.RunE(enableCmd, []string{"web"}); err != nil {
+		t.Fatalf("enable error = %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "[Install]\nWantedBy=multi-user.target default.target\n") {
+		t.Errorf("enabled file has no [Install] section:\n%s", content)
+	}
+	if fake.Reloads != 1 {
+		t.Errorf("Reloads after enable = %d, want 1", fake.Reloads)
+	}
+
+	// Enabling again changes nothing and needs no reload.
+	if err := enableCmd.RunE(enableCmd, []string{"web"}); err != nil {
+		t.Fatalf("second enable error = %v", err)
+	}
+	if fake.Reloads != 1 {
+		t.Errorf("Reloads after second enable = %d, want 1", fake.Reloads)
+	}
+
+	if err := disableCmd.RunE(disableCmd, []string{"web"}); err != nil {
+		t.Fatalf("disable error = %v", err)
+	}
+	content, _ = os.ReadFile(path)
+	if strings.Contains(string(content), "[Install]") {
+		t.Errorf("disabled file still has an [Install] section:\n%s", content)
+	}
+	if fake.Reloads != 2 {
+		t.Errorf("Reloads after disable = %d, want 2", fake.Reloads)
+	}
+
+	if err := enableCmd.RunE(enableCmd, []string{"missing"}); err == nil {
+		t.Error("enable of a missing unit error = nil")
+	}
+}
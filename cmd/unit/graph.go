@@ -0,0 +1,154 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var graphFormats = []string{"tree", "dot", "mermaid"}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [unit-name...]",
+	Short: "Show the dependency graph of quadlet units",
+	Long: `This command resolves Network=, Volume=, Pod=, Image= and [Unit]
+Requires=/Wants=/BindsTo=/After= references between the quadlet files under
+--containers-path and renders them as a text tree, Graphviz DOT or Mermaid.
+
+When unit names are given, only those units and their dependencies are shown.
+Dependency cycles and references to missing quadlet files are reported.`,
+	ValidArgsFunction: unitCompletionFunc,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+
+		graph, err := loadGraph()
+		if err != nil {
+			return err
+		}
+
+		names := graph.Names()
+		roots := graph.Roots(names)
+		if len(args) > 0 {
+			roots, err = resolveGraphNames(graph, args, nil)
+			if err != nil {
+				return err
+			}
+			names = graph.Closure(roots)
+		}
+
+		switch format {
+		case "tree":
+			if len(roots) == 0 {
+				fmt.Println(shared.WarningStyle.Render("No quadlet files found."))
+				return nil
+			}
+			if err := graph.WriteTree(os.Stdout, roots); err != nil {
+				return cmdutil.Wrap(err, "writing graph")
+			}
+		case "dot":
+			if err := graph.WriteDOT(os.Stdout, names); err != nil {
+				return cmdutil.Wrap(err, "writing graph")
+			}
+		case "mermaid":
+			if err := graph.WriteMermaid(os.Stdout, names); err != nil {
+				return cmdutil.Wrap(err, "writing graph")
+			}
+		default:
+			return cmdutil.Errorf("invalid format %q (must be tree, dot, or mermaid)", format)
+		}
+
+		printGraphProblems(graph, names, format == "tree")
+		return nil
+	},
+}
+
+// loadGraph discovers every quadlet file under --containers-path and builds
+// its dependency graph.
+func loadGraph() (*quadlet.Graph, error) {
+	containersPath := viper.GetString("containers-path")
+	realContainersPath := shared.ResolveContainersDir(containersPath)
+
+	units, err := quadlet.Discover(realContainersPath)
+	if err != nil {
+		return nil, cmdutil.Wrap(err, "reading quadlet files")
+	}
+	for _, u := range units {
+		if u.Err != nil {
+			fmt.Fprintln(os.Stderr, shared.WarningStyle.Render(fmt.Sprintf("Warning: skipping %s: %v", u.Path, u.Err)))
+		}
+	}
+	return quadlet.BuildGraph(units), nil
+}
+
+// resolveGraphNames maps base names such as "web" to graph node names such as
+// "web.container", considering only units of the given types (all types if
+// types is empty). Full file names are accepted as well.
+func resolveGraphNames(graph *quadlet.Graph, unitNames []string, types []string) ([]string, error) {
+	names := make([]string, 0, len(unitNames))
+	for _, unitName := range unitNames {
+		if _, ok := graph.Units[unitName]; ok {
+			names = append(names, unitName)
+			continue
+		}
+
+		var matches []string
+		for _, name := range graph.Names() {
+			u := graph.Units[name]
+			if u.BaseName() == unitName && (len(types) == 0 || slices.Contains(types, u.UnitType())) {
+				matches = append(matches, name)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, cmdutil.Errorf("quadlet unit %q not found", unitName)
+		case 1:
+			names = append(names, matches[0])
+		default:
+			return nil, cmdutil.Errorf("quadlet unit %q is ambiguous (%s)", unitName, strings.Join(matches, ", "))
+		}
+	}
+	return names, nil
+}
+
+// printGraphProblems reports cycles and dangling references among names. For
+// machine-readable formats they go to stderr so the graph output stays valid.
+func printGraphProblems(graph *quadlet.Graph, names []string, toStdout bool) {
+	out := os.Stderr
+	if toStdout {
+		out = os.Stdout
+	}
+
+	var problems []string
+	for _, cycle := range graph.Cycles() {
+		if slices.Contains(names, cycle[0]) {
+			problems = append(problems, "Dependency cycle between "+strings.Join(cycle, ", "))
+		}
+	}
+	for _, e := range graph.Dangling {
+		if slices.Contains(names, e.From) {
+			problems = append(problems, fmt.Sprintf("Dangling reference: %s → %s (%s)", e.From, e.To, e.Kind))
+		}
+	}
+
+	if len(problems) == 0 {
+		return
+	}
+	fmt.Fprintln(out)
+	for _, problem := range problems {
+		fmt.Fprintln(out, shared.WarningStyle.Render("Warning: "+problem))
+	}
+}
+
+func init() {
+	graphCmd.Flags().String("format", "tree", "Output format (tree, dot, mermaid)")
+	_ = graphCmd.RegisterFlagCompletionFunc("format", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return graphFormats, cobra.ShellCompDirectiveNoFileComp
+	})
+}
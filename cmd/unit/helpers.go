@@ -2,13 +2,11 @@ package unit
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
 	"github.com/mufeedali/quadlet-helper/internal/quadlet"
 	"github.com/mufeedali/quadlet-helper/internal/shared"
-	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/viper"
 )
 
@@ -29,11 +27,11 @@ func runServiceAction(unitNames []string, types []string, title string, noReload
 	fmt.Println(shared.TitleStyle.Render(fmt.Sprintf(title, strings.Join(services, " "))))
 	if reload {
 		if !noReload {
-			if err := reloadDaemon(); err != nil {
+			if err := cmdutil.ReloadDaemon(manager); err != nil {
 				return err
 			}
 		} else {
-			printSkipReload()
+			cmdutil.PrintSkipReload()
 		}
 	}
 
@@ -55,11 +53,11 @@ func runServiceAction(unitNames []string, types []string, title string, noReload
 	return nil
 }
 
-func updateInstallSections(unitNames []string, actionName string, unchangedMessage func(string) string, apply func(string) (string, bool), changedMessage string) error {
+func updateInstallSections(unitNames []string, actionName string, unchangedMessage func(string) string, apply func(*quadlet.File) bool, changedMessage string) error {
 	containersPath := viper.GetString("containers-path")
 	realContainersPath := shared.ResolveContainersDir(containersPath)
 
-	allUnits, err := quadlet.List(realContainersPath)
+	allUnits, err := quadlet.Discover(realContainersPath)
 	if err != nil {
 		return err
 	}
@@ -81,20 +79,19 @@ func updateInstallSections(unitNames []string, actionName string, unchangedMessa
 
 		fmt.Println("  Found: " + shared.FilePathStyle.Render(quadletFile))
 
-		content, err := os.ReadFile(quadletFile)
+		file, err := quadlet.ParseFile(quadletFile)
 		if err != nil {
 			fmt.Println(shared.ErrorStyle.Render(fmt.Sprintf("Error reading file: %v", err)))
 			failures++
 			continue
 		}
 
-		newContent, changed := apply(string(content))
-		if !changed {
+		if !apply(file) {
 			fmt.Println(shared.WarningStyle.Render(unchangedMessage(unitName)))
 			continue
 		}
 
-		if err := writeQuadletFile(quadletFile, []byte(newContent)); err != nil {
+		if err := writeQuadletFile(quadletFile, file.Bytes()); err != nil {
 			fmt.Println(shared.ErrorStyle.Render(fmt.Sprintf("Error writing file: %v", err)))
 			failures++
 			continue
@@ -107,7 +104,7 @@ func updateInstallSections(unitNames []string, actionName string, unchangedMessa
 
 	if anyChanged {
 		fmt.Println("  Running systemctl --user daemon-reload...")
-		if _, err := systemd.DaemonReload(); err != nil {
+		if _, err := manager.DaemonReload(); err != nil {
 			return cmdutil.Wrap(err, "running daemon-reload")
 		}
 		fmt.Println(shared.CheckMark + " Daemon reloaded.")
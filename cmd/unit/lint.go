@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var errLintFailed = errors.New("lint found errors")
+
+var lintFormats = []string{"text", "json", "sarif"}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [unit-name...]",
+	Short: "Check quadlet files offline for common mistakes",
+	Long: `This command parses quadlet files directly and checks them without podman
+or a user bus. It reports unknown and deprecated keys, missing images, missing
+Volume= host paths and EnvironmentFile= files, Network= references to .network
+units that do not exist, and host ports published by more than one unit.
+
+Each finding carries a stable rule ID. Use --format json or --format sarif to
+feed the results to CI.`,
+	ValidArgsFunction: unitCompletionFunc,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+
+		containersPath := viper.GetString("containers-path")
+		realContainersPath := shared.ResolveContainersDir(containersPath)
+
+		units, err := quadlet.Discover(realContainersPath)
+		if err != nil {
+			return cmdutil.Wrap(err, "reading quadlet files")
+		}
+
+		findings := quadlet.Lint(units)
+		if len(args) > 0 {
+			findings, err = filterFindings(units, findings, args)
+			if err != nil {
+				return err
+			}
+		}
+
+		switch format {
+		case "json":
+			if findings == nil {
+				findings = []quadlet.Finding{}
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(findings); err != nil {
+				return cmdutil.Wrap(err, "writing JSON")
+			}
+		case "sarif":
+			if err := quadlet.WriteSARIF(cmd.OutOrStdout(), realContainersPath, findings); err != nil {
+				return cmdutil.Wrap(err, "writing SARIF")
+			}
+		case "text":
+			return printFindings(realContainersPath, units, findings)
+		default:
+			return cmdutil.Errorf("invalid format %q (must be text, json, or sarif)", format)
+		}
+
+		// Machine-readable output must stay clean, so signal failure through
+		// the exit code alone.
+		if quadlet.HasErrors(findings) {
+			return cmdutil.SilentExit(cmdutil.ExitFailure, errLintFailed)
+		}
+		return nil
+	},
+}
+
+// filterFindings keeps only the findings for the named units.
+func filterFindings(units []*quadlet.UnitFile, findings []quadlet.Finding, unitNames []string) ([]quadlet.Finding, error) {
+	paths := make(map[string]bool, len(unitNames))
+	for _, unitName := range unitNames {
+		found := false
+		for _, u := range units {
+			if u.BaseName() == unitName || u.Name() == unitName {
+				paths[u.Path] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, cmdutil.Errorf("quadlet unit %q not found", unitName)
+		}
+	}
+
+	var filtered []quadlet.Finding
+	for _, f := range findings {
+		if paths[f.Path] {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered, nil
+}
+
+func printFindings(root string, units []*quadlet.UnitFile, findings []quadlet.Finding) error {
+	fmt.Println(shared.TitleStyle.Render("Linting quadlet units in " + shared.FilePathStyle.Render(root) + "\n"))
+
+	if len(units) == 0 {
+		fmt.Println(shared.WarningStyle.Render("No quadlet files found."))
+		return nil
+	}
+
+	var errorCount, warningCount int
+	for _, f := range findings {
+		location := f.Path
+		if rel, err := filepath.Rel(root, f.Path); err == nil {
+			location = rel
+		}
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, f.Line)
+		}
+
+		style := shared.WarningStyle
+		if f.Severity == quadlet.SeverityError {
+			style = shared.ErrorStyle
+			errorCount++
+		} else {
+			warningCount++
+		}
+		fmt.Printf("  %s %s [%s] %s\n", style.Render(string(f.Severity)), shared.FilePathStyle.Render(location), f.Rule, f.Message)
+	}
+
+	summary := fmt.Sprintf("\nLint complete. (%d error(s), %d warning(s))", errorCount, warningCount)
+	if errorCount > 0 {
+		fmt.Println(shared.ErrorStyle.Render(summary))
+		// The summary already says so.
+		return cmdutil.SilentExit(cmdutil.ExitFailure, errLintFailed)
+	}
+	if warningCount > 0 {
+		fmt.Println(shared.WarningStyle.Render(summary))
+		return nil
+	}
+	fmt.Println(shared.SuccessStyle.Render(summary))
+	return nil
+}
+
+func init() {
+	lintCmd.Flags().String("format", "text", "Output format (text, json, sarif)")
+	_ = lintCmd.RegisterFlagCompletionFunc("format", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return lintFormats, cobra.ShellCompDirectiveNoFileComp
+	})
+}
@@ -0,0 +1,54 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/mufeedali/quadlet-helper/internal/systemd/systemdtest"
+)
+
+func TestLintJSONExitCode(t *testing.T) {
+	systemdtest.UseContainersDir(t, map[string]string{
+		"web/web.container": "[Container]\nPublishPort=8080:80\n",
+	})
+	var out bytes.Buffer
+	lintCmd.SetOut(&out)
+	t.Cleanup(func() { lintCmd.SetOut(nil) })
+	if err := lintCmd.Flags().Set("format", "json"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lintCmd.Flags().Set("format", "text") })
+
+	err := lintCmd.RunE(lintCmd, nil)
+	if code := cmdutil.ExitCode(err); code != cmdutil.ExitFailure {
+		t.Fatalf("lint error = %v (exit code %d), want exit code %d", err, code, cmdutil.ExitFailure)
+	}
+	var exitErr *cmdutil.ExitError
+	if !errors.As(err, &exitErr) || !exitErr.Silent {
+		t.Errorf("lint error = %#v, want a silent exit", err)
+	}
+
+	var findings []quadlet.Finding
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil {
+		t.Fatalf("lint output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(findings) == 0 || findings[0].Rule != quadlet.RuleMissingImage {
+		t.Errorf("findings = %+v, want %s", findings, quadlet.RuleMissingImage)
+	}
+}
+
+func TestLintTextExitCode(t *testing.T) {
+	systemdtest.UseContainersDir(t, map[string]string{
+		"web/web.container": "[Container]\nPublishPort=8080:80\n",
+	})
+
+	err := lintCmd.RunE(lintCmd, nil)
+	var exitErr *cmdutil.ExitError
+	if !errors.As(err, &exitErr) || !exitErr.Silent || exitErr.Code != cmdutil.ExitFailure {
+		t.Errorf("lint error = %#v, want a silent exit with code %d", err, cmdutil.ExitFailure)
+	}
+}
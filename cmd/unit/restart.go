@@ -3,12 +3,12 @@ package unit
 import (
 	"strings"
 
-	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
 )
 
 var restartNoReload bool
 var restartTypes []string
+var restartWithDeps bool
 
 var restartCmd = &cobra.Command{
 	Use:               "restart <unit-name>...",
@@ -16,13 +16,16 @@ var restartCmd = &cobra.Command{
 	Args:              cobra.MinimumNArgs(1),
 	ValidArgsFunction: unitCompletionFunc,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if restartWithDeps {
+			return runWithDeps(args, restartTypes, "restart", restartNoReload, true)
+		}
 		return runServiceAction(
 			args,
 			restartTypes,
 			"Restarting %s...",
 			restartNoReload,
 			true,
-			systemd.RestartMultiple,
+			manager.RestartMultiple,
 			"restarting services",
 			"✓ Successfully restarted %s",
 			func(services []string) string {
@@ -35,5 +38,6 @@ var restartCmd = &cobra.Command{
 func init() {
 	restartCmd.Flags().BoolVar(&restartNoReload, "no-reload", false, "Skip systemctl daemon-reload step")
 	restartCmd.Flags().StringSliceVar(&restartTypes, "type", []string{"container", "kube", "pod"}, "Quadlet unit types to act on")
+	restartCmd.Flags().BoolVar(&restartWithDeps, "with-deps", false, "Restart these units with their dependents and dependencies, stopping dependents first and starting dependencies first")
 	_ = restartCmd.RegisterFlagCompletionFunc("type", typeCompletionFunc)
 }
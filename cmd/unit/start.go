@@ -1,25 +1,65 @@
 package unit
 
 import (
-	"github.com/mufeedali/quadlet-helper/internal/systemd"
+	"fmt"
+	"time"
+
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
 	"github.com/spf13/cobra"
 )
 
 var startNoReload bool
 var startTypes []string
+var startWithDeps bool
+var startWait bool
+var startTimeout time.Duration
 
 var startCmd = &cobra.Command{
-	Use:               "start <unit-name>...",
-	Short:             "Start one or more quadlet units",
+	Use:   "start <unit-name>...",
+	Short: "Start one or more quadlet units",
+	Long: `Start one or more quadlet units.
+
+With --wait, qh keeps polling the units after systemd has started them until
+each one is ready: active in systemd and, for containers with HealthCmd=,
+reported healthy by podman. Units that fail or are not ready within --timeout
+have their last journal lines printed. The exit status is 0 when every unit is
+ready, 1 when a unit failed and 124 when the wait timed out.`,
 	Args:              cobra.MinimumNArgs(1),
 	ValidArgsFunction: unitCompletionFunc,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runServiceAction(args, startTypes, "Starting %s...", startNoReload, true, systemd.StartMultiple, "starting services", "✓ Successfully started %s", nil)
+		var waitUnits []*quadlet.UnitFile
+		if startWait {
+			var err error
+			if waitUnits, err = resolveUnitFiles(args, startTypes); err != nil {
+				return err
+			}
+		}
+
+		started := time.Now()
+		var err error
+		if startWithDeps {
+			err = runWithDeps(args, startTypes, "start", startNoReload, true)
+		} else {
+			err = runServiceAction(args, startTypes, "Starting %s...", startNoReload, true, manager.StartMultiple, "starting services", "✓ Successfully started %s", nil)
+		}
+		if !startWait {
+			return err
+		}
+		// Even if starting failed, the wait reports which units failed and
+		// shows their journals.
+		fmt.Println()
+		if waitErr := waitForUnits(waitUnits, started, startTimeout); waitErr != nil {
+			return waitErr
+		}
+		return err
 	},
 }
 
 func init() {
 	startCmd.Flags().BoolVar(&startNoReload, "no-reload", false, "Skip systemctl daemon-reload step")
 	startCmd.Flags().StringSliceVar(&startTypes, "type", []string{"container", "kube", "pod"}, "Quadlet unit types to act on")
+	startCmd.Flags().BoolVar(&startWithDeps, "with-deps", false, "Also start the networks, volumes, pods and units these depend on, in dependency order")
+	startCmd.Flags().BoolVar(&startWait, "wait", false, "Wait until the units are active and healthy")
+	startCmd.Flags().DurationVar(&startTimeout, "timeout", 2*time.Minute, "How long --wait waits before giving up (0 waits indefinitely)")
 	_ = startCmd.RegisterFlagCompletionFunc("type", typeCompletionFunc)
 }
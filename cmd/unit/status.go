@@ -2,9 +2,12 @@ package unit
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 
 	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
-	"github.com/mufeedali/quadlet-helper/internal/systemd"
+	"github.com/mufeedali/quadlet-helper/internal/output"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
 	"github.com/spf13/cobra"
 )
 
@@ -16,23 +19,54 @@ var statusCmd = &cobra.Command{
 	Args:              cobra.MinimumNArgs(1),
 	ValidArgsFunction: unitCompletionFunc,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if format := output.Current(); format != output.Table {
+			return printUnitStatuses(args, statusTypes, format)
+		}
+
 		services, err := loadServices(args, statusTypes)
 		if err != nil {
 			return err
 		}
 
-		output, _ := systemd.Status(services...)
+		output, _ := manager.Status(services...)
 		fmt.Println(output)
 		if output == "" {
 			return cmdutil.Errorf("no status output returned")
 		}
-		if _, err := systemd.IsActiveMultiple(services); err != nil {
+		if _, err := manager.IsActiveMultiple(services); err != nil {
 			return cmdutil.Wrap(err, "getting unit status")
 		}
 		return nil
 	},
 }
 
+// printUnitStatuses prints the status of the named units as JSON, YAML or
+// tab-separated text instead of the systemctl status output.
+func printUnitStatuses(unitNames []string, types []string, format output.Format) error {
+	units, err := resolveUnits(unitNames, types)
+	if err != nil {
+		return cmdutil.Wrap(err, "resolving units")
+	}
+
+	statuses := make([]quadlet.UnitStatus, len(units))
+	for i, u := range units {
+		statuses[i] = u.Info()
+	}
+	return writeUnitStatuses(statuses, format)
+}
+
+// writeUnitStatuses writes statuses as JSON, YAML or tab-separated text.
+func writeUnitStatuses(statuses []quadlet.UnitStatus, format output.Format) error {
+	if format.Structured() {
+		return output.Encode(os.Stdout, format, statuses)
+	}
+	rows := make([][]string, len(statuses))
+	for i, s := range statuses {
+		rows[i] = []string{s.Name, s.Type, s.Path, strconv.FormatBool(s.Enabled), s.ActiveState, s.SubState}
+	}
+	return output.WritePlain(os.Stdout, rows)
+}
+
 func init() {
 	statusCmd.Flags().StringSliceVar(&statusTypes, "type", []string{"container", "kube", "pod"}, "Quadlet unit types to act on")
 	_ = statusCmd.RegisterFlagCompletionFunc("type", typeCompletionFunc)
@@ -1,11 +1,11 @@
 package unit
 
 import (
-	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
 )
 
 var stopTypes []string
+var stopWithDeps bool
 
 var stopCmd = &cobra.Command{
 	Use:               "stop <unit-name>...",
@@ -13,11 +13,15 @@ var stopCmd = &cobra.Command{
 	Args:              cobra.MinimumNArgs(1),
 	ValidArgsFunction: activeUnitCompletionFunc,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runServiceAction(args, stopTypes, "Stopping %s...", false, false, systemd.StopMultiple, "stopping services", "✓ Successfully stopped %s", nil)
+		if stopWithDeps {
+			return runWithDeps(args, stopTypes, "stop", false, false)
+		}
+		return runServiceAction(args, stopTypes, "Stopping %s...", false, false, manager.StopMultiple, "stopping services", "✓ Successfully stopped %s", nil)
 	},
 }
 
 func init() {
 	stopCmd.Flags().StringSliceVar(&stopTypes, "type", []string{"container", "kube", "pod"}, "Quadlet unit types to act on")
+	stopCmd.Flags().BoolVar(&stopWithDeps, "with-deps", false, "Also stop the units that depend on these first, and then what these depend on unless other running units still use it")
 	_ = stopCmd.RegisterFlagCompletionFunc("type", typeCompletionFunc)
 }
@@ -1,9 +1,14 @@
 package unit
 
 import (
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
 	"github.com/spf13/cobra"
 )
 
+// manager runs the systemd operations of every command in this package.
+// Tests replace it with a systemd.Fake.
+var manager systemd.Manager = systemd.NewManager()
+
 var UnitCmd = &cobra.Command{
 	Use:   "unit",
 	Short: "Manage quadlet unit files",
@@ -11,8 +16,11 @@ var UnitCmd = &cobra.Command{
 }
 
 func init() {
+	UnitCmd.AddCommand(createCmd)
 	UnitCmd.AddCommand(disableCmd)
 	UnitCmd.AddCommand(enableCmd)
+	UnitCmd.AddCommand(graphCmd)
+	UnitCmd.AddCommand(lintCmd)
 	UnitCmd.AddCommand(listCmd)
 	UnitCmd.AddCommand(logsCmd)
 	UnitCmd.AddCommand(restartCmd)
@@ -12,6 +12,21 @@ import (
 // resolveServiceNames resolves a list of unit names to their systemd service names
 // via a single podman quadlet list call. Only units matching the given types are considered.
 func resolveServiceNames(unitNames []string, types []string) ([]string, error) {
+	units, err := resolveUnits(unitNames, types)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]string, len(units))
+	for i, u := range units {
+		services[i] = u.UnitName
+	}
+	return services, nil
+}
+
+// resolveUnits looks up the named units via a single podman quadlet list call.
+// Only units matching the given types are considered.
+func resolveUnits(unitNames []string, types []string) ([]quadlet.Unit, error) {
 	containersPath := viper.GetString("containers-path")
 	realContainersPath := shared.ResolveContainersDir(containersPath)
 
@@ -33,15 +48,15 @@ func resolveServiceNames(unitNames []string, types []string) ([]string, error) {
 		}
 	}
 
-	services := make([]string, 0, len(unitNames))
+	units := make([]quadlet.Unit, 0, len(unitNames))
 	for _, unitName := range unitNames {
 		u, ok := index[unitName]
 		if !ok {
 			return nil, fmt.Errorf("quadlet unit %q not found", unitName)
 		}
-		services = append(services, u.UnitName)
+		units = append(units, *u)
 	}
-	return services, nil
+	return units, nil
 }
 
 func writeQuadletFile(path string, content []byte) error {
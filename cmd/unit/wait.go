@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
+)
+
+// waitPollInterval is how often waitForUnits checks on the units.
+var waitPollInterval = time.Second
+
+// containerHealth returns podman's health status for a container. Tests
+// replace it.
+var containerHealth = quadlet.HealthStatus
+
+// waitJournalLines is how much of the journal is shown for a unit that failed
+// or timed out.
+const waitJournalLines = 20
+
+type waitState int
+
+const (
+	waitPending waitState = iota
+	waitReady
+	waitFailed
+)
+
+// waitTarget is a unit being waited on and what was last seen of it.
+type waitTarget struct {
+	unit   *quadlet.UnitFile
+	since  time.Time // when the unit was started
+	state  waitState
+	detail string
+}
+
+// checkUnit looks at a started unit once. A unit is ready when systemd reports
+// it active (for Type=notify services that is after READY=1) and, if it has a
+// HealthCmd=, podman reports the container healthy. Oneshot services that
+// exited successfully after they were started count as ready.
+func checkUnit(t *waitTarget) {
+	service := t.unit.ServiceName()
+	props, err := manager.ShowProperties(service, "ActiveState", "SubState", "Result", "ExecMainStartTimestamp", "InactiveEnterTimestamp")
+	if err != nil {
+		t.detail = err.Error()
+		return
+	}
+	active, sub := props["ActiveState"], props["SubState"]
+	t.detail = active + "/" + sub
+
+	switch active {
+	case "active":
+	case "failed":
+		t.state = waitFailed
+		if result := props["Result"]; result != "" && result != "success" {
+			t.detail += " (" + result + ")"
+		}
+		return
+	case "inactive":
+		// A oneshot service without RemainAfterExit= that ran to completion
+		// looks just like one that never started, except for when it ran.
+		if props["Result"] == "success" && sub == "dead" {
+			ran, ok := systemd.ParseTimestamp(props["ExecMainStartTimestamp"])
+			if !ok {
+				ran, ok = systemd.ParseTimestamp(props["InactiveEnterTimestamp"])
+			}
+			// Timestamps are whole seconds.
+			if ok && !ran.Before(t.since.Truncate(time.Second)) {
+				t.state = waitReady
+				return
+			}
+			t.detail += " (not started)"
+		}
+		t.state = waitFailed
+		return
+	default:
+		// activating, reloading, deactivating: still on its way.
+		return
+	}
+
+	if !t.unit.HasHealthCheck() {
+		t.state = waitReady
+		return
+	}
+	health, err := containerHealth(t.unit.ContainerName())
+	if err != nil {
+		t.detail += ", health unknown"
+		return
+	}
+	if health == "" {
+		health = "starting"
+	}
+	t.detail += ", " + health
+	switch health {
+	case "healthy":
+		t.state = waitReady
+	case "unhealthy":
+		t.state = waitFailed
+	}
+}
+
+// waitForUnits polls the services of units, started at since, until each one
+// is ready or has failed, or until timeout passes (0 waits indefinitely). It prints a summary
+// and the journal of every unit that did not become ready. The returned error
+// carries cmdutil.ExitTimeout if units were still starting at the deadline.
+func waitForUnits(units []*quadlet.UnitFile, since time.Time, timeout time.Duration) error {
+	targets := make([]*waitTarget, len(units))
+	names := make([]string, len(units))
+	for i, u := range units {
+		targets[i] = &waitTarget{unit: u, since: since}
+		names[i] = u.ServiceName()
+	}
+
+	message := fmt.Sprintf("Waiting for %s to become ready", strings.Join(names, " "))
+	if timeout > 0 {
+		message += fmt.Sprintf(" (timeout %s)", timeout)
+	}
+	fmt.Println(shared.TitleStyle.Render(message + "..."))
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		pending := 0
+		for _, t := range targets {
+			if t.state == waitPending {
+				checkUnit(t)
+			}
+			if t.state == waitPending {
+				pending++
+			}
+		}
+		if pending == 0 || (!deadline.IsZero() && !time.Now().Before(deadline)) {
+			break
+		}
+		time.Sleep(waitPollInterval)
+	}
+
+	failed, timedOut := 0, 0
+	rows := make([][]string, len(targets))
+	for i, t := range targets {
+		result := "✓ ready"
+		switch t.state {
+		case waitFailed:
+			failed++
+			result = "✗ failed"
+		case waitPending:
+			timedOut++
+			result = "✗ timed out"
+		}
+		rows[i] = []string{t.unit.BaseName(), t.unit.ServiceName(), t.detail, result}
+	}
+	fmt.Println()
+	shared.PrintTable([]string{"Unit", "Service", "State", "Result"}, rows)
+
+	for _, t := range targets {
+		if t.state == waitReady {
+			continue
+		}
+		fmt.Println()
+		fmt.Println(shared.WarningStyle.Render(fmt.Sprintf("Last %d journal lines for %s:", waitJournalLines, t.unit.ServiceName())))
+		logs, err := manager.Journal(t.unit.ServiceName(), waitJournalLines)
+		if err != nil {
+			fmt.Println(shared.ErrorStyle.Render(err.Error()))
+		}
+		if strings.TrimSpace(logs) != "" {
+			fmt.Println(strings.TrimRight(logs, "\n"))
+		}
+	}
+
+	switch {
+	case failed > 0:
+		return cmdutil.Errorf("%d of %d unit(s) failed to start", failed, len(targets))
+	case timedOut > 0:
+		return cmdutil.WithExitCode(cmdutil.ExitTimeout, cmdutil.Errorf("%d of %d unit(s) not ready after %s", timedOut, len(targets), timeout))
+	}
+	fmt.Println(shared.SuccessStyle.Render(fmt.Sprintf("✓ %s ready", strings.Join(names, " "))))
+	return nil
+}
+
+// resolveUnitFiles finds the quadlet files of the named units on disk.
+func resolveUnitFiles(unitNames []string, types []string) ([]*quadlet.UnitFile, error) {
+	graph, err := loadGraph()
+	if err != nil {
+		return nil, err
+	}
+	names, err := resolveGraphNames(graph, unitNames, types)
+	if err != nil {
+		return nil, err
+	}
+	units := make([]*quadlet.UnitFile, len(names))
+	for i, name := range names {
+		units[i] = graph.Units[name]
+	}
+	return units, nil
+}
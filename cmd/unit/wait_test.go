@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
+	"github.com/mufeedali/quadlet-helper/internal/systemd/systemdtest"
+)
+
+// useHealth makes containerHealth return the given statuses in turn for each
+// container, repeating the last one.
+func useHealth(t *testing.T, statuses map[string][]string) {
+	t.Helper()
+	oldHealth, oldInterval := containerHealth, waitPollInterval
+	containerHealth = func(container string) (string, error) {
+		seq := statuses[container]
+		if len(seq) == 0 {
+			return "", errors.New("no such container")
+		}
+		status := seq[0]
+		if len(seq) > 1 {
+			statuses[container] = seq[1:]
+		}
+		return status, nil
+	}
+	waitPollInterval = time.Millisecond
+	t.Cleanup(func() { containerHealth, waitPollInterval = oldHealth, oldInterval })
+}
+
+func TestStartWait(t *testing.T) {
+	started := time.Now()
+	ranAfter := fmt.Sprintf("@%d", started.Unix())
+	ranBefore := fmt.Sprintf("@%d", started.Add(-time.Hour).Unix())
+	systemdtest.UseContainersDir(t, map[string]string{
+		"app/web.container":    "[Container]\nImage=nginx\nHealthCmd=curl -f localhost\n",
+		"app/worker.container": "[Container]\nImage=worker\n",
+		"app/db.container":     "[Container]\nImage=postgres\nContainerName=postgres\nHealthCmd=pg_isready\n",
+		"app/init.container":   "[Container]\nImage=init\n",
+	})
+
+	tests := []struct {
+		name     string
+		units    []string
+		props    map[string]map[string]string
+		health   map[string][]string
+		wantCode int
+	}{
+		{
+			name:   "ready once healthy",
+			units:  []string{"web", "worker"},
+			health: map[string][]string{"systemd-web": {"starting", "starting", "healthy"}},
+		},
+		{
+			name:     "unhealthy",
+			units:    []string{"db"},
+			health:   map[string][]string{"postgres": {"starting", "unhealthy"}},
+			wantCode: cmdutil.ExitFailure,
+		},
+		{
+			name:     "unit failed",
+			units:    []string{"worker"},
+			props:    map[string]map[string]string{"worker.service": {"ActiveState": "failed", "SubState": "failed", "Result": "exit-code"}},
+			wantCode: cmdutil.ExitFailure,
+		},
+		{
+			name:     "still activating",
+			units:    []string{"worker"},
+			props:    map[string]map[string]string{"worker.service": {"ActiveState": "activating", "SubState": "start"}},
+			wantCode: cmdutil.ExitTimeout,
+		},
+		{
+			name:   "oneshot finished",
+			units:  []string{"init"},
+			props:  map[string]map[string]string{"init.service": {"ActiveState": "inactive", "SubState": "dead", "Result": "success", "ExecMainStartTimestamp": ranAfter}},
+			health: map[string][]string{},
+		},
+		{
+			name:     "oneshot never started",
+			units:    []string{"init"},
+			props:    map[string]map[string]string{"init.service": {"ActiveState": "inactive", "SubState": "dead", "Result": "success"}},
+			wantCode: cmdutil.ExitFailure,
+		},
+		{
+			name:     "oneshot from an earlier start",
+			units:    []string{"init"},
+			props:    map[string]map[string]string{"init.service": {"ActiveState": "inactive", "SubState": "dead", "Result": "success", "ExecMainStartTimestamp": ranBefore, "InactiveEnterTimestamp": ranBefore}},
+			wantCode: cmdutil.ExitFailure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := systemdtest.UseFake(t, &manager)
+			for unit, props := range tt.props {
+				fake.Props[unit] = props
+			}
+			fake.Logs["worker.service"] = "line 1\nline 2\n"
+			useHealth(t, tt.health)
+
+			units, err := resolveUnitFiles(tt.units, nil)
+			if err != nil {
+				t.Fatalf("resolveUnitFiles() error = %v", err)
+			}
+			for _, u := range units {
+				fake.Active[u.ServiceName()] = true
+			}
+
+			err = waitForUnits(units, started, 50*time.Millisecond)
+			if got := cmdutil.ExitCode(err); got != tt.wantCode {
+				t.Errorf("waitForUnits() exit code = %d (%v), want %d", got, err, tt.wantCode)
+			}
+		})
+	}
+}
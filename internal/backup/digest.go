@@ -0,0 +1,270 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mufeedali/quadlet-helper/internal/config"
+)
+
+// DigestBackup summarizes one backup's runs over the period of a digest.
+type DigestBackup struct {
+	Name string
+	Type BackupType
+
+	// Runs, Successes, Failures and BytesTransferred cover the period.
+	Runs             int
+	Successes        int
+	Failures         int
+	BytesTransferred int64
+
+	// LastRun is the most recent run, even if it is older than the period.
+	LastRun *HistoryEntry
+	Failing bool // whether the most recent run failed
+	Streak  int  // consecutive runs with the most recent run's outcome
+
+	Error string // why the backup could not be summarized
+}
+
+// DigestData is what digest templates are executed with.
+type DigestData struct {
+	Hostname string
+	Since    time.Time
+	Until    time.Time
+	Backups  []DigestBackup
+	Runs     int
+	Failures int // failed runs in the period
+	Failing  int // backups whose most recent run failed
+}
+
+// NewDigest summarizes the runs of every configured backup between since and
+// until.
+func NewDigest(since, until time.Time) (DigestData, error) {
+	names, err := ListConfigs()
+	if err != nil {
+		return DigestData{}, err
+	}
+	hostname, _ := os.Hostname()
+	data := DigestData{Hostname: hostname, Since: since, Until: until}
+	for _, name := range names {
+		b := DigestBackup{Name: name}
+		if cfg, err := LoadConfig(name); err == nil {
+			b.Type = cfg.Type
+		}
+		entries, err := LoadHistory(name)
+		if err != nil {
+			b.Error = err.Error()
+		} else {
+			b.summarize(entries, since, until)
+		}
+		data.add(b)
+	}
+	return data, nil
+}
+
+// summarize fills in the runs among entries, which must be oldest first.
+func (b *DigestBackup) summarize(entries []HistoryEntry, since, until time.Time) {
+	var runs, inPeriod []HistoryEntry
+	for _, e := range entries {
+		if e.Kind != HistoryRun || e.Start.After(until) {
+			continue
+		}
+		runs = append(runs, e)
+		if !e.Start.Before(since) {
+			inPeriod = append(inPeriod, e)
+			b.BytesTransferred += e.BytesTransferred
+		}
+	}
+	if len(runs) == 0 {
+		return
+	}
+	b.LastRun = &runs[len(runs)-1]
+	b.Failing = !b.LastRun.Success
+	b.Streak = Summarize(runs).Streak
+
+	period := Summarize(inPeriod)
+	b.Runs, b.Successes, b.Failures = period.Runs, period.Successes, period.Failures
+}
+
+func (d *DigestData) add(b DigestBackup) {
+	d.Backups = append(d.Backups, b)
+	d.Runs += b.Runs
+	d.Failures += b.Failures
+	if b.Failing {
+		d.Failing++
+	}
+}
+
+// Template files that override the default digest.
+const (
+	DigestSubjectTemplateFile = "digest-subject.tmpl"
+	DigestTextTemplateFile    = "digest.txt.tmpl"
+	DigestHTMLTemplateFile    = "digest.html.tmpl"
+)
+
+const defaultDigestSubjectTemplate = `Backup digest: {{len .Backups}} backups{{if .Failing}}, {{.Failing}} failing{{end}}`
+
+const defaultDigestTextTemplate = `Backup Digest
+
+Host: {{.Hostname}}
+Period: {{.Since.Format "Mon, 02 Jan 2006 15:04"}} to {{.Until.Format "Mon, 02 Jan 2006 15:04"}}
+Runs: {{.Runs}} ({{.Failures}} failed)
+{{range .Backups}}
+{{.Name}}{{if .Type}} ({{.Type}}){{end}}
+{{- if .Error}}
+  Error: {{.Error}}
+{{- else if not .LastRun}}
+  Never run
+{{- else}}
+  Last run: {{.LastRun.Start.Format "Mon, 02 Jan 2006 15:04"}}, {{if .LastRun.Success}}success{{else}}FAILED{{end}}{{if gt .Streak 1}} ({{.Streak}} in a row){{end}}
+  Runs: {{.Runs}}, failed: {{.Failures}}{{if .BytesTransferred}}, transferred: {{bytes .BytesTransferred}}{{end}}
+{{- if and .Failing .LastRun.Error}}
+  Error: {{.LastRun.Error}}
+{{- end}}
+{{- end}}
+{{end}}
+This is an automated message from quadlet-helper.
+`
+
+const defaultDigestHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<style>
+body { font-family: sans-serif; }
+.container { padding: 20px; border: 1px solid #ddd; border-radius: 5px; max-width: 800px; margin: auto; }
+.success { color: green; }
+.failure { color: red; font-weight: bold; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 20px; border: 1px solid #ddd; }
+th, td { text-align: left; padding: 8px; border: 1px solid #ddd; }
+th { background-color: #f2f2f2; }
+</style>
+</head>
+<body>
+<div class="container">
+<h2>Backup Digest</h2>
+<p>{{.Hostname}}, {{.Since.Format "Mon, 02 Jan 2006 15:04"}} to {{.Until.Format "Mon, 02 Jan 2006 15:04"}}: {{.Runs}} runs, {{.Failures}} failed</p>
+<table>
+<tr><th>Backup</th><th>Last Run</th><th>Result</th><th>Runs</th><th>Failed</th><th>Transferred</th></tr>
+{{- range .Backups}}
+<tr>
+<td>{{.Name}}</td>
+{{- if .Error}}
+<td colspan="5" class="failure">{{.Error}}</td>
+{{- else if not .LastRun}}
+<td colspan="5">Never run</td>
+{{- else}}
+<td>{{.LastRun.Start.Format "Mon, 02 Jan 2006 15:04"}}</td>
+<td class="{{if .LastRun.Success}}success{{else}}failure{{end}}">{{if .LastRun.Success}}success{{else}}failed{{end}}{{if gt .Streak 1}} ({{.Streak}} in a row){{end}}</td>
+<td>{{.Runs}}</td>
+<td>{{.Failures}}</td>
+<td>{{if .BytesTransferred}}{{bytes .BytesTransferred}}{{end}}</td>
+{{- end}}
+</tr>
+{{- end}}
+</table>
+<p><small>This is an automated message from quadlet-helper.</small></p>
+</div>
+</body>
+</html>`
+
+// RenderDigest renders a digest with the user's templates, falling back to
+// the defaults for those there are none of.
+func RenderDigest(data DigestData) (RenderedNotification, error) {
+	dir, err := GetTemplatesDir()
+	if err != nil {
+		return RenderedNotification{}, err
+	}
+	return renderDigest(dir, data)
+}
+
+// renderDigest renders the digest templates in dir, or the defaults if dir is
+// empty.
+func renderDigest(dir string, data DigestData) (RenderedNotification, error) {
+	subject, err := renderText(dir, DigestSubjectTemplateFile, defaultDigestSubjectTemplate, data)
+	if err != nil {
+		return RenderedNotification{}, err
+	}
+	text, err := renderText(dir, DigestTextTemplateFile, defaultDigestTextTemplate, data)
+	if err != nil {
+		return RenderedNotification{}, err
+	}
+	html, err := renderHTML(dir, DigestHTMLTemplateFile, defaultDigestHTMLTemplate, data)
+	if err != nil {
+		return RenderedNotification{}, err
+	}
+	subject = strings.Join(strings.Fields(subject), " ")
+	return RenderedNotification{Subject: subject, Text: text, HTML: html}, nil
+}
+
+// SendDigest emails a digest to the global email recipients. If the user's
+// templates are broken, the digest is sent with the built-in ones and the
+// template error is returned as a warning.
+func SendDigest(data DigestData) (warnings []error, err error) {
+	emailConf := config.LoadEmailConfig()
+	if emailConf.Host == "" {
+		return nil, fmt.Errorf("no SMTP host configured")
+	}
+
+	rendered, err := RenderDigest(data)
+	if err != nil {
+		warnings = append(warnings, err)
+		rendered, _ = renderDigest("", data)
+	}
+	email := emailMessage{Subject: rendered.Subject, Text: rendered.Text, HTML: rendered.HTML}
+	if err := addressEmail(&email, emailConf, EmailConfig{}, "digest"); err != nil {
+		return warnings, err
+	}
+	return warnings, sendEmail(emailConf, email)
+}
+
+// getDigestStatePath returns the path of the file recording when the last
+// digest was sent.
+func getDigestStatePath() (string, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "digest.last"), nil
+}
+
+// LastDigest returns when the last digest was sent, or false if none was.
+func LastDigest() (time.Time, bool) {
+	path, err := getDigestStatePath()
+	if err != nil {
+		return time.Time{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	return t, err == nil
+}
+
+// RecordDigest records that a digest covering the time up to t was sent.
+func RecordDigest(t time.Time) error {
+	path, err := getDigestStatePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating state directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(t.Format(time.RFC3339)+"\n"), 0600); err != nil {
+		return fmt.Errorf("error recording digest: %w", err)
+	}
+	return nil
+}
+
+// DigestSince parses the --since duration of a digest, such as 7d or 36h,
+// into the start of its period.
+func DigestSince(duration string, now time.Time) (time.Time, error) {
+	d, err := ParseDuration(duration)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-d), nil
+}
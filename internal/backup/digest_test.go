@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mufeedali/quadlet-helper/internal/config"
+	"github.com/spf13/viper"
+)
+
+func TestDigestBackupSummarize(t *testing.T) {
+	day := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	run := func(hours int, success bool, bytes int64) HistoryEntry {
+		return HistoryEntry{Kind: HistoryRun, Start: day.Add(time.Duration(hours) * time.Hour), Success: success, BytesTransferred: bytes}
+	}
+	entries := []HistoryEntry{
+		run(-30, true, 100),
+		run(-20, false, 0),
+		run(2, false, 0),
+		{Kind: HistoryCleanup, Start: day.Add(3 * time.Hour), Success: true},
+		run(4, true, 1024),
+		run(6, true, 2048),
+		run(30, false, 0), // after the period
+	}
+
+	var b DigestBackup
+	b.summarize(entries, day, day.Add(24*time.Hour))
+	if b.Runs != 3 || b.Successes != 2 || b.Failures != 1 || b.BytesTransferred != 3072 {
+		t.Errorf("summarize() = %+v, want 3 runs, 2 successes, 1 failure, 3072 bytes", b)
+	}
+	if b.LastRun == nil || !b.LastRun.Start.Equal(day.Add(6*time.Hour)) || b.Failing || b.Streak != 2 {
+		t.Errorf("summarize() last run = %+v, failing = %v, streak = %d", b.LastRun, b.Failing, b.Streak)
+	}
+
+	// A backup that has not run in the period still shows its last run.
+	b = DigestBackup{}
+	b.summarize(entries[:2], day, day.Add(24*time.Hour))
+	if b.Runs != 0 || b.LastRun == nil || !b.Failing {
+		t.Errorf("summarize() of older runs = %+v, want no runs and a failing last run", b)
+	}
+}
+
+func TestRenderDigestDefaults(t *testing.T) {
+	since := time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)
+	var data DigestData
+	data.Hostname, data.Since, data.Until = "nas", since, since.Add(24*time.Hour)
+	data.add(DigestBackup{
+		Name: "docs", Type: BackupTypeRestic, Runs: 2, Failures: 2, Failing: true, Streak: 5,
+		LastRun: &HistoryEntry{Kind: HistoryRun, Start: since.Add(time.Hour), Error: "exit status 1"},
+	})
+	data.add(DigestBackup{Name: "photos", Runs: 1, Successes: 1, BytesTransferred: 2048, LastRun: &HistoryEntry{Kind: HistoryRun, Start: since, Success: true}})
+	data.add(DigestBackup{Name: "music"})
+
+	rendered, err := renderDigest("", data)
+	if err != nil {
+		t.Fatalf("renderDigest() error = %v", err)
+	}
+	if rendered.Subject != "Backup digest: 3 backups, 1 failing" {
+		t.Errorf("Subject = %q", rendered.Subject)
+	}
+	for _, want := range []string{
+		"Runs: 3 (2 failed)",
+		"docs (restic)\n  Last run: Fri, 02 Jan 2026 09:00, FAILED (5 in a row)\n",
+		"  Error: exit status 1\n",
+		"transferred: 2.0 KiB",
+		"music\n  Never run\n",
+	} {
+		if !strings.Contains(rendered.Text, want) {
+			t.Errorf("Text does not contain %q:\n%s", want, rendered.Text)
+		}
+	}
+	if !strings.Contains(rendered.HTML, `<td class="failure">failed (5 in a row)</td>`) {
+		t.Errorf("HTML does not show the failing backup:\n%s", rendered.HTML)
+	}
+}
+
+func TestSendDigestWithBrokenTemplate(t *testing.T) {
+	host, port, session := smtpStub(t)
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	templates := filepath.Join(configHome, "quadlet-helper", "templates")
+	if err := os.MkdirAll(templates, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templates, DigestSubjectTemplateFile), []byte("{{.Missing}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("email.host", host)
+	viper.Set("email.port", port)
+	viper.Set("email.tls", config.TLSNone)
+	viper.Set("email.from", "qh@example.org")
+	viper.Set("email.to", "admin@example.org")
+
+	var data DigestData
+	data.Hostname = "nas"
+	warnings, err := SendDigest(data)
+	if err != nil {
+		t.Fatalf("SendDigest() error = %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("SendDigest() warnings = %v, want the template error", warnings)
+	}
+	if s := <-session; !strings.Contains(s.Data, "Subject: Backup digest:") {
+		t.Errorf("digest was not sent with the default subject:\n%s", s.Data)
+	}
+}
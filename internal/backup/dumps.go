@@ -0,0 +1,314 @@
+package backup
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DumpKind is the database engine a dump is taken from.
+type DumpKind string
+
+const (
+	DumpPostgres DumpKind = "postgres"
+	DumpMySQL    DumpKind = "mysql"
+	DumpMariaDB  DumpKind = "mariadb"
+	DumpSQLite   DumpKind = "sqlite"
+)
+
+// Dump is a database dumped from inside a container before the backup runs.
+// Dumps are written to a staging directory that is backed up along with the
+// sources and removed again afterwards.
+type Dump struct {
+	Kind      DumpKind `yaml:"kind"`
+	Container string   `yaml:"container"`          // podman container name
+	Database  string   `yaml:"database,omitempty"` // postgres, mysql and mariadb; all databases if empty
+	User      string   `yaml:"user,omitempty"`     // defaults to postgres or root
+	// PasswordFile is read on the host and handed to the dump tool through
+	// PGPASSWORD or MYSQL_PWD, so it never appears on a command line.
+	PasswordFile string `yaml:"password_file,omitempty"`
+	Path         string `yaml:"path,omitempty"` // sqlite: database file inside the container
+	File         string `yaml:"file,omitempty"` // name of the dump file; derived from the fields above if empty
+}
+
+// FileName returns the name of the file the dump is written to in the
+// staging directory.
+func (d Dump) FileName() string {
+	if d.File != "" {
+		return d.File
+	}
+	name := d.Container
+	switch d.Kind {
+	case DumpSQLite:
+		base := strings.TrimSuffix(filepath.Base(d.Path), filepath.Ext(d.Path))
+		return name + "-" + base + ".sqlite"
+	default:
+		if d.Database != "" {
+			name += "-" + d.Database
+		}
+		return name + ".sql"
+	}
+}
+
+func (d Dump) user() string {
+	switch {
+	case d.User != "":
+		return d.User
+	case d.Kind == DumpPostgres:
+		return "postgres"
+	default:
+		return "root"
+	}
+}
+
+// passwordEnv returns the environment variable the dump tool reads its
+// password from.
+func (d Dump) passwordEnv() string {
+	if d.Kind == DumpPostgres {
+		return "PGPASSWORD"
+	}
+	return "MYSQL_PWD"
+}
+
+// DumpArgs returns the "podman exec" arguments that write the dump to
+// standard output. SQLite dumps are written to containerPath inside the
+// container instead and copied out by dumpSQLite.
+func DumpArgs(d Dump, containerPath string) []string {
+	args := []string{"exec"}
+	if d.PasswordFile != "" {
+		// Without a value, podman passes the variable on from its own
+		// environment.
+		args = append(args, "--env", d.passwordEnv())
+	}
+	args = append(args, d.Container)
+
+	switch d.Kind {
+	case DumpPostgres:
+		if d.Database == "" {
+			return append(args, "pg_dumpall", "--username", d.user(), "--clean", "--if-exists")
+		}
+		return append(args, "pg_dump", "--username", d.user(), "--clean", "--if-exists", d.Database)
+	case DumpMySQL, DumpMariaDB:
+		tool := "mysqldump"
+		if d.Kind == DumpMariaDB {
+			tool = "mariadb-dump"
+		}
+		args = append(args, tool, "--user="+d.user(), "--single-transaction", "--routines", "--events")
+		if d.Database == "" {
+			return append(args, "--all-databases")
+		}
+		return append(args, "--databases", d.Database)
+	case DumpSQLite:
+		return append(args, "sqlite3", d.Path, ".backup '"+strings.ReplaceAll(containerPath, "'", "''")+"'")
+	}
+	return nil
+}
+
+func (d Dump) validate() error {
+	switch d.Kind {
+	case DumpPostgres, DumpMySQL, DumpMariaDB:
+	case DumpSQLite:
+		if d.Path == "" {
+			return fmt.Errorf("dump of %s: path is required for sqlite dumps", d.Container)
+		}
+		if d.PasswordFile != "" {
+			return fmt.Errorf("dump of %s: sqlite dumps take no password_file", d.Container)
+		}
+	default:
+		return fmt.Errorf("invalid dump kind: %q (must be postgres, mysql, mariadb or sqlite)", d.Kind)
+	}
+	if d.Container == "" {
+		return fmt.Errorf("%s dump: container is required", d.Kind)
+	}
+	if d.Kind != DumpSQLite && d.Path != "" {
+		return fmt.Errorf("dump of %s: path is only used by sqlite dumps", d.Container)
+	}
+	if name := d.FileName(); strings.ContainsRune(name, os.PathSeparator) || name == "." || name == ".." {
+		return fmt.Errorf("dump of %s: invalid file name %q", d.Container, name)
+	}
+	return nil
+}
+
+// validateDumps checks every dump and that their files do not collide.
+func validateDumps(dumps []Dump) error {
+	var names []string
+	for _, d := range dumps {
+		if err := d.validate(); err != nil {
+			return err
+		}
+		if slices.Contains(names, d.FileName()) {
+			return fmt.Errorf("two dumps write to %s; set file on one of them", d.FileName())
+		}
+		names = append(names, d.FileName())
+	}
+	return nil
+}
+
+// DumpStagingDir returns the directory a backup's dumps and exported volumes
+// are written to. It is added to the backup's sources, so its name must not
+// clash with theirs.
+func DumpStagingDir(name string) (string, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "dumps", name+"-dumps"), nil
+}
+
+// isDumpStagingDir reports whether source is the backup's staging
+// directory, which only exists while a backup runs.
+func (c *Config) isDumpStagingDir(source string) bool {
+	if !c.usesStaging() {
+		return false
+	}
+	dir, err := DumpStagingDir(c.Name)
+	return err == nil && source == dir
+}
+
+// liveSources returns the sources that exist between runs, leaving out the
+// dump staging directory.
+func (c *Config) liveSources() []string {
+	return slices.DeleteFunc(slices.Clone(c.Source), c.isDumpStagingDir)
+}
+
+// podmanCommand builds a podman command. Tests replace it.
+var podmanCommand = func(args ...string) *exec.Cmd {
+	return exec.Command("podman", args...)
+}
+
+// prepareStaging takes the lock on a backup's staging directory and gives
+// the run an empty one. The returned function removes it again and releases
+// the lock. A dry run leaves the contents alone, and only makes sure the
+// directory exists so that the backup tool finds it; if a run is using it, a
+// dry run does not touch it at all.
+func prepareStaging(name string, dryRun bool) (func(), error) {
+	dir, err := DumpStagingDir(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+		return nil, fmt.Errorf("error creating dump staging directory: %w", err)
+	}
+	unlock, err := tryFileLock(dir)
+	if errors.Is(err, errLocked) {
+		if dryRun {
+			return func() {}, nil
+		}
+		return nil, fmt.Errorf("another run of %s is using its dump staging directory", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cleanup := func() {
+		_ = os.RemoveAll(dir)
+		unlock()
+	}
+	if dryRun {
+		// Only an empty directory, which the dry run made, is removed.
+		cleanup = func() {
+			_ = os.Remove(dir)
+			unlock()
+		}
+	} else if err := os.RemoveAll(dir); err != nil {
+		unlock()
+		return nil, fmt.Errorf("error clearing dump staging directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("error creating dump staging directory: %w", err)
+	}
+	return cleanup, nil
+}
+
+// runDumps writes every dump into the staging directory prepareStaging set
+// up and returns a log of what it did.
+func runDumps(config *Config, dryRun bool) (string, error) {
+	dir, err := DumpStagingDir(config.Name)
+	if err != nil {
+		return "", err
+	}
+
+	var log strings.Builder
+	for _, d := range config.Dumps {
+		line := fmt.Sprintf("Dumping %s %s from %s to %s\n", d.Kind, cmp.Or(d.Database, d.Path, "databases"), d.Container, d.FileName())
+		if dryRun {
+			line = "Would dump" + strings.TrimPrefix(line, "Dumping")
+		}
+		fmt.Print(line)
+		log.WriteString(line)
+		if dryRun {
+			continue
+		}
+		if err := runDump(d, filepath.Join(dir, d.FileName())); err != nil {
+			return log.String(), err
+		}
+	}
+	return log.String(), nil
+}
+
+// runDump writes a single dump to path, through a temporary file so that a
+// failed dump never leaves a truncated file behind.
+func runDump(d Dump, path string) error {
+	tmp := path + ".tmp"
+	if d.Kind == DumpSQLite {
+		if err := dumpSQLite(d, tmp); err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+		return os.Rename(tmp, path)
+	}
+
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error creating dump file: %w", err)
+	}
+	cmd := podmanCommand(DumpArgs(d, "")...)
+	cmd.Env = os.Environ()
+	if d.PasswordFile != "" {
+		password, err := os.ReadFile(d.PasswordFile)
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(tmp)
+			return fmt.Errorf("dump of %s: error reading password file: %w", d.Container, err)
+		}
+		cmd.Env = append(cmd.Env, d.passwordEnv()+"="+strings.TrimRight(string(password), "\r\n"))
+	}
+	var stderr bytes.Buffer
+	cmd.Stdout = file
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("dump of %s from %s failed: %w\n%s", cmp.Or(d.Database, "all databases"), d.Container, err, strings.TrimSpace(stderr.String()))
+	}
+	return os.Rename(tmp, path)
+}
+
+// dumpSQLite takes an online backup of an SQLite database inside the
+// container, copies it out to path and removes it from the container.
+func dumpSQLite(d Dump, path string) error {
+	inContainer := "/tmp/qh-dump-" + filepath.Base(path)
+	steps := [][]string{
+		DumpArgs(d, inContainer),
+		{"cp", d.Container + ":" + inContainer, path},
+	}
+	defer func() {
+		_ = podmanCommand("exec", d.Container, "rm", "-f", inContainer).Run()
+	}()
+	for _, args := range steps {
+		if output, err := podmanCommand(args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("dump of %s from %s failed: %w\n%s", d.Path, d.Container, err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
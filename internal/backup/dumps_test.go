@@ -0,0 +1,212 @@
+package backup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDumpArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		dump Dump
+		want []string
+	}{
+		{
+			"postgres database",
+			Dump{Kind: DumpPostgres, Container: "immich-db", Database: "immich"},
+			[]string{"exec", "immich-db", "pg_dump", "--username", "postgres", "--clean", "--if-exists", "immich"},
+		},
+		{
+			"postgres cluster with password",
+			Dump{Kind: DumpPostgres, Container: "db", User: "admin", PasswordFile: "/run/secrets/pg"},
+			[]string{"exec", "--env", "PGPASSWORD", "db", "pg_dumpall", "--username", "admin", "--clean", "--if-exists"},
+		},
+		{
+			"mysql",
+			Dump{Kind: DumpMySQL, Container: "db", Database: "app"},
+			[]string{"exec", "db", "mysqldump", "--user=root", "--single-transaction", "--routines", "--events", "--databases", "app"},
+		},
+		{
+			"mariadb all databases",
+			Dump{Kind: DumpMariaDB, Container: "db", PasswordFile: "/pw"},
+			[]string{"exec", "--env", "MYSQL_PWD", "db", "mariadb-dump", "--user=root", "--single-transaction", "--routines", "--events", "--all-databases"},
+		},
+		{
+			"sqlite",
+			Dump{Kind: DumpSQLite, Container: "vaultwarden", Path: "/data/db.sqlite3"},
+			[]string{"exec", "vaultwarden", "sqlite3", "/data/db.sqlite3", ".backup '/tmp/out'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DumpArgs(tt.dump, "/tmp/out"); !slices.Equal(got, tt.want) {
+				t.Errorf("DumpArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDumpFileName(t *testing.T) {
+	tests := []struct {
+		dump Dump
+		want string
+	}{
+		{Dump{Kind: DumpPostgres, Container: "immich-db", Database: "immich"}, "immich-db-immich.sql"},
+		{Dump{Kind: DumpMariaDB, Container: "db"}, "db.sql"},
+		{Dump{Kind: DumpSQLite, Container: "vw", Path: "/data/db.sqlite3"}, "vw-db.sqlite"},
+		{Dump{Kind: DumpPostgres, Container: "db", File: "custom.sql"}, "custom.sql"},
+	}
+	for _, tt := range tests {
+		if got := tt.dump.FileName(); got != tt.want {
+			t.Errorf("FileName(%+v) = %q, want %q", tt.dump, got, tt.want)
+		}
+	}
+}
+
+func TestValidateDumps(t *testing.T) {
+	tests := []struct {
+		name    string
+		dumps   []Dump
+		wantErr bool
+	}{
+		{"valid", []Dump{{Kind: DumpPostgres, Container: "a"}, {Kind: DumpSQLite, Container: "b", Path: "/db"}}, false},
+		{"unknown kind", []Dump{{Kind: "mongo", Container: "a"}}, true},
+		{"missing container", []Dump{{Kind: DumpPostgres}}, true},
+		{"sqlite without path", []Dump{{Kind: DumpSQLite, Container: "a"}}, true},
+		{"path outside sqlite", []Dump{{Kind: DumpMySQL, Container: "a", Path: "/db"}}, true},
+		{"file with a slash", []Dump{{Kind: DumpMySQL, Container: "a", File: "../x.sql"}}, true},
+		{"colliding files", []Dump{{Kind: DumpMySQL, Container: "a"}, {Kind: DumpMariaDB, Container: "a"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDumps(tt.dumps)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateDumps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizedAddsDumpStagingDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	config := Config{Name: "immich", Source: []string{"/srv/immich"}, Dumps: []Dump{{Kind: DumpPostgres, Container: "immich-db"}}}
+	staging, err := DumpStagingDir("immich")
+	if err != nil {
+		t.Fatalf("DumpStagingDir() error = %v", err)
+	}
+
+	normalized := config.Normalized()
+	if want := []string{"/srv/immich", staging}; !slices.Equal(normalized.Source, want) {
+		t.Errorf("Normalized().Source = %v, want %v", normalized.Source, want)
+	}
+	if twice := normalized.Normalized(); len(twice.Source) != 2 {
+		t.Errorf("normalizing twice gave sources %v", twice.Source)
+	}
+	if len(config.Source) != 1 {
+		t.Errorf("Normalized() changed the original sources to %v", config.Source)
+	}
+	if got := normalized.liveSources(); !slices.Equal(got, []string{"/srv/immich"}) {
+		t.Errorf("liveSources() = %v, want only /srv/immich", got)
+	}
+}
+
+func TestRunDumps(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	// Stand in for podman: print the arguments and the password variable.
+	old := podmanCommand
+	podmanCommand = func(args ...string) *exec.Cmd {
+		return exec.Command("sh", append([]string{"-c", `echo "$* pw=$PGPASSWORD"`, "podman"}, args...)...)
+	}
+	t.Cleanup(func() { podmanCommand = old })
+
+	config := &Config{Name: "immich", Dumps: []Dump{{Kind: DumpPostgres, Container: "immich-db", Database: "immich", PasswordFile: passwordFile}}}
+	cleanup, err := prepareStaging("immich", false)
+	if err != nil {
+		t.Fatalf("prepareStaging() error = %v", err)
+	}
+	defer func() { cleanup() }()
+	log, err := runDumps(config, false)
+	if err != nil {
+		t.Fatalf("runDumps() error = %v", err)
+	}
+	if !strings.Contains(log, "Dumping postgres immich from immich-db") {
+		t.Errorf("runDumps() log = %q", log)
+	}
+
+	staging, _ := DumpStagingDir("immich")
+	data, err := os.ReadFile(filepath.Join(staging, "immich-db-immich.sql"))
+	if err != nil {
+		t.Fatalf("reading dump: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(data)), "exec --env PGPASSWORD immich-db pg_dump --username postgres --clean --if-exists immich pw=hunter2"; got != want {
+		t.Errorf("dump = %q, want %q", got, want)
+	}
+
+	cleanup()
+	if cleanup, err = prepareStaging("immich", false); err != nil {
+		t.Fatalf("prepareStaging() error = %v", err)
+	}
+	podmanCommand = func(args ...string) *exec.Cmd {
+		return exec.Command("sh", "-c", "echo 'pg_dump: connection refused' >&2; exit 1")
+	}
+	if _, err := runDumps(config, false); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("runDumps() error = %v, want the dump tool's error", err)
+	}
+	if entries, _ := os.ReadDir(staging); len(entries) != 0 {
+		t.Errorf("failed dump left files behind: %v", entries)
+	}
+}
+
+func TestPrepareStaging(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	staging, _ := DumpStagingDir("immich")
+
+	cleanup, err := prepareStaging("immich", false)
+	if err != nil {
+		t.Fatalf("prepareStaging() error = %v", err)
+	}
+	dump := filepath.Join(staging, "immich-db.sql")
+	if err := os.WriteFile(dump, []byte("dump"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// While a run is using the directory, another run is refused and a dry
+	// run leaves the dumps alone.
+	if _, err := prepareStaging("immich", false); err == nil {
+		t.Error("prepareStaging() during a run error = nil, want busy")
+	}
+	dryCleanup, err := prepareStaging("immich", true)
+	if err != nil {
+		t.Fatalf("prepareStaging(dry run) error = %v", err)
+	}
+	dryCleanup()
+	if _, err := os.Stat(dump); err != nil {
+		t.Errorf("dry run removed the running backup's dump: %v", err)
+	}
+
+	cleanup()
+	if _, err := os.Stat(staging); !os.IsNotExist(err) {
+		t.Errorf("staging directory after the run: %v, want removed", err)
+	}
+
+	// A dry run on its own creates the directory and removes it again.
+	dryCleanup, err = prepareStaging("immich", true)
+	if err != nil {
+		t.Fatalf("prepareStaging(dry run) error = %v", err)
+	}
+	if info, err := os.Stat(staging); err != nil || !info.IsDir() {
+		t.Errorf("dry run did not create the staging directory: %v", err)
+	}
+	dryCleanup()
+	if _, err := os.Stat(staging); !os.IsNotExist(err) {
+		t.Errorf("staging directory after the dry run: %v, want removed", err)
+	}
+}
@@ -0,0 +1,425 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mufeedali/quadlet-helper/internal/config"
+)
+
+// emailMessage is an email with plain text and HTML versions of the same
+// report and any number of attachments.
+type emailMessage struct {
+	From        *mail.Address
+	To          []*mail.Address
+	CC          []*mail.Address
+	BCC         []*mail.Address // only in the envelope, never in a header
+	Subject     string
+	Date        time.Time
+	Text        string
+	HTML        string
+	Attachments []emailAttachment
+}
+
+type emailAttachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// gzipAttachment compresses data into an attachment named name+".gz".
+func gzipAttachment(name string, data []byte) (emailAttachment, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Name = name
+	if _, err := zw.Write(data); err != nil {
+		return emailAttachment{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return emailAttachment{}, err
+	}
+	return emailAttachment{Name: name + ".gz", ContentType: "application/gzip", Data: buf.Bytes()}, nil
+}
+
+// Bytes renders the message as MIME with CRLF line endings: a
+// multipart/alternative of the text and HTML parts, wrapped in
+// multipart/mixed when there are attachments.
+func (m emailMessage) Bytes() ([]byte, error) {
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	messageID, err := newMessageID(m.From)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	var contentType string
+	if len(m.Attachments) == 0 {
+		contentType, err = writeAlternative(&body, m.Text, m.HTML)
+	} else {
+		contentType, err = m.writeMixed(&body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	to := "undisclosed-recipients:;"
+	if len(m.To) > 0 {
+		to = formatAddresses(m.To)
+	}
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", m.From.String()},
+		{"To", to},
+	}
+	if len(m.CC) > 0 {
+		headers = append(headers, [2]string{"Cc", formatAddresses(m.CC)})
+	}
+	headers = append(headers, [][2]string{
+		{"Subject", mime.QEncoding.Encode("UTF-8", m.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", contentType},
+	}...)
+	for _, h := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", h[0], h[1])
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// writeMixed writes the alternative parts followed by the attachments and
+// returns the Content-Type of the whole.
+func (m emailMessage) writeMixed(w io.Writer) (string, error) {
+	mixed := multipart.NewWriter(w)
+
+	var alternative bytes.Buffer
+	altType, err := writeAlternative(&alternative, m.Text, m.HTML)
+	if err != nil {
+		return "", err
+	}
+	part, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {altType}})
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(alternative.Bytes()); err != nil {
+		return "", err
+	}
+
+	for _, a := range m.Attachments {
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(a.ContentType, map[string]string{"name": a.Name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return "", err
+		}
+		if err := writeBase64(part, a.Data); err != nil {
+			return "", err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return "", err
+	}
+	return mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mixed.Boundary()}), nil
+}
+
+// writeAlternative writes the text and HTML versions as quoted-printable parts
+// of a multipart/alternative and returns its Content-Type. The HTML part comes
+// last, as the one clients should prefer.
+func writeAlternative(w io.Writer, text, html string) (string, error) {
+	alternative := multipart.NewWriter(w)
+	for _, p := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		part, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.content)); err != nil {
+			return "", err
+		}
+		if err := qp.Close(); err != nil {
+			return "", err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return "", err
+	}
+	return mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": alternative.Boundary()}), nil
+}
+
+// writeBase64 writes data base64-encoded in lines of 76 characters.
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
+
+// newMessageID returns a unique Message-ID in the domain of the sender.
+func newMessageID(from *mail.Address) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	domain := "localhost"
+	if _, d, ok := strings.Cut(from.Address, "@"); ok && d != "" {
+		domain = d
+	}
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain), nil
+}
+
+// formatAddresses formats addresses for a header, encoding non-ASCII display
+// names.
+func formatAddresses(addrs []*mail.Address) string {
+	formatted := make([]string, len(addrs))
+	for i, a := range addrs {
+		formatted[i] = a.String()
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// recipients returns the envelope recipients of the message: everyone in To,
+// Cc and Bcc, once each.
+func (m emailMessage) recipients() []string {
+	var rcpt []string
+	for _, list := range [][]*mail.Address{m.To, m.CC, m.BCC} {
+		for _, a := range list {
+			if !slices.Contains(rcpt, a.Address) {
+				rcpt = append(rcpt, a.Address)
+			}
+		}
+	}
+	return rcpt
+}
+
+// sendEmail sends an email using SMTP
+func sendEmail(emailConf config.EmailConfig, email emailMessage) error {
+	if err := emailConf.Validate(); err != nil {
+		return err
+	}
+	if email.From == nil {
+		return fmt.Errorf("no sender email address configured")
+	}
+	toList := email.recipients()
+	if len(toList) == 0 {
+		return fmt.Errorf("no recipient email address configured")
+	}
+
+	msg, err := email.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	// Setup authentication
+	var auth smtp.Auth
+	if emailConf.Username != "" {
+		password, err := emailConf.Password()
+		if err != nil {
+			return err
+		}
+		auth = smtpAuth(emailConf, password)
+	}
+
+	client, err := dialSMTP(emailConf)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	// Authenticate
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("SMTP server does not support authentication")
+		}
+		if err = client.Auth(auth); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	// Set sender
+	if err = client.Mail(email.From.Address); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+
+	// Set recipients
+	for _, addr := range toList {
+		if err = client.Rcpt(addr); err != nil {
+			return fmt.Errorf("failed to set recipient: %w", err)
+		}
+	}
+
+	// Send message
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to get data writer: %w", err)
+	}
+
+	_, err = w.Write(msg)
+	if err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close data writer: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dialSMTP connects to the SMTP server in the configured TLS mode.
+func dialSMTP(emailConf config.EmailConfig) (*smtp.Client, error) {
+	addr := net.JoinHostPort(emailConf.Host, strconv.Itoa(emailConf.Port))
+	mode := emailConf.TLSMode()
+
+	var tlsConfig *tls.Config
+	if mode != config.TLSNone {
+		var err error
+		if tlsConfig, err = smtpTLSConfig(emailConf); err != nil {
+			return nil, err
+		}
+	}
+
+	if mode == config.TLSImplicit {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: smtpTimeout}, "tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to SMTP server using TLS: %w", err)
+		}
+		client, err := smtp.NewClient(conn, emailConf.Host)
+		if err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to create SMTP client: %w", err)
+		}
+		return client, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, smtpTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	client, err := smtp.NewClient(conn, emailConf.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to create SMTP client: %w", err)
+	}
+	if mode == config.TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			_ = client.Close()
+			return nil, fmt.Errorf("SMTP server does not support STARTTLS (set email.tls to implicit or none if it should not be used)")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	return client, nil
+}
+
+const smtpTimeout = 30 * time.Second
+
+// smtpTLSConfig returns the TLS settings for the SMTP server, trusting the
+// configured CA on top of the system ones.
+func smtpTLSConfig(emailConf config.EmailConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         emailConf.Host,
+		InsecureSkipVerify: emailConf.TLSInsecureSkipVerify,
+	}
+	if emailConf.TLSCAFile != "" {
+		data, err := os.ReadFile(emailConf.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SMTP CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", emailConf.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// smtpAuth returns the authentication for the configured mechanism.
+func smtpAuth(emailConf config.EmailConfig, password string) smtp.Auth {
+	switch emailConf.Auth {
+	case config.AuthLogin:
+		return loginAuth{username: emailConf.Username, password: password, host: emailConf.Host}
+	case config.AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(emailConf.Username, password)
+	}
+	return smtp.PlainAuth("", emailConf.Username, password, emailConf.Host)
+}
+
+// loginAuth implements the LOGIN mechanism, which some servers offer in
+// place of PLAIN. Like smtp.PlainAuth, it only sends the password over TLS
+// or to localhost.
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	prompt := strings.ToLower(string(fromServer))
+	switch {
+	case strings.HasPrefix(prompt, "user"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "pass"):
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected LOGIN prompt %q", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
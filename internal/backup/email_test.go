@@ -0,0 +1,408 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mufeedali/quadlet-helper/internal/config"
+)
+
+// smtpSession is what the stub SMTP server received in one session.
+type smtpSession struct {
+	Commands []string
+	Data     string
+	TLS      bool
+}
+
+// smtpStub accepts a single plain SMTP session on a local port and sends
+// what it received on the returned channel.
+func smtpStub(t *testing.T) (host string, port int, session <-chan smtpSession) {
+	return smtpTLSStub(t, config.TLSNone, nil)
+}
+
+// smtpTLSStub is smtpStub for a server that speaks TLS from the start in the
+// implicit mode, and offers STARTTLS in the starttls mode. It accepts any
+// AUTH PLAIN, LOGIN or CRAM-MD5 exchange.
+func smtpTLSStub(t *testing.T, mode string, tlsConfig *tls.Config) (host string, port int, session <-chan smtpSession) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	done := make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		var s smtpSession
+		if mode == config.TLSImplicit {
+			conn = tls.Server(conn, tlsConfig)
+			s.TLS = true
+		}
+		defer func() { _ = conn.Close() }()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+		readLine := func() (string, error) {
+			line, err := r.ReadString('\n')
+			line = strings.TrimRight(line, "\r\n")
+			s.Commands = append(s.Commands, line)
+			return line, err
+		}
+		reply("220 stub ESMTP")
+		for {
+			cmd, err := readLine()
+			if err != nil {
+				break
+			}
+			fields := strings.Fields(strings.ToUpper(cmd) + " ")
+			switch fields[0] {
+			case "EHLO", "HELO":
+				if mode == config.TLSStartTLS && !s.TLS {
+					reply("250-stub")
+					reply("250 STARTTLS")
+				} else {
+					reply("250-stub")
+					reply("250 AUTH PLAIN LOGIN CRAM-MD5")
+				}
+			case "STARTTLS":
+				reply("220 go ahead")
+				conn = tls.Server(conn, tlsConfig)
+				r = bufio.NewReader(conn)
+				s.TLS = true
+			case "AUTH":
+				switch fields[1] {
+				case "LOGIN":
+					reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+					_, _ = readLine()
+					reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+					_, _ = readLine()
+				case "CRAM-MD5":
+					reply("334 " + base64.StdEncoding.EncodeToString([]byte("<1@stub>")))
+					_, _ = readLine()
+				}
+				reply("235 authenticated")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(line, "."))
+				}
+				s.Data = data.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				done <- s
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+		done <- s
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, done
+}
+
+func TestSendNotificationEmail(t *testing.T) {
+	host, port, session := smtpStub(t)
+	emailConf := config.EmailConfig{
+		Host:       host,
+		Port:       port,
+		TLS:        config.TLSNone,
+		From:       "Bäckups <qh@example.org>",
+		Recipients: config.Recipients{To: config.AddressList{"admin@example.org"}},
+		OnFailure: config.Recipients{
+			To:  config.AddressList{"On Call <oncall@example.org>, ops@example.org"},
+			CC:  config.AddressList{"lead@example.org"},
+			BCC: config.AddressList{"audit@example.org"},
+		},
+	}
+	backupConfig := &Config{Name: "immich", Type: BackupTypeRestic, Source: []string{"/srv/immich"}, Destination: Destination{Repository: "/repo"}}
+
+	var details strings.Builder
+	for i := range 80 {
+		details.WriteString("line " + strings.Repeat("x", i%3) + "\n")
+	}
+	details.WriteString("Fatal: repository is locked\n")
+	rendered, err := renderNotification("", newNotificationData(backupConfig, "failure", details.String(), nil))
+	if err != nil {
+		t.Fatalf("renderNotification() error = %v", err)
+	}
+	if err := sendEmailNotification(backupConfig, emailConf, "failure", details.String(), rendered); err != nil {
+		t.Fatalf("sendEmailNotification() error = %v", err)
+	}
+	s := <-session
+
+	var rcpt []string
+	for _, cmd := range s.Commands {
+		if addr, ok := strings.CutPrefix(cmd, "RCPT TO:"); ok {
+			rcpt = append(rcpt, addr)
+		}
+	}
+	if want := "<oncall@example.org> <ops@example.org> <lead@example.org> <audit@example.org>"; strings.Join(rcpt, " ") != want {
+		t.Errorf("recipients = %v, want %s", rcpt, want)
+	}
+	if strings.Contains(strings.ReplaceAll(s.Data, "\r\n", ""), "\n") {
+		t.Error("message has bare LF line endings")
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(s.Data))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Backup FAILURE: immich" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	if from, err := msg.Header.AddressList("From"); err != nil || from[0].Name != "Bäckups" {
+		t.Errorf("From = %q (%v)", msg.Header.Get("From"), err)
+	}
+	if to := msg.Header.Get("To"); to != `"On Call" <oncall@example.org>, <ops@example.org>` {
+		t.Errorf("To = %q", to)
+	}
+	if cc := msg.Header.Get("Cc"); cc != "<lead@example.org>" {
+		t.Errorf("Cc = %q", cc)
+	}
+	if strings.Contains(s.Data, "audit@example.org") {
+		t.Error("Bcc recipient appears in the message")
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date header: %v", err)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@example.org>") {
+		t.Errorf("Message-ID = %q", id)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+	mixed := multipart.NewReader(msg.Body, params["boundary"])
+
+	// The first part holds the text and HTML alternatives.
+	part, err := mixed.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart() error = %v", err)
+	}
+	altType, altParams, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+	if altType != "multipart/alternative" {
+		t.Fatalf("first part is %s, want multipart/alternative", altType)
+	}
+	alternative := multipart.NewReader(part, altParams["boundary"])
+	for _, want := range []string{"text/plain", "text/html"} {
+		p, err := alternative.NextRawPart()
+		if err != nil {
+			t.Fatalf("alternative part %s: %v", want, err)
+		}
+		if got, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type")); got != want {
+			t.Errorf("alternative part = %s, want %s", got, want)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		if err != nil {
+			t.Fatalf("reading %s part: %v", want, err)
+		}
+		if !strings.Contains(string(body), "Fatal: repository is locked") || !strings.Contains(string(body), "last 50 lines") {
+			t.Errorf("%s part does not show the tail of the log:\n%s", want, body)
+		}
+	}
+
+	// The second part is the full log, gzipped.
+	part, err = mixed.NextRawPart()
+	if err != nil {
+		t.Fatalf("attachment: %v", err)
+	}
+	if _, params, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); params["filename"] != "immich-failure.log.gz" {
+		t.Errorf("Content-Disposition = %q", part.Header.Get("Content-Disposition"))
+	}
+	encoded, _ := io.ReadAll(part)
+	compressed, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil {
+		t.Fatalf("decoding attachment: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	if log, _ := io.ReadAll(zr); string(log) != details.String() {
+		t.Errorf("attached log has %d bytes, want the %d bytes of details", len(log), details.Len())
+	}
+}
+
+func TestEmailWithoutDetailsHasNoAttachment(t *testing.T) {
+	email, err := buildEmail(&Config{Name: "docs"}, "success", "", RenderedNotification{Subject: "Backup SUCCESS: docs", Text: "ok", HTML: "<p>ok</p>"})
+	if err != nil {
+		t.Fatalf("buildEmail() error = %v", err)
+	}
+	email.From = &mail.Address{Address: "qh@example.org"}
+	email.To = []*mail.Address{{Address: "admin@example.org"}}
+	data, err := email.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(msg.Header.Get("Content-Type")); mediaType != "multipart/alternative" {
+		t.Errorf("Content-Type = %s, want multipart/alternative", mediaType)
+	}
+}
+
+func TestAddressEmailRouting(t *testing.T) {
+	list := func(addrs ...string) config.AddressList { return addrs }
+	global := config.EmailConfig{
+		From:       "qh@example.org",
+		Recipients: config.Recipients{To: list("admin@example.org")},
+		OnFailure:  config.Recipients{To: list("oncall@example.org")},
+		OnSuccess:  config.Recipients{To: list("archive@example.org")},
+	}
+	tests := []struct {
+		name   string
+		backup EmailConfig
+		status string
+		want   string
+	}{
+		{"global failure route", EmailConfig{}, "failure", "oncall@example.org"},
+		{"global success route", EmailConfig{}, "success", "archive@example.org"},
+		{"global default", EmailConfig{}, "test", "admin@example.org"},
+		{"backup default replaces global", EmailConfig{Recipients: config.Recipients{To: list("team@example.org")}}, "failure", "team@example.org"},
+		{"backup route", EmailConfig{Recipients: config.Recipients{To: list("team@example.org")}, OnFailure: config.Recipients{BCC: list("pager@example.org")}}, "failure", "pager@example.org"},
+		{"backup route for other status", EmailConfig{OnFailure: config.Recipients{To: list("pager@example.org")}}, "success", "archive@example.org"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var email emailMessage
+			if err := addressEmail(&email, global, tt.backup, tt.status); err != nil {
+				t.Fatalf("addressEmail() error = %v", err)
+			}
+			if got := strings.Join(email.recipients(), " "); got != tt.want {
+				t.Errorf("recipients = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	var email emailMessage
+	if err := addressEmail(&email, config.EmailConfig{From: "qh@example.org"}, EmailConfig{}, "failure"); err == nil {
+		t.Error("addressEmail() without recipients error = nil, want an error")
+	}
+	bad := EmailConfig{Recipients: config.Recipients{To: list("not an address")}}
+	if err := addressEmail(&email, global, bad, "failure"); err == nil {
+		t.Error("addressEmail() with an invalid address error = nil, want an error")
+	}
+}
+
+// testCertificate returns a certificate for 127.0.0.1 and a file holding it
+// in PEM form to trust it with.
+func testCertificate(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	srv := httptest.NewTLSServer(nil)
+	cert := srv.TLS.Certificates[0]
+	srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	if err := os.WriteFile(caFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return cert, caFile
+}
+
+func TestSendEmailTLSAndAuth(t *testing.T) {
+	cert, caFile := testCertificate(t)
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		mode     string
+		auth     string
+		wantAuth string // the AUTH command the client sends
+	}{
+		{"implicit with plain", config.TLSImplicit, "", "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00qh\x00s3cret"))},
+		{"starttls with login", config.TLSStartTLS, config.AuthLogin, "AUTH LOGIN"},
+		{"starttls with cram-md5", config.TLSStartTLS, config.AuthCRAMMD5, "AUTH CRAM-MD5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, session := smtpTLSStub(t, tt.mode, &tls.Config{Certificates: []tls.Certificate{cert}})
+			emailConf := config.EmailConfig{
+				Host:         host,
+				Port:         port,
+				TLS:          tt.mode,
+				TLSCAFile:    caFile,
+				Auth:         tt.auth,
+				Username:     "qh",
+				PasswordFile: passwordFile,
+			}
+			email := emailMessage{
+				From: &mail.Address{Address: "qh@example.org"},
+				To:   []*mail.Address{{Address: "admin@example.org"}},
+				Text: "hello",
+			}
+			if err := sendEmail(emailConf, email); err != nil {
+				t.Fatalf("sendEmail() error = %v", err)
+			}
+			s := <-session
+			if !s.TLS {
+				t.Error("message was sent without TLS")
+			}
+			if !slices.Contains(s.Commands, tt.wantAuth) {
+				t.Errorf("commands = %q, want %q", s.Commands, tt.wantAuth)
+			}
+			if tt.auth == config.AuthLogin && !slices.Contains(s.Commands, base64.StdEncoding.EncodeToString([]byte("s3cret"))) {
+				t.Errorf("commands = %q, want the password in the LOGIN exchange", s.Commands)
+			}
+		})
+	}
+}
+
+func TestSendEmailTLSVerification(t *testing.T) {
+	cert, _ := testCertificate(t)
+	email := emailMessage{
+		From: &mail.Address{Address: "qh@example.org"},
+		To:   []*mail.Address{{Address: "admin@example.org"}},
+	}
+
+	host, port, _ := smtpTLSStub(t, config.TLSImplicit, &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err := sendEmail(config.EmailConfig{Host: host, Port: port, TLS: config.TLSImplicit}, email); err == nil {
+		t.Error("sendEmail() to an untrusted server error = nil, want an error")
+	}
+
+	host, port, session := smtpTLSStub(t, config.TLSImplicit, &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err := sendEmail(config.EmailConfig{Host: host, Port: port, TLS: config.TLSImplicit, TLSInsecureSkipVerify: true}, email); err != nil {
+		t.Fatalf("sendEmail() with tls_insecure_skip_verify error = %v", err)
+	}
+	<-session
+
+	host, port, _ = smtpStub(t)
+	if err := sendEmail(config.EmailConfig{Host: host, Port: port, TLS: config.TLSStartTLS}, email); err == nil {
+		t.Error("sendEmail() to a server without STARTTLS error = nil, want an error")
+	}
+}
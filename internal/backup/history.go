@@ -0,0 +1,357 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryKind is the operation a history entry records.
+type HistoryKind string
+
+const (
+	HistoryRun     HistoryKind = "run"
+	HistoryVerify  HistoryKind = "verify"
+	HistoryCleanup HistoryKind = "cleanup"
+	HistoryRestore HistoryKind = "restore"
+	HistoryPrune   HistoryKind = "prune"
+)
+
+// MaxHistoryEntries is how many entries are kept per backup; older ones are
+// dropped when new ones are recorded.
+const MaxHistoryEntries = 500
+
+// maxHistoryOutput is how much of a command's output is kept per entry. The
+// end of the output is kept, since that is where tools print their summary
+// and errors.
+const maxHistoryOutput = 4096
+
+// HistoryEntry is one recorded run, verify, cleanup or restore of a backup.
+type HistoryEntry struct {
+	Kind             HistoryKind `json:"kind" yaml:"kind"`
+	Start            time.Time   `json:"start" yaml:"start"`
+	End              time.Time   `json:"end" yaml:"end"`
+	DurationSeconds  float64     `json:"duration_seconds" yaml:"duration_seconds"`
+	Success          bool        `json:"success" yaml:"success"`
+	ExitCode         int         `json:"exit_code" yaml:"exit_code"`
+	BytesTransferred int64       `json:"bytes_transferred,omitempty" yaml:"bytes_transferred,omitempty"`
+	FilesChanged     int64       `json:"files_changed,omitempty" yaml:"files_changed,omitempty"`
+	Output           string      `json:"output,omitempty" yaml:"output,omitempty"` // tail of the output
+	Error            string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Duration returns how long the operation took.
+func (e HistoryEntry) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+// NewHistoryEntry builds an entry for an operation that ran from start to end
+// and failed with err, if non-nil.
+func NewHistoryEntry(kind HistoryKind, start, end time.Time, output string, err error) HistoryEntry {
+	entry := HistoryEntry{
+		Kind:            kind,
+		Start:           start,
+		End:             end,
+		DurationSeconds: end.Sub(start).Seconds(),
+		Success:         err == nil,
+		ExitCode:        exitCode(err),
+		Output:          truncateOutput(output),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// RunEntry builds the history entry for a backup run, including the transfer
+// statistics the tool printed.
+func RunEntry(config *Config, result *RunResult) HistoryEntry {
+	entry := NewHistoryEntry(HistoryRun, result.StartTime, result.EndTime, result.Output, result.Error)
+	entry.BytesTransferred, entry.FilesChanged = ParseTransferStats(config.Type, result.Output)
+	return entry
+}
+
+// exitCode returns the exit status of the command behind err: 0 for success,
+// the process exit code if a tool failed, and 1 otherwise.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+// truncateOutput keeps the last maxHistoryOutput bytes of output, cut at a
+// line boundary.
+func truncateOutput(output string) string {
+	output = strings.TrimRight(output, "\n")
+	if len(output) <= maxHistoryOutput {
+		return output
+	}
+	tail := output[len(output)-maxHistoryOutput:]
+	if idx := strings.IndexByte(tail, '\n'); idx >= 0 {
+		tail = tail[idx+1:]
+	}
+	return "...\n" + tail
+}
+
+var (
+	rsyncFilesRe  = regexp.MustCompile(`(?m)^Number of regular files transferred: ([\d,]+)`)
+	rsyncBytesRe  = regexp.MustCompile(`(?m)^Total transferred file size: ([\d,.]+ ?[A-Za-z]*)`)
+	resticFilesRe = regexp.MustCompile(`(?m)^Files:\s+(\d+) new,\s+(\d+) changed`)
+	resticBytesRe = regexp.MustCompile(`(?m)^Added to the repository: ([\d.]+ ?[A-Za-z]+)`)
+	rcloneStatsRe = regexp.MustCompile(`(?m)^Transferred:\s+([\d.]+ ?[A-Za-z]*) / [^,\n]+, \d+%`)
+)
+
+// ParseTransferStats extracts the bytes transferred and files changed from a
+// backup tool's output. Values the tool did not print are 0. rclone repeats
+// its cumulative stats periodically, so the last block is used.
+func ParseTransferStats(backupType BackupType, output string) (bytes int64, files int64) {
+	switch backupType {
+	case BackupTypeRsync:
+		if m := rsyncFilesRe.FindStringSubmatch(output); m != nil {
+			files, _ = strconv.ParseInt(strings.ReplaceAll(m[1], ",", ""), 10, 64)
+		}
+		if m := rsyncBytesRe.FindStringSubmatch(output); m != nil {
+			bytes, _ = ParseSize(m[1])
+		}
+	case BackupTypeRestic:
+		if m := resticFilesRe.FindStringSubmatch(output); m != nil {
+			added, _ := strconv.ParseInt(m[1], 10, 64)
+			changed, _ := strconv.ParseInt(m[2], 10, 64)
+			files = added + changed
+		}
+		if m := resticBytesRe.FindStringSubmatch(output); m != nil {
+			bytes, _ = ParseSize(m[1])
+		}
+	case BackupTypeRclone:
+		// The byte and file counts are both on "Transferred:" lines; the byte
+		// line has a unit.
+		for _, m := range rcloneStatsRe.FindAllStringSubmatch(output, -1) {
+			if strings.IndexFunc(m[1], isLetter) >= 0 {
+				bytes, _ = ParseSize(m[1])
+			} else {
+				files, _ = strconv.ParseInt(m[1], 10, 64)
+			}
+		}
+	}
+	return bytes, files
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+var sizeUnits = map[string]float64{
+	"": 1, "b": 1, "bytes": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10, "kbytes": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20, "mbytes": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30, "gbytes": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40, "tbytes": 1 << 40,
+}
+
+// ParseSize parses a size as printed by rsync, restic or rclone, such as
+// "1,234 bytes", "1.5 MiB" or "12.3M". Units are binary.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	idx := strings.IndexFunc(s, isLetter)
+	number, unit := s, ""
+	if idx >= 0 {
+		number, unit = strings.TrimSpace(s[:idx]), strings.ToLower(s[idx:])
+	}
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit in %q", s)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	return int64(value * multiplier), nil
+}
+
+// FormatBytes renders a byte count with a binary unit, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTP"[exp])
+}
+
+// GetStateDir returns the directory qh keeps backup state such as run history
+// in.
+func GetStateDir() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error finding home directory: %w", err)
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "quadlet-helper", "backups"), nil
+}
+
+// GetHistoryPath returns the path of a backup's history file, which holds one
+// JSON entry per line.
+func GetHistoryPath(name string) (string, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, name+".history.jsonl"), nil
+}
+
+// LoadHistory returns a backup's recorded entries, oldest first. A backup
+// without history has no entries. Lines that cannot be parsed, such as one
+// cut short by a crash, are skipped.
+func LoadHistory(name string) ([]HistoryEntry, error) {
+	path, err := GetHistoryPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// RecordHistory appends an entry to a backup's history, keeping at most
+// MaxHistoryEntries entries. Concurrent runs, verifies and cleanups of the
+// same backup take turns.
+func RecordHistory(name string, entry HistoryEntry) error {
+	path, err := GetHistoryPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating state directory: %w", err)
+	}
+	return withFileLock(path, func() error {
+		entries, err := LoadHistory(name)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		if len(entries) > MaxHistoryEntries {
+			entries = entries[len(entries)-MaxHistoryEntries:]
+		}
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return fmt.Errorf("error encoding history: %w", err)
+			}
+		}
+		if err := writeFileAtomic(path, buf.Bytes(), 0600); err != nil {
+			return fmt.Errorf("error writing history: %w", err)
+		}
+		return nil
+	})
+}
+
+// HistoryTrendWindow is how many recent runs the averages in a HistorySummary
+// cover; the previous averages cover the same number of runs before those.
+const HistoryTrendWindow = 10
+
+// HistorySummary describes the backup runs (not verifies or cleanups) in a
+// history.
+type HistorySummary struct {
+	Runs                 int        `json:"runs" yaml:"runs"`
+	Successes            int        `json:"successes" yaml:"successes"`
+	Failures             int        `json:"failures" yaml:"failures"`
+	LastSuccess          *time.Time `json:"last_success,omitempty" yaml:"last_success,omitempty"`
+	LastFailure          *time.Time `json:"last_failure,omitempty" yaml:"last_failure,omitempty"`
+	Streak               int        `json:"streak" yaml:"streak"`                                 // consecutive runs with the latest run's outcome
+	StreakSuccess        bool       `json:"streak_success" yaml:"streak_success"`                 // whether that outcome is success
+	LongestFailureStreak int        `json:"longest_failure_streak" yaml:"longest_failure_streak"` // most consecutive failed runs
+	AvgDurationSeconds   float64    `json:"avg_duration_seconds" yaml:"avg_duration_seconds"`
+	PrevDurationSeconds  float64    `json:"prev_avg_duration_seconds,omitempty" yaml:"prev_avg_duration_seconds,omitempty"`
+	AvgBytes             int64      `json:"avg_bytes" yaml:"avg_bytes"`
+	PrevAvgBytes         int64      `json:"prev_avg_bytes,omitempty" yaml:"prev_avg_bytes,omitempty"`
+}
+
+// Summarize computes success counts, streaks and trends over the runs in
+// entries, which must be oldest first.
+func Summarize(entries []HistoryEntry) HistorySummary {
+	var s HistorySummary
+	var runs []HistoryEntry
+	failureStreak := 0
+	for _, e := range entries {
+		if e.Kind != HistoryRun {
+			continue
+		}
+		runs = append(runs, e)
+		start := e.Start
+		if e.Success {
+			s.Successes++
+			s.LastSuccess = &start
+			failureStreak = 0
+		} else {
+			s.Failures++
+			s.LastFailure = &start
+			failureStreak++
+			s.LongestFailureStreak = max(s.LongestFailureStreak, failureStreak)
+		}
+		if s.Streak > 0 && e.Success == s.StreakSuccess {
+			s.Streak++
+		} else {
+			s.Streak, s.StreakSuccess = 1, e.Success
+		}
+	}
+	s.Runs = len(runs)
+
+	recent := runs[max(0, len(runs)-HistoryTrendWindow):]
+	previous := runs[max(0, len(runs)-2*HistoryTrendWindow) : len(runs)-len(recent)]
+	s.AvgDurationSeconds, s.AvgBytes = averages(recent)
+	s.PrevDurationSeconds, s.PrevAvgBytes = averages(previous)
+	return s
+}
+
+// averages returns the mean duration and bytes transferred of runs.
+func averages(runs []HistoryEntry) (float64, int64) {
+	if len(runs) == 0 {
+		return 0, 0
+	}
+	var seconds float64
+	var bytes int64
+	for _, e := range runs {
+		seconds += e.DurationSeconds
+		bytes += e.BytesTransferred
+	}
+	return seconds / float64(len(runs)), bytes / int64(len(runs))
+}
@@ -0,0 +1,154 @@
+package backup
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTransferStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		backupType BackupType
+		output     string
+		wantBytes  int64
+		wantFiles  int64
+	}{
+		{
+			name:       "rsync",
+			backupType: BackupTypeRsync,
+			output: "Number of files: 1,234 (reg: 1,000, dir: 234)\n" +
+				"Number of regular files transferred: 1,012\n" +
+				"Total file size: 99,999,999 bytes\n" +
+				"Total transferred file size: 1,048,576 bytes\n",
+			wantBytes: 1 << 20,
+			wantFiles: 1012,
+		},
+		{
+			name:       "restic",
+			backupType: BackupTypeRestic,
+			output: "Files:          10 new,     2 changed,   100 unmodified\n" +
+				"Dirs:            1 new,     3 changed,    20 unmodified\n" +
+				"Added to the repository: 1.500 MiB (600.000 KiB stored)\n",
+			wantBytes: 3 << 19,
+			wantFiles: 12,
+		},
+		{
+			name:       "rclone uses the last stats block",
+			backupType: BackupTypeRclone,
+			output: "Transferred:   \t    1 KiB / 2 KiB, 50%, 0 B/s, ETA -\n" +
+				"Transferred:            1 / 2, 50%\n" +
+				"Transferred:   \t    2 KiB / 2 KiB, 100%, 0 B/s, ETA -\n" +
+				"Transferred:            2 / 2, 100%\n",
+			wantBytes: 2048,
+			wantFiles: 2,
+		},
+		{
+			name:       "no stats",
+			backupType: BackupTypeRclone,
+			output:     "nothing to do\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bytes, files := ParseTransferStats(tt.backupType, tt.output)
+			if bytes != tt.wantBytes || files != tt.wantFiles {
+				t.Errorf("ParseTransferStats() = %d, %d, want %d, %d", bytes, files, tt.wantBytes, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestRecordAndSummarizeHistory(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if entries, err := LoadHistory("docs"); err != nil || len(entries) != 0 {
+		t.Fatalf("LoadHistory() of a new backup = %v, %v, want no entries", entries, err)
+	}
+
+	start := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+	outcomes := []bool{true, false, false, true, true, true}
+	for i, ok := range outcomes {
+		var err error
+		if !ok {
+			err = errors.New("rsync failed")
+		}
+		begin := start.Add(time.Duration(i) * time.Hour)
+		entry := NewHistoryEntry(HistoryRun, begin, begin.Add(time.Duration(i+1)*time.Second), "out", err)
+		entry.BytesTransferred = int64(100 * (i + 1))
+		if err := RecordHistory("docs", entry); err != nil {
+			t.Fatalf("RecordHistory() error = %v", err)
+		}
+	}
+	if err := RecordHistory("docs", NewHistoryEntry(HistoryVerify, start, start, "", errors.New("bad"))); err != nil {
+		t.Fatalf("RecordHistory() error = %v", err)
+	}
+
+	entries, err := LoadHistory("docs")
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	if len(entries) != len(outcomes)+1 {
+		t.Fatalf("LoadHistory() returned %d entries, want %d", len(entries), len(outcomes)+1)
+	}
+	if entries[1].ExitCode != 1 || entries[1].Error != "rsync failed" {
+		t.Errorf("failed entry = %+v, want exit code 1 and the error", entries[1])
+	}
+
+	s := Summarize(entries)
+	if s.Runs != 6 || s.Successes != 4 || s.Failures != 2 {
+		t.Errorf("Summarize() counts = %d/%d/%d, want 6/4/2", s.Runs, s.Successes, s.Failures)
+	}
+	if s.Streak != 3 || !s.StreakSuccess || s.LongestFailureStreak != 2 {
+		t.Errorf("Summarize() streaks = %d %v %d, want 3 true 2", s.Streak, s.StreakSuccess, s.LongestFailureStreak)
+	}
+	if want := start.Add(5 * time.Hour); s.LastSuccess == nil || !s.LastSuccess.Equal(want) {
+		t.Errorf("Summarize() LastSuccess = %v, want %v", s.LastSuccess, want)
+	}
+	if s.AvgDurationSeconds != 3.5 || s.AvgBytes != 350 {
+		t.Errorf("Summarize() averages = %v, %d, want 3.5, 350", s.AvgDurationSeconds, s.AvgBytes)
+	}
+}
+
+func TestRecordHistoryConcurrently(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	const writers = 20
+	start := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := range writers {
+		wg.Go(func() {
+			begin := start.Add(time.Duration(i) * time.Minute)
+			errs <- RecordHistory("docs", NewHistoryEntry(HistoryRun, begin, begin.Add(time.Second), "", nil))
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("RecordHistory() error = %v", err)
+		}
+	}
+
+	entries, err := LoadHistory("docs")
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	if len(entries) != writers {
+		t.Errorf("LoadHistory() = %d entries, want %d", len(entries), writers)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	long := strings.Repeat("line of output\n", 1000) + "final summary"
+	got := truncateOutput(long)
+	if len(got) > maxHistoryOutput+4 || !strings.HasPrefix(got, "...\nline") || !strings.HasSuffix(got, "final summary") {
+		t.Errorf("truncateOutput() kept %d bytes: %q...", len(got), got[:20])
+	}
+	if got := truncateOutput("short\n"); got != "short" {
+		t.Errorf("truncateOutput(short) = %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// errLocked is returned by tryFileLock when another process holds the lock.
+var errLocked = errors.New("locked by another process")
+
+// withFileLock runs fn while holding an exclusive lock on path+".lock", so
+// that processes updating the same state file take turns. The lock is on a
+// file of its own because path itself is replaced on every write.
+func withFileLock(path string, fn func() error) error {
+	unlock, err := lockFile(path, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
+// tryFileLock takes an exclusive lock on path+".lock" without waiting, and
+// returns errLocked if another process holds it.
+func tryFileLock(path string) (unlock func(), err error) {
+	return lockFile(path, syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+func lockFile(path string, how int) (func(), error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file: %w", err)
+	}
+	if err := syscall.Flock(int(lock.Fd()), how); err != nil {
+		lock.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errLocked
+		}
+		return nil, fmt.Errorf("error locking %s: %w", path, err)
+	}
+	// Closing the file releases the lock.
+	return func() { lock.Close() }, nil
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -1,139 +1,237 @@
 package backup
 
 import (
-	"crypto/tls"
+	"cmp"
+	"errors"
 	"fmt"
-	"html"
-	"net/smtp"
+	"net/mail"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/mufeedali/quadlet-helper/internal/config"
+	"github.com/mufeedali/quadlet-helper/internal/notify"
 )
 
-// SendNotification sends an email notification about backup status
-func SendNotification(backupConfig *Config, status string, details string) error {
-	if !backupConfig.Notifications.Enabled {
+// SendNotification notifies about a backup's status by email, if an SMTP host
+// is configured, and through the global and per-backup notification targets.
+// Email follows the backup's on_success and on_failure; targets may override
+// them. Aggregated notifications skip repeated failures and report a recovery
+// instead of the success that ends them. Every channel is tried, and their
+// errors are returned together. result may be nil if the run is not known.
+func SendNotification(backupConfig *Config, status string, details string, result *RunResult) error {
+	notifications := backupConfig.Notifications
+	if !notifications.Enabled {
 		return nil
 	}
-
-	// Check if we should send based on status
-	if status == "failure" && !backupConfig.Notifications.OnFailure {
-		return nil
+	if !notifications.Aggregate {
+		_, err := deliverNotification(backupConfig, status, details, result, notifyState{})
+		return err
+	}
+
+	// The state is only updated as far as the notification got out. Without
+	// it, aggregation falls back to sending the status as is.
+	var sendErr error
+	attempted := false
+	now := time.Now()
+	err := updateNotifyState(backupConfig.Name, func(state *notifyState) {
+		attempted = true
+		send := state.record(status, now, notifications.RepeatAfter)
+		if send == "" {
+			return
+		}
+		var delivered bool
+		delivered, sendErr = deliverNotification(backupConfig, send, details, result, *state)
+		if delivered {
+			state.sent(send, now)
+		}
+	})
+	if !attempted {
+		_, sendErr = deliverNotification(backupConfig, status, details, result, notifyState{})
 	}
-	if status == "success" && !backupConfig.Notifications.OnSuccess {
-		return nil
+	return errors.Join(err, sendErr)
+}
+
+// deliverNotification sends a notification through every channel that wants
+// its status, and reports whether any of them delivered it, or none wanted
+// it. failing gives the failures a failure or recovery concerns.
+func deliverNotification(backupConfig *Config, status string, details string, result *RunResult, failing notifyState) (bool, error) {
+	notifications := backupConfig.Notifications
+
+	// A broken global targets list must not keep email or the backup's own
+	// targets from being tried.
+	var errs []error
+	targets, err := config.LoadNotifyTargets()
+	if err != nil {
+		errs = append(errs, err)
 	}
+	targets = append(targets, notifications.Targets...)
 
 	emailConf := config.LoadEmailConfig()
-	backupEmailConf := backupConfig.Notifications.Email
+	if emailConf.Host == "" && len(targets) == 0 {
+		errs = append(errs, fmt.Errorf("no SMTP host or notification targets configured"))
+		return false, errors.Join(errs...)
+	}
+
+	// A broken template must not keep a failure from being reported, so the
+	// defaults stand in for it.
+	data := newNotificationData(backupConfig, status, details, result)
+	data.FailingSince, data.Failures = failing.FailingSince, failing.Failures
+	rendered, err := renderUserNotification(data)
+	if err != nil {
+		errs = append(errs, err)
+		rendered, _ = renderNotification("", data)
+	}
 
-	// Read SMTP password if file is provided
-	var password string
-	if emailConf.PasswordFile != "" {
-		data, err := os.ReadFile(emailConf.PasswordFile)
+	tried, delivered := false, false
+	if emailConf.Host != "" && notifications.wants(status) {
+		tried = true
+		if err := sendEmailNotification(backupConfig, emailConf, status, details, rendered); err != nil {
+			errs = append(errs, err)
+		} else {
+			delivered = true
+		}
+	}
+
+	msg := notify.Message{
+		Backup: backupConfig.Name,
+		Status: status,
+		Title:  rendered.Subject,
+		Body:   formatTextBody(backupConfig, details),
+	}
+	for _, target := range targets {
+		if !target.Wants(status, notifications.OnSuccess, notifications.OnFailure) {
+			continue
+		}
+		tried = true
+		notifier, err := notify.New(target)
+		if err == nil {
+			err = notifier.Send(msg)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to read SMTP password file: %w", err)
+			errs = append(errs, fmt.Errorf("%s notification failed: %w", target.Kind(), err))
+		} else {
+			delivered = true
 		}
-		password = strings.TrimSpace(string(data))
 	}
+	return delivered || !tried, errors.Join(errs...)
+}
 
-	// Prepare email subject and body
-	subject := fmt.Sprintf("Backup %s: %s", strings.ToUpper(status), backupConfig.Name)
-	body, err := formatEmailBody(backupConfig, status, details)
-	if err != nil {
-		return fmt.Errorf("failed to format email body: %w", err)
+// wants reports whether email is sent for the given status. Recoveries are
+// sent wherever failures are.
+func (n Notifications) wants(status string) bool {
+	switch status {
+	case notify.StatusSuccess:
+		return n.OnSuccess
+	case notify.StatusFailure, notify.StatusRecovery:
+		return n.OnFailure
 	}
+	return true
+}
+
+// sendEmailNotification emails the backup's status report.
+func sendEmailNotification(backupConfig *Config, emailConf config.EmailConfig, status string, details string, rendered RenderedNotification) error {
+	backupEmailConf := backupConfig.Notifications.Email
 
-	// Determine From address
-	from := emailConf.From
-	if backupEmailConf.From != "" {
-		from = backupEmailConf.From
+	email, err := buildEmail(backupConfig, status, details, rendered)
+	if err != nil {
+		return err
+	}
+	if err := addressEmail(&email, emailConf, backupEmailConf, status); err != nil {
+		return err
 	}
+
+	// Send email
+	return sendEmail(emailConf, email)
+}
+
+// addressEmail sets the sender of the email and its recipients for the
+// status. The backup's own settings replace the global ones, and recipients
+// routed to the status replace the default ones.
+func addressEmail(email *emailMessage, emailConf config.EmailConfig, backupEmailConf EmailConfig, status string) error {
+	from := cmp.Or(backupEmailConf.From, emailConf.From)
 	if from == "" {
 		return fmt.Errorf("no sender email address configured")
 	}
-
-	// Determine To address
-	to := emailConf.To
-	if backupEmailConf.To != "" {
-		to = backupEmailConf.To
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("invalid sender email address %q: %w", from, err)
+	}
+	email.From = sender
+
+	var recipients config.Recipients
+	for _, r := range []config.Recipients{
+		statusRecipients(status, backupEmailConf.OnFailure, backupEmailConf.OnSuccess),
+		backupEmailConf.Recipients,
+		statusRecipients(status, emailConf.OnFailure, emailConf.OnSuccess),
+		emailConf.Recipients,
+	} {
+		if !r.IsEmpty() {
+			recipients = r
+			break
+		}
 	}
-	if to == "" {
+	if recipients.IsEmpty() {
 		return fmt.Errorf("no recipient email address configured")
 	}
-
-	// Send email
-	return sendEmail(emailConf, password, from, to, subject, body)
+	if email.To, err = recipients.To.Parse(); err != nil {
+		return err
+	}
+	if email.CC, err = recipients.CC.Parse(); err != nil {
+		return err
+	}
+	email.BCC, err = recipients.BCC.Parse()
+	return err
 }
 
-// formatEmailBody creates the email body
-func formatEmailBody(backupConfig *Config, status string, details string) (string, error) {
-	// If this is an rclone backup, limit details to the last 50 lines to avoid huge emails
-	if backupConfig.Type == BackupTypeRclone && details != "" {
-		details = tailLines(details, 50)
+// statusRecipients returns the recipients routed to a status, if any.
+// Recoveries go to those that were told about the failure.
+func statusRecipients(status string, onFailure, onSuccess config.Recipients) config.Recipients {
+	switch status {
+	case notify.StatusFailure, notify.StatusRecovery:
+		return onFailure
+	case notify.StatusSuccess:
+		return onSuccess
 	}
+	return config.Recipients{}
+}
 
-	e := html.EscapeString
-	statusUpper := strings.ToUpper(status)
-	timestamp := time.Now().Format(time.RFC1123Z)
+// emailDetailLines is how many lines of the details the email body shows.
+// The full details are attached.
+const emailDetailLines = 50
 
-	var sourcesHTML strings.Builder
-	if len(backupConfig.Source) > 1 {
-		sourcesHTML.WriteString("<ul>")
-		for _, s := range backupConfig.Source {
-			sourcesHTML.WriteString("<li>")
-			sourcesHTML.WriteString(e(s))
-			sourcesHTML.WriteString("</li>")
+// buildEmail creates the status report email from its rendered templates,
+// without its addresses. The details are attached in full as a gzipped log.
+func buildEmail(backupConfig *Config, status string, details string, rendered RenderedNotification) (emailMessage, error) {
+	email := emailMessage{
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	}
+	if details != "" {
+		log, err := gzipAttachment(fmt.Sprintf("%s-%s.log", backupConfig.Name, status), []byte(details))
+		if err != nil {
+			return emailMessage{}, fmt.Errorf("failed to attach log: %w", err)
 		}
-		sourcesHTML.WriteString("</ul>")
-	} else if len(backupConfig.Source) == 1 {
-		sourcesHTML.WriteString(e(backupConfig.Source[0]))
+		email.Attachments = append(email.Attachments, log)
 	}
+	return email, nil
+}
 
-	var detailsHTML string
+// formatTextBody creates the plain text report sent to notification targets.
+// Chat services show messages inline, so details are cut to their last 50
+// lines.
+func formatTextBody(backupConfig *Config, details string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Type: %s\n", backupConfig.Type)
+	fmt.Fprintf(&b, "Destination: %s\n", backupConfig.GetDestination())
+	fmt.Fprintf(&b, "Time: %s\n", time.Now().Format(time.RFC1123Z))
 	if details != "" {
-		detailsHTML = `<h3>Details:</h3><pre class="details">` + e(details) + `</pre>`
+		b.WriteString("\n")
+		b.WriteString(tailLines(details, 50))
 	}
-
-	body := `<!DOCTYPE html>
-<html>
-<head>
-<style>
-body { font-family: sans-serif; }
-.container { padding: 20px; border: 1px solid #ddd; border-radius: 5px; max-width: 600px; margin: auto; }
-.status { font-size: 20px; font-weight: bold; }
-.status.success { color: green; }
-.status.failure { color: red; }
-.status.test { color: blue; }
-.details { background-color: #f5f5f5; padding: 15px; border-radius: 3px; white-space: pre-wrap; font-family: monospace; }
-table { border-collapse: collapse; width: 100%; margin-bottom: 20px; border: 1px solid #ddd; }
-th, td { text-align: left; padding: 8px; border: 1px solid #ddd; }
-th { background-color: #f2f2f2; }
-ul { margin: 0; padding-left: 20px; }
-</style>
-</head>
-<body>
-<div class="container">
-<h2>Backup Report</h2>
-<p><span class="status ` + e(status) + `">` + e(statusUpper) + `</span></p>
-<table>
-<tr><th>Backup Name</th><td>` + e(backupConfig.Name) + `</td></tr>
-<tr><th>Backup Type</th><td>` + e(string(backupConfig.Type)) + `</td></tr>
-<tr><th>Timestamp</th><td>` + e(timestamp) + `</td></tr>
-<tr><th>Sources</th><td>` + sourcesHTML.String() + `</td></tr>
-<tr><th>Destination</th><td>` + e(backupConfig.GetDestination()) + `</td></tr>
-<tr><th>Schedule</th><td>` + e(backupConfig.Schedule) + `</td></tr>
-</table>
-` + detailsHTML + `
-<p><small>This is an automated message from quadlet-helper.</small></p>
-</div>
-</body>
-</html>`
-
-	return body, nil
+	return strings.TrimRight(b.String(), "\n")
 }
 
 // tailLines returns the last n lines from s. If s has fewer than n lines, s is returned unchanged.
@@ -152,173 +250,57 @@ func tailLines(s string, n int) string {
 	return strings.Join(lines[len(lines)-n:], "\n")
 }
 
-// extractEmailAddress extracts the email address from a string that may contain a display name
-// e.g., "Display Name" <email@example.com> -> email@example.com
-func extractEmailAddress(addr string) string {
-	addr = strings.TrimSpace(addr)
-	// Check if the address contains angle brackets
-	if strings.Contains(addr, "<") && strings.Contains(addr, ">") {
-		start := strings.Index(addr, "<")
-		end := strings.Index(addr, ">")
-		if start < end {
-			return strings.TrimSpace(addr[start+1 : end])
-		}
-	}
-	return addr
-}
-
-// sendEmail sends an email using SMTP
-func sendEmail(emailConf config.EmailConfig, password, from, to, subject, body string) error {
-	fromAddr := extractEmailAddress(from)
-	toAddr := extractEmailAddress(to)
-	if fromAddr == "" {
-		return fmt.Errorf("no sender email address configured")
-	}
-	if toAddr == "" {
-		return fmt.Errorf("no recipient email address configured")
-	}
-
-	toList := []string{toAddr}
-
-	// Prepare message with HTML content type
-	msg := fmt.Appendf(nil, "From: %s\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"+
-		"%s\r\n", from, to, subject, body)
-
-	addr := fmt.Sprintf("%s:%d", emailConf.Host, emailConf.Port)
-
-	// Setup authentication
-	var auth smtp.Auth
-	if emailConf.Username != "" {
-		auth = smtp.PlainAuth("", emailConf.Username, password, emailConf.Host)
-	}
-
-	// Send email
-	if emailConf.TLS {
-		return sendEmailTLS(addr, auth, fromAddr, toList, msg, emailConf.Host)
-	}
-
-	return smtp.SendMail(addr, auth, fromAddr, toList, msg)
-}
-
-// sendEmailTLS sends email using TLS.
-func sendEmailTLS(addr string, auth smtp.Auth, from string, to []string, msg []byte, serverName string) error {
-	tlsConfig := &tls.Config{
-		ServerName: serverName,
-	}
-
-	var client *smtp.Client
-	var err error
-	var directTLSErr error
-
-	conn, err := tls.Dial("tcp", addr, tlsConfig)
-	if err == nil {
-		client, err = smtp.NewClient(conn, serverName)
-		if err != nil {
-			_ = conn.Close()
-			directTLSErr = fmt.Errorf("failed to create SMTP client: %w", err)
-		}
-	} else {
-		directTLSErr = err
-	}
-
-	if client == nil {
-		client, err = smtp.Dial(addr)
-		if err != nil {
-			if directTLSErr != nil {
-				return fmt.Errorf("failed to connect to SMTP server using direct TLS or STARTTLS: %w", directTLSErr)
-			}
-			return fmt.Errorf("failed to connect to SMTP server: %w", err)
-		}
-
-		if ok, _ := client.Extension("STARTTLS"); !ok {
-			_ = client.Close()
-			if directTLSErr != nil {
-				return fmt.Errorf("SMTP server does not support STARTTLS and direct TLS failed: %w", directTLSErr)
-			}
-			return fmt.Errorf("SMTP server does not support STARTTLS")
-		}
-
-		if err = client.StartTLS(tlsConfig); err != nil {
-			_ = client.Close()
-			return fmt.Errorf("failed to start TLS: %w", err)
-		}
-	}
-	defer func() { _ = client.Close() }()
-
-	// Authenticate
-	if auth != nil {
-		if err = client.Auth(auth); err != nil {
-			return fmt.Errorf("failed to authenticate: %w", err)
-		}
-	}
-
-	// Set sender
-	if err = client.Mail(from); err != nil {
-		return fmt.Errorf("failed to set sender: %w", err)
-	}
-
-	// Set recipients
-	for _, addr := range to {
-		if err = client.Rcpt(addr); err != nil {
-			return fmt.Errorf("failed to set recipient: %w", err)
-		}
-	}
-
-	// Send message
-	w, err := client.Data()
+// SendTestEmail sends a test email to verify configuration
+func SendTestEmail(backupConfig *Config) error {
+	details := "This is a test email to verify your notification settings."
+	rendered, err := RenderNotification(backupConfig, "test", details, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get data writer: %w", err)
+		return err
 	}
-
-	_, err = w.Write(msg)
+	email, err := buildEmail(backupConfig, "test", details, rendered)
 	if err != nil {
-		return fmt.Errorf("failed to write message: %w", err)
+		return err
 	}
+	email.Subject = fmt.Sprintf("Test Email from quadlet-helper: %s", backupConfig.Name)
 
-	err = w.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close data writer: %w", err)
+	emailConf := config.LoadEmailConfig()
+	backupEmailConf := backupConfig.Notifications.Email
+
+	if err := addressEmail(&email, emailConf, backupEmailConf, "test"); err != nil {
+		return err
 	}
 
-	return client.Quit()
+	return sendEmail(emailConf, email)
 }
 
-// SendTestEmail sends a test email to verify configuration
-func SendTestEmail(backupConfig *Config) error {
-	subject := fmt.Sprintf("Test Email from quadlet-helper: %s", backupConfig.Name)
-	body, err := formatEmailBody(backupConfig, "test", "This is a test email to verify your notification settings.")
-	if err != nil {
-		return fmt.Errorf("failed to format test email body: %w", err)
+// MonitorResult reads how the service that started an OnFailure= or
+// OnSuccess= unit ended from the MONITOR_* variables systemd sets for it. It
+// returns the notification status and a line describing the result, and
+// false if the variables are not set.
+func MonitorResult(getenv func(string) string) (status, summary string, ok bool) {
+	result := getenv("MONITOR_SERVICE_RESULT")
+	if result == "" {
+		return "", "", false
 	}
-
-	emailConf := config.LoadEmailConfig()
-	backupEmailConf := backupConfig.Notifications.Email
-
-	// Read SMTP password
-	var password string
-	if emailConf.PasswordFile != "" {
-		data, err := os.ReadFile(emailConf.PasswordFile)
-		if err != nil {
-			return fmt.Errorf("failed to read SMTP password file: %w", err)
-		}
-		password = strings.TrimSpace(string(data))
+	status = notify.StatusFailure
+	if result == "success" {
+		status = notify.StatusSuccess
 	}
-
-	from := emailConf.From
-	if backupEmailConf.From != "" {
-		from = backupEmailConf.From
+	summary = "Service result: " + result
+	if code := getenv("MONITOR_EXIT_CODE"); code != "" {
+		summary += fmt.Sprintf(" (%s %s)", code, getenv("MONITOR_EXIT_STATUS"))
 	}
-
-	return sendEmail(emailConf, password, from, backupEmailConf.To, subject, body)
+	return status, summary, true
 }
 
-// GetLastBackupLog retrieves the last backup log from systemd journal
+// GetLastBackupLog retrieves the last backup log from systemd journal. When
+// run by a notify unit, only the run being reported on is included.
 func GetLastBackupLog(backupName string) (string, error) {
-	serviceName := fmt.Sprintf("%s-backup.service", backupName)
-	cmd := exec.Command("journalctl", "--user", "-u", serviceName, "-n", "100", "--no-pager")
+	args := []string{"--user", "-u", BackupServiceName(backupName), "-n", "100", "--no-pager"}
+	if id := os.Getenv("MONITOR_INVOCATION_ID"); id != "" {
+		args = append(args, "_SYSTEMD_INVOCATION_ID="+id)
+	}
+	cmd := exec.Command("journalctl", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return string(output), fmt.Errorf("failed to get logs: %w", err)
@@ -0,0 +1,216 @@
+package backup
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mufeedali/quadlet-helper/internal/notify"
+	"github.com/spf13/viper"
+)
+
+func TestSendNotificationFiltersTargets(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "Destination: /backup") {
+			t.Errorf("%s got body %q", r.URL.Path, body)
+		}
+		paths = append(paths, r.URL.Path)
+	}))
+	defer srv.Close()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("notifications.targets", []map[string]any{
+		{"type": "ntfy", "url": srv.URL + "/global", "on_success": true},
+	})
+
+	yes := true
+	config := &Config{
+		Name:        "demo",
+		Type:        BackupTypeRsync,
+		Destination: Destination{Path: "/backup"},
+		Notifications: Notifications{
+			Enabled:   true,
+			OnFailure: true,
+			Targets: []notify.Target{
+				{Type: notify.TypeNtfy, URL: srv.URL + "/failures"},
+				{Type: notify.TypeNtfy, URL: srv.URL + "/everything", OnSuccess: &yes},
+			},
+		},
+	}
+
+	if err := SendNotification(config, "success", "all good", nil); err != nil {
+		t.Fatalf("SendNotification(success) error = %v", err)
+	}
+	if want := "/global /everything"; strings.Join(paths, " ") != want {
+		t.Errorf("success went to %v, want %s", paths, want)
+	}
+
+	paths = nil
+	if err := SendNotification(config, "failure", "rsync failed", nil); err != nil {
+		t.Fatalf("SendNotification(failure) error = %v", err)
+	}
+	if want := "/global /failures /everything"; strings.Join(paths, " ") != want {
+		t.Errorf("failure went to %v, want %s", paths, want)
+	}
+}
+
+func TestSendNotificationWithBrokenGlobalTargets(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer srv.Close()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("notifications.targets", "not a list")
+
+	config := &Config{
+		Name: "demo",
+		Notifications: Notifications{
+			Enabled:   true,
+			OnFailure: true,
+			Targets:   []notify.Target{{Type: notify.TypeNtfy, URL: srv.URL + "/backup"}},
+		},
+	}
+	if err := SendNotification(config, "failure", "rsync failed", nil); err == nil {
+		t.Error("SendNotification() error = nil, want the notifications.targets error")
+	}
+	if want := "/backup"; strings.Join(paths, " ") != want {
+		t.Errorf("failure went to %v, want %s", paths, want)
+	}
+}
+
+func TestSendNotificationWithBrokenTemplate(t *testing.T) {
+	var titles []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		titles = append(titles, r.Header.Get("Title"))
+	}))
+	defer srv.Close()
+
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	templates := filepath.Join(configHome, "quadlet-helper", "templates")
+	if err := os.MkdirAll(templates, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templates, SubjectTemplateFile), []byte("{{.Missing}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	config := &Config{
+		Name: "demo",
+		Notifications: Notifications{
+			Enabled:   true,
+			OnFailure: true,
+			Targets:   []notify.Target{{Type: notify.TypeNtfy, URL: srv.URL}},
+		},
+	}
+	if err := SendNotification(config, "failure", "rsync failed", nil); err == nil {
+		t.Error("SendNotification() error = nil, want the template error")
+	}
+	if len(titles) != 1 || titles[0] != "Backup FAILURE: demo" {
+		t.Errorf("titles = %q, want the default subject", titles)
+	}
+}
+
+func TestSendNotificationAggregates(t *testing.T) {
+	var titles []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		titles = append(titles, r.Header.Get("Title"))
+	}))
+	defer srv.Close()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	config := &Config{
+		Name: "demo",
+		Notifications: Notifications{
+			Enabled:   true,
+			OnFailure: true,
+			Aggregate: true,
+			Targets:   []notify.Target{{Type: notify.TypeNtfy, URL: srv.URL}},
+		},
+	}
+	for _, status := range []string{"failure", "failure", "success", "success"} {
+		if err := SendNotification(config, status, "", nil); err != nil {
+			t.Fatalf("SendNotification(%s) error = %v", status, err)
+		}
+	}
+	want := []string{"Backup FAILURE: demo", "Backup RECOVERED: demo"}
+	if strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+}
+
+func TestSendNotificationRetriesUndeliveredFailure(t *testing.T) {
+	var titles []string
+	down := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		titles = append(titles, r.Header.Get("Title"))
+	}))
+	defer srv.Close()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	config := &Config{
+		Name: "demo",
+		Notifications: Notifications{
+			Enabled:   true,
+			OnFailure: true,
+			Aggregate: true,
+			Targets:   []notify.Target{{Type: notify.TypeNtfy, URL: srv.URL}},
+		},
+	}
+	if err := SendNotification(config, "failure", "", nil); err == nil {
+		t.Fatal("SendNotification() with the target down error = nil, want an error")
+	}
+	down = false
+	for _, status := range []string{"failure", "failure"} {
+		if err := SendNotification(config, status, "", nil); err != nil {
+			t.Fatalf("SendNotification(%s) error = %v", status, err)
+		}
+	}
+	if want := []string{"Backup FAILURE: demo"}; strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+}
+
+func TestMonitorResult(t *testing.T) {
+	env := map[string]string{
+		"MONITOR_SERVICE_RESULT": "oom-kill",
+		"MONITOR_EXIT_CODE":      "killed",
+		"MONITOR_EXIT_STATUS":    "KILL",
+	}
+	status, summary, ok := MonitorResult(func(key string) string { return env[key] })
+	if !ok || status != "failure" || summary != "Service result: oom-kill (killed KILL)" {
+		t.Errorf("MonitorResult() = %q, %q, %v", status, summary, ok)
+	}
+
+	env = map[string]string{"MONITOR_SERVICE_RESULT": "success"}
+	if status, _, _ := MonitorResult(func(key string) string { return env[key] }); status != "success" {
+		t.Errorf("MonitorResult() status = %q, want success", status)
+	}
+	if _, _, ok := MonitorResult(func(string) string { return "" }); ok {
+		t.Error("MonitorResult() without variables reported ok")
+	}
+}
@@ -0,0 +1,134 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mufeedali/quadlet-helper/internal/notify"
+)
+
+// notifyState is what aggregated notifications remember about a backup, so
+// that an ongoing failure can be told from a new one. Status and LastSent
+// only change once a notification has been delivered, so a failure that could
+// not be sent is tried again on the next run.
+type notifyState struct {
+	Status       string    `json:"status"` // of the last run notified about
+	FailingSince time.Time `json:"failing_since,omitzero"`
+	Failures     int       `json:"failures,omitempty"` // consecutive failed runs
+	LastSent     time.Time `json:"last_sent,omitzero"` // when the failure was last sent
+}
+
+// record counts a run's status in the state and returns the status to
+// notify about: the status itself, a recovery for the first success after
+// reported failures, or "" for a failure that was already sent less than
+// repeatAfter ago. An empty repeatAfter never repeats a failure. Once the
+// notification is delivered, sent must be called with the returned status.
+func (s *notifyState) record(status string, now time.Time, repeatAfter string) string {
+	switch status {
+	case notify.StatusFailure:
+		if s.Failures == 0 {
+			s.FailingSince = now
+		}
+		s.Failures++
+		if s.Status != notify.StatusFailure {
+			return status
+		}
+		if repeatAfter != "" {
+			if d, err := ParseDuration(repeatAfter); err == nil && !now.Before(s.LastSent.Add(d)) {
+				return status
+			}
+		}
+		return ""
+	case notify.StatusSuccess:
+		if s.Status == notify.StatusFailure {
+			return notify.StatusRecovery
+		}
+		s.FailingSince, s.Failures = time.Time{}, 0
+	}
+	return status
+}
+
+// sent records that a notification returned by record was delivered.
+func (s *notifyState) sent(status string, now time.Time) {
+	switch status {
+	case notify.StatusFailure:
+		s.Status, s.LastSent = status, now
+	case notify.StatusSuccess, notify.StatusRecovery:
+		*s = notifyState{Status: notify.StatusSuccess}
+	}
+}
+
+var durationRe = regexp.MustCompile(`^(\d+(min|h|d))+$`)
+var durationPartRe = regexp.MustCompile(`(\d+)(min|h|d)`)
+
+// ParseDuration parses a duration such as notifications.repeat_after, made of
+// minutes (min), hours (h) and days (d), such as 30min, 12h or 1d12h. A bare
+// m is refused, as retention durations read it as months.
+func ParseDuration(s string) (time.Duration, error) {
+	if !durationRe.MatchString(s) {
+		return 0, fmt.Errorf("%q is not a duration such as 30min, 12h or 2d", s)
+	}
+	units := map[string]time.Duration{"min": time.Minute, "h": time.Hour, "d": 24 * time.Hour}
+	var d time.Duration
+	for _, m := range durationPartRe.FindAllStringSubmatch(s, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("%q: %w", s, err)
+		}
+		d += time.Duration(n) * units[m[2]]
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%q must be longer than zero", s)
+	}
+	return d, nil
+}
+
+// getNotifyStatePath returns the path of a backup's notification state.
+func getNotifyStatePath(name string) (string, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, name+".notify.json"), nil
+}
+
+// updateNotifyState runs fn on the backup's notification state and writes
+// the result back. The state is locked meanwhile, so that a notify unit and a
+// manual run of the same backup take turns.
+func updateNotifyState(name string, fn func(state *notifyState)) error {
+	path, err := getNotifyStatePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating state directory: %w", err)
+	}
+	return withFileLock(path, func() error {
+		var state notifyState
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error reading notification state: %w", err)
+		}
+		if err == nil {
+			// A damaged state file starts over rather than silencing failures.
+			_ = json.Unmarshal(data, &state)
+		}
+
+		fn(&state)
+
+		data, err = json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("error encoding notification state: %w", err)
+		}
+		if err := writeFileAtomic(path, data, 0600); err != nil {
+			return fmt.Errorf("error writing notification state: %w", err)
+		}
+		return nil
+	})
+}
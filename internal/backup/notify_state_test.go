@@ -0,0 +1,115 @@
+package backup
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNotifyStateRecord(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		repeatAfter string
+		statuses    []string
+		want        []string
+	}{
+		{"first failure is sent", "", []string{"failure"}, []string{"failure"}},
+		{"repeated failures are not", "", []string{"failure", "failure", "failure"}, []string{"failure", "", ""}},
+		{"recovery ends failures", "", []string{"failure", "failure", "success", "success"}, []string{"failure", "", "recovery", "success"}},
+		{"failure after recovery is sent", "", []string{"failure", "success", "failure"}, []string{"failure", "recovery", "failure"}},
+		{"repeat after backoff", "2h", []string{"failure", "failure", "failure", "failure"}, []string{"failure", "", "failure", ""}},
+		{"repeat after minutes", "30min", []string{"failure", "failure", "failure"}, []string{"failure", "failure", "failure"}},
+		{"test leaves state alone", "", []string{"failure", "test", "failure"}, []string{"failure", "test", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state notifyState
+			for i, status := range tt.statuses {
+				now := start.Add(time.Duration(i) * time.Hour)
+				got := state.record(status, now, tt.repeatAfter)
+				if got != tt.want[i] {
+					t.Errorf("record(%s) #%d = %q, want %q", status, i, got, tt.want[i])
+				}
+				if got != "" {
+					state.sent(got, now)
+				}
+			}
+		})
+	}
+
+	// Nothing is marked as sent, as if every channel were down.
+	var state notifyState
+	for i := range 3 {
+		if got := state.record("failure", start.Add(time.Duration(i)*time.Hour), ""); got != "failure" {
+			t.Errorf("record(failure) #%d undelivered = %q, want failure", i, got)
+		}
+	}
+	if !state.FailingSince.Equal(start) || state.Failures != 3 {
+		t.Errorf("state = %+v, want failing since %v with 3 failures", state, start)
+	}
+	if got := state.record("success", start.Add(3*time.Hour), ""); got != "success" {
+		t.Errorf("record(success) after unreported failures = %q, want success", got)
+	}
+	if state.Failures != 0 {
+		t.Errorf("Failures = %d after success, want 0", state.Failures)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30min", want: 30 * time.Minute},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "2d", want: 48 * time.Hour},
+		{in: "1d12h", want: 36 * time.Hour},
+		{in: "30m", wantErr: true},
+		{in: "0h", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "2 days", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateNotifyStateConcurrently(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	const runs = 20
+	now := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	errs := make(chan error, runs)
+	for range runs {
+		wg.Go(func() {
+			errs <- updateNotifyState("demo", func(state *notifyState) {
+				state.record("failure", now, "")
+			})
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("updateNotifyState() error = %v", err)
+		}
+	}
+
+	var state notifyState
+	if err := updateNotifyState("demo", func(s *notifyState) { state = *s }); err != nil {
+		t.Fatalf("updateNotifyState() error = %v", err)
+	}
+	if state.Failures != runs {
+		t.Errorf("Failures = %d, want %d", state.Failures, runs)
+	}
+}
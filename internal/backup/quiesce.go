@@ -0,0 +1,162 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
+)
+
+// QuiescePlan is a quiesce section resolved against the quadlet files on
+// disk: the services to stop and the podman containers to pause.
+type QuiescePlan struct {
+	Services   []string
+	Containers []string
+}
+
+// IsEmpty reports whether the plan has nothing to stop or pause.
+func (p *QuiescePlan) IsEmpty() bool {
+	return p == nil || (len(p.Services) == 0 && len(p.Containers) == 0)
+}
+
+// ResolveQuiesce resolves the names in a quiesce section against units.
+// Units to stop may be given by file name ("db.container") or base name
+// ("db"), which only matches .container and .pod files. Containers to pause
+// may name a .container file, which pauses the container it runs, or any
+// podman container by its own name.
+func ResolveQuiesce(q Quiesce, units []*quadlet.UnitFile) (*QuiescePlan, error) {
+	plan := &QuiescePlan{}
+	for _, name := range q.Stop {
+		u, err := findQuiesceUnit(units, name, "container", "pod")
+		if err != nil {
+			return nil, err
+		}
+		if u == nil {
+			return nil, fmt.Errorf("quiesce.stop: quadlet unit %q not found", name)
+		}
+		if !slices.Contains(plan.Services, u.ServiceName()) {
+			plan.Services = append(plan.Services, u.ServiceName())
+		}
+	}
+	for _, name := range q.Pause {
+		u, err := findQuiesceUnit(units, name, "container")
+		if err != nil {
+			return nil, err
+		}
+		container := name
+		if u != nil {
+			if u.UnitType() != "container" {
+				return nil, fmt.Errorf("quiesce.pause: %s is not a container", u.Name())
+			}
+			container = u.ContainerName()
+		}
+		if !slices.Contains(plan.Containers, container) {
+			plan.Containers = append(plan.Containers, container)
+		}
+	}
+	return plan, nil
+}
+
+// findQuiesceUnit finds the quadlet file a quiesce entry names, or returns
+// nil if there is none. Base names only match files of the given types.
+func findQuiesceUnit(units []*quadlet.UnitFile, name string, types ...string) (*quadlet.UnitFile, error) {
+	var matches []*quadlet.UnitFile
+	for _, u := range units {
+		if u.Name() == name {
+			return u, nil
+		}
+		if u.BaseName() == name && slices.Contains(types, u.UnitType()) {
+			matches = append(matches, u)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, u := range matches {
+			names[i] = u.Name()
+		}
+		return nil, fmt.Errorf("quadlet unit %q is ambiguous (%s)", name, strings.Join(names, ", "))
+	}
+}
+
+// podman runs podman and returns its combined output. Tests replace it.
+var podman = func(args ...string) (string, error) {
+	output, err := podmanCommand(args...).CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
+
+// Apply stops the plan's services and pauses its containers. Only those that
+// are running are touched, so resume leaves stopped units stopped. The
+// returned resume function unpauses and restarts them again and must be
+// called however the backup ends; if Apply itself fails, it has already
+// resumed whatever it had quiesced.
+func (p *QuiescePlan) Apply(m systemd.Manager) (resume func() error, err error) {
+	var stopped, paused []string
+	resume = func() error {
+		var errs []error
+		for _, container := range slices.Backward(paused) {
+			fmt.Printf("Unpausing container %s\n", container)
+			if output, err := podman("unpause", container); err != nil {
+				errs = append(errs, fmt.Errorf("unpausing %s: %w: %s", container, err, output))
+			}
+		}
+		if len(stopped) > 0 {
+			fmt.Printf("Starting %s\n", strings.Join(stopped, " "))
+			if output, err := m.StartMultiple(stopped); err != nil {
+				errs = append(errs, fmt.Errorf("starting %s: %w: %s", strings.Join(stopped, " "), err, strings.TrimSpace(output)))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	fail := func(err error) (func() error, error) {
+		if resumeErr := resume(); resumeErr != nil {
+			err = errors.Join(err, resumeErr)
+		}
+		return nil, err
+	}
+
+	if len(p.Services) > 0 {
+		active, err := m.IsActiveMultiple(p.Services)
+		if err != nil {
+			return nil, fmt.Errorf("checking units to stop: %w", err)
+		}
+		var running []string
+		for i, service := range p.Services {
+			if active[i] {
+				running = append(running, service)
+			}
+		}
+		if len(running) > 0 {
+			fmt.Printf("Stopping %s\n", strings.Join(running, " "))
+			// Whatever was asked to stop is started again, even if stopping
+			// failed halfway through.
+			stopped = running
+			if output, err := m.StopMultiple(running); err != nil {
+				return fail(fmt.Errorf("stopping %s: %w: %s", strings.Join(running, " "), err, strings.TrimSpace(output)))
+			}
+		}
+	}
+
+	for _, container := range p.Containers {
+		state, err := podman("inspect", "--type", "container", "--format", "{{.State.Status}}", container)
+		if err != nil {
+			return fail(fmt.Errorf("inspecting container %s: %w: %s", container, err, state))
+		}
+		if state != "running" {
+			continue
+		}
+		fmt.Printf("Pausing container %s\n", container)
+		if output, err := podman("pause", container); err != nil {
+			return fail(fmt.Errorf("pausing %s: %w: %s", container, err, output))
+		}
+		paused = append(paused, container)
+	}
+	return resume, nil
+}
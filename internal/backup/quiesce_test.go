@@ -0,0 +1,144 @@
+package backup
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
+)
+
+func testUnits(t *testing.T, files map[string]string) []*quadlet.UnitFile {
+	t.Helper()
+	var units []*quadlet.UnitFile
+	for name, content := range files {
+		file, err := quadlet.ParseBytes([]byte(content))
+		if err != nil {
+			t.Fatalf("ParseBytes(%s) error = %v", name, err)
+		}
+		units = append(units, &quadlet.UnitFile{Path: "/containers/" + name, File: file})
+	}
+	return units
+}
+
+func TestResolveQuiesce(t *testing.T) {
+	units := testUnits(t, map[string]string{
+		"db.container":    "[Container]\nImage=postgres\n",
+		"db.volume":       "[Volume]\n",
+		"cache.container": "[Container]\nImage=redis\nContainerName=redis\n",
+		"app.pod":         "[Pod]\n",
+	})
+
+	plan, err := ResolveQuiesce(Quiesce{
+		Stop:  []string{"db", "app", "db.container"},
+		Pause: []string{"cache", "standalone"},
+	}, units)
+	if err != nil {
+		t.Fatalf("ResolveQuiesce() error = %v", err)
+	}
+	if want := []string{"db.service", "app-pod.service"}; !slices.Equal(plan.Services, want) {
+		t.Errorf("Services = %v, want %v", plan.Services, want)
+	}
+	if want := []string{"redis", "standalone"}; !slices.Equal(plan.Containers, want) {
+		t.Errorf("Containers = %v, want %v", plan.Containers, want)
+	}
+
+	for _, q := range []Quiesce{
+		{Stop: []string{"missing"}},
+		{Pause: []string{"app.pod"}},
+	} {
+		if _, err := ResolveQuiesce(q, units); err == nil {
+			t.Errorf("ResolveQuiesce(%+v) error = nil, want an error", q)
+		}
+	}
+}
+
+// fakePodman replaces podman for the rest of the test. Containers in running
+// are reported as running; every call is recorded.
+func fakePodman(t *testing.T, running ...string) *[]string {
+	t.Helper()
+	var calls []string
+	old := podman
+	podman = func(args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		switch args[0] {
+		case "inspect":
+			if slices.Contains(running, args[len(args)-1]) {
+				return "running", nil
+			}
+			return "exited", nil
+		case "pause", "unpause":
+			return "", nil
+		}
+		return "", errors.New("unexpected podman call")
+	}
+	t.Cleanup(func() { podman = old })
+	return &calls
+}
+
+func TestQuiescePlanApply(t *testing.T) {
+	calls := fakePodman(t, "redis")
+	fake := systemd.NewFake(t.TempDir())
+	fake.Active["db.service"] = true
+
+	plan := &QuiescePlan{Services: []string{"db.service", "idle.service"}, Containers: []string{"redis", "stopped"}}
+	resume, err := plan.Apply(fake)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if fake.Active["db.service"] {
+		t.Error("db.service is still active after Apply()")
+	}
+	if want := []string{"inspect --type container --format {{.State.Status}} redis", "pause redis", "inspect --type container --format {{.State.Status}} stopped"}; !slices.Equal(*calls, want) {
+		t.Errorf("podman calls = %v, want %v", *calls, want)
+	}
+
+	if err := resume(); err != nil {
+		t.Fatalf("resume() error = %v", err)
+	}
+	if !fake.Active["db.service"] {
+		t.Error("db.service was not started again")
+	}
+	if fake.Active["idle.service"] {
+		t.Error("idle.service was started although it was not running before")
+	}
+	if got := (*calls)[len(*calls)-1]; got != "unpause redis" {
+		t.Errorf("last podman call = %q, want unpause redis", got)
+	}
+}
+
+func TestQuiescePlanApplyFailureResumes(t *testing.T) {
+	fake := systemd.NewFake(t.TempDir())
+	fake.Active["db.service"] = true
+	old := podman
+	podman = func(args ...string) (string, error) { return "no such container", errors.New("exit status 125") }
+	t.Cleanup(func() { podman = old })
+
+	plan := &QuiescePlan{Services: []string{"db.service"}, Containers: []string{"missing"}}
+	if _, err := plan.Apply(fake); err == nil {
+		t.Fatal("Apply() error = nil, want inspect failure")
+	}
+	if !fake.Active["db.service"] {
+		t.Error("db.service was not started again after Apply() failed")
+	}
+}
+
+func TestRunQuiescedResumesAfterFailedBackup(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no backup tools, so the backup itself fails
+	fake := systemd.NewFake(t.TempDir())
+	fake.Active["db.service"] = true
+
+	config := &Config{Type: BackupTypeRsync, Source: []string{"/srv/db"}, Destination: Destination{Path: "/backup"}}
+	_, err := runQuiesced(config, RunOptions{Quiesce: &QuiescePlan{Services: []string{"db.service"}}, Manager: fake})
+	if err == nil {
+		t.Fatal("runQuiesced() error = nil, want backup failure")
+	}
+	if want := []string{"stop db.service", "start db.service"}; !slices.Equal(fake.Calls, want) {
+		t.Errorf("systemd calls = %v, want %v", fake.Calls, want)
+	}
+	if !fake.Active["db.service"] {
+		t.Error("db.service was not started again")
+	}
+}
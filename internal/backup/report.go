@@ -0,0 +1,269 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// NotificationData is what notification templates are executed with.
+type NotificationData struct {
+	Name        string
+	Type        BackupType
+	Status      string // success, failure, recovery or test
+	Success     bool   // for success and recovery
+	Hostname    string
+	Sources     []string
+	Destination string
+	Schedule    string
+	Timestamp   time.Time
+
+	// StartTime, EndTime and Duration are those of the run, and zero when
+	// they are not known.
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+	Error     string
+
+	// FailingSince and Failures describe the failures of aggregated
+	// notifications: those that a failure continues, or that a recovery
+	// ends.
+	FailingSince time.Time
+	Failures     int
+
+	// Output is the run's output or logs in full. LogTail is its last
+	// 50 lines, which LogHeading introduces.
+	Output     string
+	LogTail    string
+	LogHeading string
+	Truncated  bool
+}
+
+// newNotificationData collects the template data for a notification. result
+// may be nil if the run is not known.
+func newNotificationData(backupConfig *Config, status string, details string, result *RunResult) NotificationData {
+	hostname, _ := os.Hostname()
+	data := NotificationData{
+		Name:        backupConfig.Name,
+		Type:        backupConfig.Type,
+		Status:      status,
+		Success:     status == "success" || status == "recovery",
+		Hostname:    hostname,
+		Sources:     backupConfig.Source,
+		Destination: backupConfig.GetDestination(),
+		Schedule:    backupConfig.Schedule,
+		Timestamp:   time.Now(),
+		Output:      details,
+	}
+	if result != nil {
+		data.StartTime, data.EndTime = result.StartTime, result.EndTime
+		if !result.StartTime.IsZero() && !result.EndTime.IsZero() {
+			data.Duration = result.EndTime.Sub(result.StartTime).Round(time.Millisecond)
+		}
+		if result.Error != nil {
+			data.Error = result.Error.Error()
+		}
+	}
+	if details != "" {
+		data.LogTail = tailLines(details, emailDetailLines)
+		data.Truncated = data.LogTail != strings.TrimRight(strings.ReplaceAll(details, "\r\n", "\n"), "\n")
+		data.LogHeading = "Details:"
+		if data.Truncated {
+			data.LogHeading = fmt.Sprintf("Details (last %d lines, full log attached):", emailDetailLines)
+		}
+	}
+	return data
+}
+
+// Template files that override the defaults, in the templates directory.
+const (
+	SubjectTemplateFile = "subject.tmpl"
+	TextTemplateFile    = "body.txt.tmpl"
+	HTMLTemplateFile    = "body.html.tmpl"
+)
+
+const defaultSubjectTemplate = `{{if eq .Status "recovery"}}Backup RECOVERED{{else}}Backup {{upper .Status}}{{end}}: {{.Name}}`
+
+const defaultTextTemplate = `Backup Report
+
+Status: {{upper .Status}}
+Backup Name: {{.Name}}
+Backup Type: {{.Type}}
+Host: {{.Hostname}}
+Timestamp: {{.Timestamp.Format "Mon, 02 Jan 2006 15:04:05 -0700"}}
+{{- if .Duration}}
+Duration: {{.Duration}}
+{{- end}}
+{{- if not .FailingSince.IsZero}}
+Failing since: {{.FailingSince.Format "Mon, 02 Jan 2006 15:04:05 -0700"}} ({{.Failures}} failed runs)
+{{- end}}
+Sources: {{join .Sources ", "}}
+Destination: {{.Destination}}
+Schedule: {{.Schedule}}
+{{- if .Output}}
+
+{{.LogHeading}}
+{{.LogTail}}
+{{- end}}
+
+This is an automated message from quadlet-helper.
+`
+
+const defaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<style>
+body { font-family: sans-serif; }
+.container { padding: 20px; border: 1px solid #ddd; border-radius: 5px; max-width: 600px; margin: auto; }
+.status { font-size: 20px; font-weight: bold; }
+.status.success { color: green; }
+.status.failure { color: red; }
+.status.recovery { color: green; }
+.status.test { color: blue; }
+.details { background-color: #f5f5f5; padding: 15px; border-radius: 3px; white-space: pre-wrap; font-family: monospace; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 20px; border: 1px solid #ddd; }
+th, td { text-align: left; padding: 8px; border: 1px solid #ddd; }
+th { background-color: #f2f2f2; }
+ul { margin: 0; padding-left: 20px; }
+</style>
+</head>
+<body>
+<div class="container">
+<h2>Backup Report</h2>
+<p><span class="status {{.Status}}">{{upper .Status}}</span></p>
+<table>
+<tr><th>Backup Name</th><td>{{.Name}}</td></tr>
+<tr><th>Backup Type</th><td>{{.Type}}</td></tr>
+<tr><th>Host</th><td>{{.Hostname}}</td></tr>
+<tr><th>Timestamp</th><td>{{.Timestamp.Format "Mon, 02 Jan 2006 15:04:05 -0700"}}</td></tr>
+{{- if .Duration}}
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+{{- end}}
+{{- if not .FailingSince.IsZero}}
+<tr><th>Failing Since</th><td>{{.FailingSince.Format "Mon, 02 Jan 2006 15:04:05 -0700"}} ({{.Failures}} failed runs)</td></tr>
+{{- end}}
+<tr><th>Sources</th><td>{{if gt (len .Sources) 1}}<ul>{{range .Sources}}<li>{{.}}</li>{{end}}</ul>{{else}}{{join .Sources ""}}{{end}}</td></tr>
+<tr><th>Destination</th><td>{{.Destination}}</td></tr>
+<tr><th>Schedule</th><td>{{.Schedule}}</td></tr>
+</table>
+{{- if .Output}}
+<h3>{{.LogHeading}}</h3><pre class="details">{{.LogTail}}</pre>
+{{- end}}
+<p><small>This is an automated message from quadlet-helper.</small></p>
+</div>
+</body>
+</html>`
+
+// templateFuncs are the functions available to notification templates.
+var templateFuncs = map[string]any{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+	"tail":  func(n int, s string) string { return tailLines(s, n) },
+	"bytes": FormatBytes,
+}
+
+// GetTemplatesDir returns the directory notification templates are
+// overridden from.
+func GetTemplatesDir() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configDir), "templates"), nil
+}
+
+// RenderedNotification is a notification's subject and bodies.
+type RenderedNotification struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// RenderNotification renders the notification for a backup's status with the
+// user's templates, falling back to the defaults for those there are none
+// of. result may be nil if the run is not known.
+func RenderNotification(backupConfig *Config, status string, details string, result *RunResult) (RenderedNotification, error) {
+	return renderUserNotification(newNotificationData(backupConfig, status, details, result))
+}
+
+func renderUserNotification(data NotificationData) (RenderedNotification, error) {
+	dir, err := GetTemplatesDir()
+	if err != nil {
+		return RenderedNotification{}, err
+	}
+	return renderNotification(dir, data)
+}
+
+// renderNotification renders the templates in dir, or the defaults if dir is
+// empty.
+func renderNotification(dir string, data NotificationData) (RenderedNotification, error) {
+	subject, err := renderText(dir, SubjectTemplateFile, defaultSubjectTemplate, data)
+	if err != nil {
+		return RenderedNotification{}, err
+	}
+	text, err := renderText(dir, TextTemplateFile, defaultTextTemplate, data)
+	if err != nil {
+		return RenderedNotification{}, err
+	}
+	html, err := renderHTML(dir, HTMLTemplateFile, defaultHTMLTemplate, data)
+	if err != nil {
+		return RenderedNotification{}, err
+	}
+	// Headers are a single line.
+	subject = strings.Join(strings.Fields(subject), " ")
+	return RenderedNotification{Subject: subject, Text: text, HTML: html}, nil
+}
+
+func renderText(dir, name, fallback string, data any) (string, error) {
+	src, err := readTemplate(dir, name, fallback)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	tmpl, err := texttemplate.New(name).Funcs(templateFuncs).Parse(src)
+	if err == nil {
+		err = tmpl.Execute(&b, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid notification template: %w", err)
+	}
+	return b.String(), nil
+}
+
+func renderHTML(dir, name, fallback string, data any) (string, error) {
+	src, err := readTemplate(dir, name, fallback)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	tmpl, err := htmltemplate.New(name).Funcs(templateFuncs).Parse(src)
+	if err == nil {
+		err = tmpl.Execute(&b, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid notification template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// readTemplate reads a template from dir, or returns fallback if the file
+// does not exist or dir is empty.
+func readTemplate(dir, name, fallback string) (string, error) {
+	if dir == "" {
+		return fallback, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return fallback, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading template: %w", err)
+	}
+	return string(data), nil
+}
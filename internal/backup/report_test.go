@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderNotificationDefaults(t *testing.T) {
+	config := &Config{Name: "<docs>", Type: BackupTypeRsync, Schedule: "daily", Source: []string{"/srv/a", "/srv/b"}, Destination: Destination{Path: "/mnt"}}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	result := &RunResult{StartTime: start, EndTime: start.Add(90 * time.Second), Error: errors.New("exit status 23")}
+
+	rendered, err := renderNotification("", newNotificationData(config, "failure", "rsync: link_stat failed\n", result))
+	if err != nil {
+		t.Fatalf("renderNotification() error = %v", err)
+	}
+	if rendered.Subject != "Backup FAILURE: <docs>" {
+		t.Errorf("Subject = %q", rendered.Subject)
+	}
+	for _, want := range []string{"Backup Name: <docs>", "Duration: 1m30s", "Sources: /srv/a, /srv/b", "Details:\nrsync: link_stat failed\n"} {
+		if !strings.Contains(rendered.Text, want) {
+			t.Errorf("Text does not contain %q:\n%s", want, rendered.Text)
+		}
+	}
+	for _, want := range []string{"<td>&lt;docs&gt;</td>", `<span class="status failure">`, "<li>/srv/b</li>", "<td>1m30s</td>"} {
+		if !strings.Contains(rendered.HTML, want) {
+			t.Errorf("HTML does not contain %q:\n%s", want, rendered.HTML)
+		}
+	}
+
+	// Without a run, there is no duration to show.
+	rendered, err = renderNotification("", newNotificationData(config, "test", "", nil))
+	if err != nil {
+		t.Fatalf("renderNotification() error = %v", err)
+	}
+	if strings.Contains(rendered.Text, "Duration") || strings.Contains(rendered.Text, "Details") {
+		t.Errorf("Text without a run or details:\n%s", rendered.Text)
+	}
+}
+
+func TestRenderNotificationOverrides(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(SubjectTemplateFile, "[{{.Hostname}}]\n{{.Name}} {{if .Success}}ok{{else}}FAILED{{end}}\n")
+	write(HTMLTemplateFile, "<p>{{.Error}}</p>")
+
+	data := newNotificationData(&Config{Name: "docs"}, "failure", "line\n", &RunResult{Error: errors.New("<disk full>")})
+	data.Hostname = "nas"
+	rendered, err := renderNotification(dir, data)
+	if err != nil {
+		t.Fatalf("renderNotification() error = %v", err)
+	}
+	if rendered.Subject != "[nas] docs FAILED" {
+		t.Errorf("Subject = %q, want it on one line", rendered.Subject)
+	}
+	if rendered.HTML != "<p>&lt;disk full&gt;</p>" {
+		t.Errorf("HTML = %q", rendered.HTML)
+	}
+	if !strings.HasPrefix(rendered.Text, "Backup Report") {
+		t.Errorf("Text = %q, want the default", rendered.Text)
+	}
+
+	write(TextTemplateFile, "{{.Missing}}")
+	if _, err := renderNotification(dir, data); err == nil {
+		t.Error("renderNotification() with a broken template error = nil, want an error")
+	}
+}
+
+func TestNotificationDataTruncatesLog(t *testing.T) {
+	var details strings.Builder
+	for range emailDetailLines + 10 {
+		details.WriteString("line\n")
+	}
+	data := newNotificationData(&Config{Name: "docs"}, "failure", details.String(), nil)
+	if !data.Truncated || strings.Count(data.LogTail, "\n") != emailDetailLines-1 {
+		t.Errorf("Truncated = %v with %d lines in LogTail", data.Truncated, strings.Count(data.LogTail, "\n")+1)
+	}
+	if !strings.Contains(data.LogHeading, "full log attached") {
+		t.Errorf("LogHeading = %q", data.LogHeading)
+	}
+}
@@ -0,0 +1,164 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RestoreOptions controls what Restore brings back and where to.
+type RestoreOptions struct {
+	Snapshot string   // restic snapshot ID or rsync snapshot name, or "latest"
+	Target   string   // directory to restore into
+	Include  []string // paths to restore, relative to each source; everything if empty
+	DryRun   bool
+}
+
+// DefaultRestoreTarget returns a fresh staging directory for restoring a
+// backup, so that restores never overwrite live data unless asked to.
+func DefaultRestoreTarget(name string) (string, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "restore", name+"-"+time.Now().Format("20060102-150405")), nil
+}
+
+// Restore copies a backup back into opts.Target. Each source is restored into
+// a directory named after it below the target; restic keeps the full source
+// paths instead, as "restic restore" does.
+func Restore(config *Config, opts RestoreOptions) (*RunResult, error) {
+	normalized := config.Normalized()
+	config = &normalized
+
+	result := &RunResult{StartTime: time.Now()}
+	fail := func(err error) (*RunResult, error) {
+		result.Error = err
+		result.EndTime = time.Now()
+		return result, err
+	}
+
+	if opts.Target == "" {
+		return fail(fmt.Errorf("no restore target given"))
+	}
+	if err := requireTool(config); err != nil {
+		return fail(err)
+	}
+	if !opts.DryRun {
+		if err := os.MkdirAll(opts.Target, 0700); err != nil {
+			return fail(fmt.Errorf("error creating restore target: %w", err))
+		}
+	}
+
+	var output string
+	var err error
+	switch config.Type {
+	case BackupTypeRestic:
+		output, err = runCommandStreaming("restic", ResticRestoreArgs(opts), ResticEnv(config))
+		if err != nil {
+			err = fmt.Errorf("restic restore failed: %w", err)
+		}
+	case BackupTypeRsync:
+		output, err = restoreEachSource(config, opts, "rsync", RsyncRestoreArgs)
+	case BackupTypeRclone:
+		output, err = restoreEachSource(config, opts, "rclone", RcloneRestoreArgs)
+	default:
+		err = fmt.Errorf("unsupported backup type: %s", config.Type)
+	}
+
+	result.Output = output
+	result.EndTime = time.Now()
+	result.Error = err
+	result.Success = err == nil
+	return result, err
+}
+
+// restoreEachSource runs one restore command per configured source.
+func restoreEachSource(config *Config, opts RestoreOptions, tool string, argsFor func(*Config, string, RestoreOptions) []string) (string, error) {
+	var allOutput strings.Builder
+	for _, source := range config.layoutSources() {
+		output, err := runCommandStreaming(tool, argsFor(config, source, opts), BaseEnv(config))
+		allOutput.WriteString(output)
+		if err != nil {
+			return allOutput.String(), fmt.Errorf("%s restore failed for %s: %w", tool, source, err)
+		}
+	}
+	return allOutput.String(), nil
+}
+
+// restoreTargetPath returns where a source is restored to below the target.
+func restoreTargetPath(target, source string) string {
+	return filepath.Join(target, sourceBase(source))
+}
+
+// ResticRestoreArgs returns the arguments of "restic restore" for opts.
+func ResticRestoreArgs(opts RestoreOptions) []string {
+	snapshot := opts.Snapshot
+	if snapshot == "" {
+		snapshot = "latest"
+	}
+	args := []string{"restore", snapshot, "--target", opts.Target}
+	if opts.DryRun {
+		args = append(args, "--dry-run", "--verbose")
+	}
+	for _, include := range opts.Include {
+		args = append(args, "--include", include)
+	}
+	return args
+}
+
+// RsyncRestoreArgs returns the rsync arguments that copy a source back from
+// the backup destination. Nothing in the target is deleted.
+func RsyncRestoreArgs(config *Config, source string, opts RestoreOptions) []string {
+	args := []string{}
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if config.Options.Archive {
+		args = append(args, "-a")
+	} else {
+		args = append(args, "-r")
+	}
+	if config.Options.Compress {
+		args = append(args, "-z")
+	}
+	args = append(args, "-v", "--progress")
+	if len(opts.Include) > 0 {
+		// Let rsync descend into every directory, keep the included paths and
+		// everything below them, and drop directories left empty.
+		for _, include := range opts.Include {
+			include = "/" + strings.Trim(include, "/")
+			args = append(args, "--include", include, "--include", include+"/***")
+		}
+		args = append(args, "--include", "*/", "--exclude", "*", "--prune-empty-dirs")
+	}
+	backupPath := RsyncDestPath(config.RsyncSnapshotPath(opts.Snapshot), source, len(config.layoutSources()))
+	return append(args, backupPath+"/", restoreTargetPath(opts.Target, source)+"/")
+}
+
+// RcloneRestoreArgs returns the "rclone copy" arguments that copy a source
+// back from the remote.
+func RcloneRestoreArgs(config *Config, source string, opts RestoreOptions) []string {
+	args := []string{"copy"}
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if config.Options.Transfers > 0 {
+		args = append(args, "--transfers", fmt.Sprintf("%d", config.Options.Transfers))
+	}
+	if config.Options.Checkers > 0 {
+		args = append(args, "--checkers", fmt.Sprintf("%d", config.Options.Checkers))
+	}
+	if config.Options.BandwidthLimit != "" {
+		args = append(args, "--bwlimit", config.Options.BandwidthLimit)
+	}
+	for _, include := range opts.Include {
+		include = "/" + strings.Trim(include, "/")
+		args = append(args, "--include", include, "--include", include+"/**")
+	}
+	args = append(args, "-v", "--progress")
+	remotePath := RcloneDestPath(config.Destination.Remote, source, len(config.layoutSources()))
+	return append(args, remotePath, restoreTargetPath(opts.Target, source))
+}
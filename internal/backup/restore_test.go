@@ -0,0 +1,54 @@
+package backup
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResticRestoreArgs(t *testing.T) {
+	got := ResticRestoreArgs(RestoreOptions{Target: "/tmp/r", Include: []string{"/srv/data/photos"}, DryRun: true})
+	want := []string{"restore", "latest", "--target", "/tmp/r", "--dry-run", "--verbose", "--include", "/srv/data/photos"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ResticRestoreArgs() = %v, want %v", got, want)
+	}
+
+	got = ResticRestoreArgs(RestoreOptions{Snapshot: "4f2a1b", Target: "/tmp/r"})
+	want = []string{"restore", "4f2a1b", "--target", "/tmp/r"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ResticRestoreArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestRsyncRestoreArgs(t *testing.T) {
+	config := &Config{
+		Source:      []string{"/srv/data", "/srv/media"},
+		Destination: Destination{Path: "/mnt/backup"},
+		Options:     Options{Archive: true, Delete: true},
+	}
+	opts := RestoreOptions{Target: "/tmp/r", Include: []string{"photos/"}}
+
+	got := RsyncRestoreArgs(config, "/srv/media", opts)
+	want := []string{
+		"-a", "-v", "--progress",
+		"--include", "/photos", "--include", "/photos/***",
+		"--include", "*/", "--exclude", "*", "--prune-empty-dirs",
+		"/mnt/backup/media/", "/tmp/r/media/",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("RsyncRestoreArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestRcloneRestoreArgs(t *testing.T) {
+	config := &Config{
+		Source:      []string{"/srv/data"},
+		Destination: Destination{Remote: "b2:bucket/data"},
+		Options:     Options{Transfers: 4},
+	}
+
+	got := RcloneRestoreArgs(config, "/srv/data", RestoreOptions{Target: "/tmp/r", DryRun: true})
+	want := []string{"copy", "--dry-run", "--transfers", "4", "-v", "--progress", "b2:bucket/data", "/tmp/r/data"}
+	if !slices.Equal(got, want) {
+		t.Errorf("RcloneRestoreArgs() = %v, want %v", got, want)
+	}
+}
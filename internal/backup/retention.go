@@ -0,0 +1,348 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IsSet reports whether any retention rule is configured.
+func (r Retention) IsSet() bool {
+	return r.KeepLast > 0 || r.KeepHourly > 0 || r.KeepDaily > 0 || r.KeepWeekly > 0 ||
+		r.KeepMonthly > 0 || r.KeepYearly > 0 || r.Within() != "" || len(r.KeepTags) > 0
+}
+
+// HasCountRules reports whether any rule other than keep_within and keep_days
+// is configured. Those rules need snapshots to count, which rclone lacks.
+func (r Retention) HasCountRules() bool {
+	return r.KeepLast > 0 || r.KeepHourly > 0 || r.KeepDaily > 0 || r.KeepWeekly > 0 ||
+		r.KeepMonthly > 0 || r.KeepYearly > 0 || len(r.KeepTags) > 0
+}
+
+// Within returns the keep-within duration in restic's syntax, taken from
+// KeepWithin or else KeepDays, or "" if neither is set.
+func (r Retention) Within() string {
+	if r.KeepWithin != "" {
+		return r.KeepWithin
+	}
+	if r.KeepDays > 0 {
+		return fmt.Sprintf("%dd", r.KeepDays)
+	}
+	return ""
+}
+
+// validate checks the rules themselves, whatever the backup type.
+func (r Retention) validate() error {
+	counts := []struct {
+		key string
+		n   int
+	}{
+		{"keep_last", r.KeepLast},
+		{"keep_hourly", r.KeepHourly},
+		{"keep_daily", r.KeepDaily},
+		{"keep_weekly", r.KeepWeekly},
+		{"keep_monthly", r.KeepMonthly},
+		{"keep_yearly", r.KeepYearly},
+		{"keep_days", r.KeepDays},
+	}
+	for _, c := range counts {
+		if c.n < 0 {
+			return fmt.Errorf("retention.%s must not be negative", c.key)
+		}
+	}
+	if r.KeepWithin != "" && r.KeepDays > 0 {
+		return fmt.Errorf("set either retention.keep_within or retention.keep_days, not both")
+	}
+	if r.KeepWithin != "" {
+		w, err := parseWithin(r.KeepWithin)
+		if err != nil {
+			return fmt.Errorf("invalid retention.keep_within: %w", err)
+		}
+		if w.isZero() {
+			return fmt.Errorf("retention.keep_within must be longer than zero")
+		}
+	}
+	for _, tags := range r.KeepTags {
+		if strings.TrimSpace(tags) == "" {
+			return fmt.Errorf("retention.keep_tags must not contain empty entries")
+		}
+	}
+	if r.PruneSchedule != "" {
+		if _, err := ParseSchedule(r.PruneSchedule); err != nil {
+			return fmt.Errorf("invalid retention.prune_schedule: %w", err)
+		}
+	}
+	return nil
+}
+
+// within is a restic keep-within duration such as "1y6m" or "36h".
+type within struct {
+	years, months, days, hours int
+}
+
+var withinRe = regexp.MustCompile(`^(\d+[ymdh])+$`)
+var withinPartRe = regexp.MustCompile(`(\d+)([ymdh])`)
+
+// parseWithin parses a duration made of years (y), months (m), days (d) and
+// hours (h), as restic's --keep-within accepts.
+func parseWithin(s string) (within, error) {
+	var w within
+	if !withinRe.MatchString(s) {
+		return w, fmt.Errorf("%q is not a duration like 1y6m, 14d or 36h", s)
+	}
+	for _, m := range withinPartRe.FindAllStringSubmatch(s, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return w, fmt.Errorf("%q: %w", s, err)
+		}
+		switch m[2] {
+		case "y":
+			w.years += n
+		case "m":
+			w.months += n
+		case "d":
+			w.days += n
+		case "h":
+			w.hours += n
+		}
+	}
+	return w, nil
+}
+
+// isZero reports whether the duration adds up to nothing, which would keep
+// nothing at all.
+func (w within) isZero() bool {
+	return w.years == 0 && w.months == 0 && w.days == 0 && w.hours == 0
+}
+
+// before returns the time the duration lies before t.
+func (w within) before(t time.Time) time.Time {
+	return t.AddDate(-w.years, -w.months, -w.days).Add(-time.Duration(w.hours) * time.Hour)
+}
+
+// Retained decides which snapshots the retention rules keep, in the same way
+// restic's forget does: KeepLast keeps the newest snapshots, KeepDaily keeps
+// the newest snapshot of each of the last KeepDaily days that have one, and
+// likewise for hours, weeks, months and years; the within duration keeps
+// everything younger than that relative to the newest snapshot, and KeepTags
+// keeps snapshots carrying all tags of any of its comma-separated lists. The
+// newest snapshot is always kept, and so is everything if no rule is set.
+// Periods are counted in the local time zone.
+func (r Retention) Retained(snapshots []Snapshot) ([]bool, error) {
+	keep := make([]bool, len(snapshots))
+	if len(snapshots) == 0 {
+		return keep, nil
+	}
+	if !r.IsSet() {
+		for i := range keep {
+			keep[i] = true
+		}
+		return keep, nil
+	}
+
+	newestFirst := make([]int, len(snapshots))
+	for i := range newestFirst {
+		newestFirst[i] = i
+	}
+	slices.SortStableFunc(newestFirst, func(a, b int) int {
+		return snapshots[b].Time.Compare(snapshots[a].Time)
+	})
+	keep[newestFirst[0]] = true
+
+	for _, i := range newestFirst[:min(r.KeepLast, len(newestFirst))] {
+		keep[i] = true
+	}
+
+	keepPeriods := func(n int, period func(time.Time) string) {
+		last := ""
+		for _, i := range newestFirst {
+			if n <= 0 {
+				return
+			}
+			if p := period(snapshots[i].Time.Local()); p != last {
+				keep[i] = true
+				last = p
+				n--
+			}
+		}
+	}
+	keepPeriods(r.KeepHourly, func(t time.Time) string { return t.Format("2006-01-02T15") })
+	keepPeriods(r.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") })
+	keepPeriods(r.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	keepPeriods(r.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") })
+	keepPeriods(r.KeepYearly, func(t time.Time) string { return t.Format("2006") })
+
+	if duration := r.Within(); duration != "" {
+		w, err := parseWithin(duration)
+		if err != nil {
+			return nil, err
+		}
+		cutoff := w.before(snapshots[newestFirst[0]].Time)
+		for i, s := range snapshots {
+			if s.Time.After(cutoff) {
+				keep[i] = true
+			}
+		}
+	}
+
+	for _, list := range r.KeepTags {
+		for i, s := range snapshots {
+			if hasAllTags(s.Tags, strings.Split(list, ",")) {
+				keep[i] = true
+			}
+		}
+	}
+	return keep, nil
+}
+
+func hasAllTags(tags, want []string) bool {
+	for _, tag := range want {
+		if !slices.Contains(tags, strings.TrimSpace(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResticForgetArgs returns the "restic forget" arguments that apply the
+// retention rules, removing unreferenced data too if prune is set.
+func ResticForgetArgs(r Retention, prune, dryRun bool) []string {
+	args := []string{"forget"}
+	counts := []struct {
+		flag string
+		n    int
+	}{
+		{"--keep-last", r.KeepLast},
+		{"--keep-hourly", r.KeepHourly},
+		{"--keep-daily", r.KeepDaily},
+		{"--keep-weekly", r.KeepWeekly},
+		{"--keep-monthly", r.KeepMonthly},
+		{"--keep-yearly", r.KeepYearly},
+	}
+	for _, c := range counts {
+		if c.n > 0 {
+			args = append(args, c.flag, strconv.Itoa(c.n))
+		}
+	}
+	if duration := r.Within(); duration != "" {
+		args = append(args, "--keep-within", duration)
+	}
+	for _, tags := range r.KeepTags {
+		args = append(args, "--keep-tag", tags)
+	}
+	if prune {
+		args = append(args, "--prune")
+	}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+	return args
+}
+
+// CleanupPlan lists what cleanup would keep and remove, oldest first.
+type CleanupPlan struct {
+	Keep   []Snapshot `json:"keep" yaml:"keep"`
+	Remove []Snapshot `json:"remove" yaml:"remove"`
+}
+
+// PlanCleanup works out which snapshots cleanup would remove, without
+// removing anything. restic is asked through "forget --dry-run"; rsync
+// snapshot directories are matched against the rules here. rclone backups
+// have no snapshots to plan for.
+func PlanCleanup(config *Config) (*CleanupPlan, error) {
+	normalized := config.Normalized()
+	config = &normalized
+
+	if !config.IsSnapshotMode() {
+		return nil, fmt.Errorf("cleanup plans are only available for restic backups and rsync backups in snapshot mode")
+	}
+	plan := &CleanupPlan{}
+	if !config.Retention.IsSet() {
+		snapshots, err := ListSnapshots(config)
+		if err != nil {
+			return nil, err
+		}
+		plan.Keep = snapshots
+		return plan, nil
+	}
+
+	switch config.Type {
+	case BackupTypeRestic:
+		if err := requireTool(config); err != nil {
+			return nil, err
+		}
+		args := append(ResticForgetArgs(config.Retention, false, true), "--json")
+		output, err := runCommandOutput("restic", args, ResticEnv(config))
+		if err != nil {
+			return nil, err
+		}
+		if plan, err = parseResticForget(output); err != nil {
+			return nil, err
+		}
+	case BackupTypeRsync:
+		snapshots, err := rsyncSnapshots(config)
+		if err != nil {
+			return nil, err
+		}
+		keep, err := config.Retention.Retained(snapshots)
+		if err != nil {
+			return nil, err
+		}
+		latest := latestRsyncSnapshot(config.Destination.Path)
+		for i, s := range snapshots {
+			if keep[i] || s.ID == latest {
+				plan.Keep = append(plan.Keep, s)
+			} else {
+				plan.Remove = append(plan.Remove, s)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// parseResticForget parses the groups printed by "restic forget --json".
+func parseResticForget(data []byte) (*CleanupPlan, error) {
+	var groups []struct {
+		Keep   []Snapshot `json:"keep"`
+		Remove []Snapshot `json:"remove"`
+	}
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("parsing restic forget output: %w", err)
+	}
+	plan := &CleanupPlan{}
+	for _, g := range groups {
+		plan.Keep = append(plan.Keep, g.Keep...)
+		plan.Remove = append(plan.Remove, g.Remove...)
+	}
+	byTime := func(a, b Snapshot) int { return a.Time.Compare(b.Time) }
+	slices.SortStableFunc(plan.Keep, byTime)
+	slices.SortStableFunc(plan.Remove, byTime)
+	return plan, nil
+}
+
+// Prune removes data no longer referenced by any snapshot from a restic
+// repository. It is run by cleanup unless retention.prune_schedule gives it
+// a timer of its own.
+func Prune(config *Config) (string, error) {
+	normalized := config.Normalized()
+	config = &normalized
+
+	if config.Type != BackupTypeRestic {
+		return "", fmt.Errorf("prune is only supported for restic backups")
+	}
+	if err := requireTool(config); err != nil {
+		return "", err
+	}
+	output, err := runCommandStreaming("restic", []string{"prune"}, ResticEnv(config))
+	if err != nil {
+		return output, fmt.Errorf("restic prune failed: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,156 @@
+package backup
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetentionRetained(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	// Two snapshots a day for the last 60 days, newest last.
+	var snapshots []Snapshot
+	for d := 59; d >= 0; d-- {
+		day := now.AddDate(0, 0, -d)
+		snapshots = append(snapshots, Snapshot{Time: day.Add(-6 * time.Hour)}, Snapshot{Time: day.Add(-time.Hour)})
+	}
+	snapshots[0].Tags = []string{"keep", "monthly"}
+	snapshots[10].Tags = []string{"keep"}
+
+	count := func(keep []bool) int {
+		n := 0
+		for _, k := range keep {
+			if k {
+				n++
+			}
+		}
+		return n
+	}
+
+	tests := []struct {
+		name      string
+		retention Retention
+		want      int
+	}{
+		{"no rules keeps everything", Retention{}, len(snapshots)},
+		{"last", Retention{KeepLast: 3}, 3},
+		{"hourly", Retention{KeepHourly: 5}, 5},
+		{"daily", Retention{KeepDaily: 7}, 7},
+		{"days", Retention{KeepDays: 3}, 6},
+		{"within", Retention{KeepWithin: "36h"}, 4},
+		{"monthly", Retention{KeepMonthly: 12}, 3}, // January, February and March
+		{"yearly", Retention{KeepYearly: 5}, 1},
+		{"daily and weekly overlap", Retention{KeepDaily: 7, KeepWeekly: 4}, 7 + 3},
+		{"tags need every tag of a list", Retention{KeepTags: []string{"keep,monthly"}}, 2},
+		{"any tag list matches", Retention{KeepTags: []string{"monthly", "keep"}}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keep, err := tt.retention.Retained(snapshots)
+			if err != nil {
+				t.Fatalf("Retained() error = %v", err)
+			}
+			if got := count(keep); got != tt.want {
+				t.Errorf("Retained() kept %d snapshots, want %d", got, tt.want)
+			}
+			if !keep[len(keep)-1] {
+				t.Error("Retained() dropped the newest snapshot")
+			}
+		})
+	}
+}
+
+func TestParseWithin(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    within
+		wantErr bool
+	}{
+		{in: "14d", want: within{days: 14}},
+		{in: "1y6m", want: within{years: 1, months: 6}},
+		{in: "2d12h", want: within{days: 2, hours: 12}},
+		{in: "", wantErr: true},
+		{in: "2w", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "1y 2m", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseWithin(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseWithin(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseWithin(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestResticForgetArgs(t *testing.T) {
+	retention := Retention{
+		KeepLast:   2,
+		KeepDaily:  7,
+		KeepYearly: 3,
+		KeepDays:   30,
+		KeepTags:   []string{"manual", "release,stable"},
+	}
+	got := ResticForgetArgs(retention, true, false)
+	want := []string{"forget", "--keep-last", "2", "--keep-daily", "7", "--keep-yearly", "3", "--keep-within", "30d", "--keep-tag", "manual", "--keep-tag", "release,stable", "--prune"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ResticForgetArgs() = %v, want %v", got, want)
+	}
+
+	got = ResticForgetArgs(Retention{KeepWithin: "1y"}, false, true)
+	want = []string{"forget", "--keep-within", "1y", "--dry-run"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ResticForgetArgs() dry-run = %v, want %v", got, want)
+	}
+}
+
+func TestRcloneCleanupArgs(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	config := &Config{Destination: Destination{Remote: "b2:bucket"}, Retention: Retention{KeepWithin: "1m2d"}}
+	got, err := RcloneCleanupArgs(config, now)
+	if err != nil {
+		t.Fatalf("RcloneCleanupArgs() error = %v", err)
+	}
+	// 13 February to 15 March is 30 days.
+	want := []string{"delete", "b2:bucket", "--min-age", "720h"}
+	if !slices.Equal(got, want) {
+		t.Errorf("RcloneCleanupArgs() = %v, want %v", got, want)
+	}
+
+	config.Retention.KeepWithin = "0d"
+	if _, err := RcloneCleanupArgs(config, now); err == nil {
+		t.Error("RcloneCleanupArgs() with a zero duration error = nil, want an error")
+	}
+}
+
+func TestParseResticForget(t *testing.T) {
+	data := []byte(`[
+  {"tags": null, "host": "h", "paths": ["/srv"],
+   "keep": [{"id": "c", "short_id": "c", "time": "2026-03-03T00:00:00Z", "paths": ["/srv"]},
+            {"id": "b", "short_id": "b", "time": "2026-03-02T00:00:00Z", "paths": ["/srv"]}],
+   "remove": [{"id": "a", "short_id": "a", "time": "2026-03-01T00:00:00Z", "paths": ["/srv"]}]},
+  {"tags": null, "host": "h", "paths": ["/etc"],
+   "keep": [{"id": "d", "short_id": "d", "time": "2026-03-01T12:00:00Z", "paths": ["/etc"]}],
+   "remove": null}
+]`)
+	plan, err := parseResticForget(data)
+	if err != nil {
+		t.Fatalf("parseResticForget() error = %v", err)
+	}
+	ids := func(snapshots []Snapshot) []string {
+		var out []string
+		for _, s := range snapshots {
+			out = append(out, s.ID)
+		}
+		return out
+	}
+	if got, want := ids(plan.Keep), []string{"d", "b", "c"}; !slices.Equal(got, want) {
+		t.Errorf("Keep = %v, want %v", got, want)
+	}
+	if got, want := ids(plan.Remove), []string{"a"}; !slices.Equal(got, want) {
+		t.Errorf("Remove = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,185 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LatestSnapshotLink is the symlink in an rsync snapshot destination that
+// points at the newest complete snapshot.
+const LatestSnapshotLink = "latest"
+
+// rsyncSnapshotLayout names rsync snapshot directories by their UTC start
+// time, so they sort chronologically.
+const rsyncSnapshotLayout = "2006-01-02T150405Z"
+
+// incompleteSuffix marks a snapshot directory that rsync is still writing.
+const incompleteSuffix = ".incomplete"
+
+// isRemoteRsyncPath reports whether an rsync location is on another host,
+// such as "host:/srv/backup".
+func isRemoteRsyncPath(path string) bool {
+	return strings.Contains(path, ":")
+}
+
+// IsSnapshotMode reports whether the backup keeps point-in-time snapshots
+// rather than a single mirror: always for restic, and for rsync in snapshot
+// mode.
+func (c *Config) IsSnapshotMode() bool {
+	return c.Type == BackupTypeRestic || (c.Type == BackupTypeRsync && c.Options.Mode == RsyncModeSnapshot)
+}
+
+// RsyncSnapshotPath returns the directory holding an rsync backup's files: the
+// destination itself for a mirror, or the given snapshot ("latest" if empty)
+// in snapshot mode.
+func (c *Config) RsyncSnapshotPath(snapshot string) string {
+	if c.Options.Mode != RsyncModeSnapshot {
+		return c.Destination.Path
+	}
+	if snapshot == "" {
+		snapshot = LatestSnapshotLink
+	}
+	return filepath.Join(c.Destination.Path, snapshot)
+}
+
+// rsyncSnapshot is a complete snapshot directory in an rsync destination.
+type rsyncSnapshot struct {
+	Name string
+	Time time.Time
+}
+
+// listRsyncSnapshots returns the complete snapshots in dest, oldest first.
+func listRsyncSnapshots(dest string) ([]rsyncSnapshot, error) {
+	entries, err := os.ReadDir(dest)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot directory: %w", err)
+	}
+
+	var snapshots []rsyncSnapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		t, err := time.Parse(rsyncSnapshotLayout, entry.Name())
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, rsyncSnapshot{Name: entry.Name(), Time: t})
+	}
+	slices.SortFunc(snapshots, func(a, b rsyncSnapshot) int {
+		return a.Time.Compare(b.Time)
+	})
+	return snapshots, nil
+}
+
+// latestRsyncSnapshot returns the name of the snapshot the latest link points
+// to, or "" if there is none.
+func latestRsyncSnapshot(dest string) string {
+	target, err := os.Readlink(filepath.Join(dest, LatestSnapshotLink))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// runRsyncSnapshot writes a new snapshot directory, hard-linking unchanged
+// files to the latest snapshot. The snapshot is written under a temporary
+// name and only renamed and made latest once rsync succeeds.
+func runRsyncSnapshot(config *Config, dryRun bool) (string, error) {
+	dest := config.Destination.Path
+	name := time.Now().UTC().Format(rsyncSnapshotLayout)
+	dir := filepath.Join(dest, name)
+	partial := dir + incompleteSuffix
+
+	linkDest := ""
+	if latest := latestRsyncSnapshot(dest); latest != "" {
+		linkDest = filepath.Join(dest, latest)
+		if abs, err := filepath.Abs(linkDest); err == nil {
+			linkDest = abs
+		}
+	}
+
+	if !dryRun {
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return "", fmt.Errorf("error creating destination: %w", err)
+		}
+	}
+
+	output, err := runCommandStreaming("rsync", RsyncSnapshotArgs(config, dryRun, partial, linkDest), BaseEnv(config))
+	if dryRun {
+		if err != nil {
+			return output, fmt.Errorf("rsync failed: %w", err)
+		}
+		return output, nil
+	}
+	if err != nil {
+		_ = os.RemoveAll(partial)
+		return output, fmt.Errorf("rsync failed: %w", err)
+	}
+
+	if err := os.Rename(partial, dir); err != nil {
+		return output, fmt.Errorf("error completing snapshot %s: %w", name, err)
+	}
+	if err := setLatestRsyncSnapshot(dest, name); err != nil {
+		return output, err
+	}
+	return output + fmt.Sprintf("Snapshot: %s\n", dir), nil
+}
+
+// setLatestRsyncSnapshot points the latest link at name, replacing it
+// atomically.
+func setLatestRsyncSnapshot(dest, name string) error {
+	link := filepath.Join(dest, LatestSnapshotLink)
+	tmp := link + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(name, tmp); err != nil {
+		return fmt.Errorf("error updating %s link: %w", LatestSnapshotLink, err)
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("error updating %s link: %w", LatestSnapshotLink, err)
+	}
+	return nil
+}
+
+// cleanupRsyncSnapshots deletes the snapshots the retention rules do not keep.
+// The snapshot that latest points to is never deleted. It returns the names
+// of the deleted snapshots.
+func cleanupRsyncSnapshots(config *Config) ([]string, error) {
+	plan, err := PlanCleanup(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, s := range plan.Remove {
+		if err := removeSnapshotDir(filepath.Join(config.Destination.Path, s.ID)); err != nil {
+			return removed, fmt.Errorf("error removing snapshot %s: %w", s.ID, err)
+		}
+		removed = append(removed, s.ID)
+	}
+	return removed, nil
+}
+
+// removeSnapshotDir deletes a snapshot directory. rsync -a copies directory
+// permissions, so read-only directories are made writable first if needed.
+func removeSnapshotDir(dir string) error {
+	if err := os.RemoveAll(dir); err == nil {
+		return nil
+	}
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = os.Chmod(path, 0700)
+		}
+		return nil
+	})
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,121 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCleanupRsyncSnapshots(t *testing.T) {
+	dest := t.TempDir()
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	var names []string
+	for d := 4; d >= 0; d-- {
+		name := now.AddDate(0, 0, -d).Format(rsyncSnapshotLayout)
+		names = append(names, name)
+		if err := os.MkdirAll(filepath.Join(dest, name, "data"), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+	}
+	// A read-only directory inside a snapshot, as rsync -a can produce.
+	if err := os.Chmod(filepath.Join(dest, names[0], "data"), 0555); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	// Unrelated entries and unfinished runs are left alone.
+	if err := os.MkdirAll(filepath.Join(dest, "notes"), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dest, names[0]+incompleteSuffix), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	// latest points at an older snapshot, which must survive.
+	if err := setLatestRsyncSnapshot(dest, names[1]); err != nil {
+		t.Fatalf("setLatestRsyncSnapshot() error = %v", err)
+	}
+
+	config := &Config{
+		Type:        BackupTypeRsync,
+		Destination: Destination{Path: dest},
+		Options:     Options{Mode: RsyncModeSnapshot},
+		Retention:   Retention{KeepDaily: 2},
+	}
+	removed, err := cleanupRsyncSnapshots(config)
+	if err != nil {
+		t.Fatalf("cleanupRsyncSnapshots() error = %v", err)
+	}
+	if want := []string{names[0], names[2]}; !slices.Equal(removed, want) {
+		t.Errorf("cleanupRsyncSnapshots() removed %v, want %v", removed, want)
+	}
+
+	snapshots, err := ListSnapshots(config)
+	if err != nil {
+		t.Fatalf("ListSnapshots() error = %v", err)
+	}
+	var left []string
+	for _, s := range snapshots {
+		left = append(left, s.ID)
+	}
+	if want := []string{names[1], names[3], names[4]}; !slices.Equal(left, want) {
+		t.Errorf("snapshots left = %v, want %v", left, want)
+	}
+	if got := config.RsyncSnapshotPath(""); got != filepath.Join(dest, LatestSnapshotLink) {
+		t.Errorf("RsyncSnapshotPath() = %q", got)
+	}
+	for _, name := range []string{"notes", names[0] + incompleteSuffix} {
+		if _, err := os.Stat(filepath.Join(dest, name)); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+}
+
+func TestRsyncSnapshotArgs(t *testing.T) {
+	config := &Config{
+		Source:      []string{"/src"},
+		Destination: Destination{Path: "/dest"},
+		Options:     Options{Archive: true, Mode: RsyncModeSnapshot},
+	}
+	got := RsyncSnapshotArgs(config, false, "/dest/2026-01-01T000000Z.incomplete", "/dest/2025-12-31T000000Z")
+	want := []string{"-a", "-v", "--progress", "--stats", "--link-dest", "/dest/2025-12-31T000000Z", "/src", "/dest/2026-01-01T000000Z.incomplete"}
+	if !slices.Equal(got, want) {
+		t.Errorf("RsyncSnapshotArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateRsyncMode(t *testing.T) {
+	base := Config{
+		Name:        "demo",
+		Type:        BackupTypeRsync,
+		Schedule:    "daily",
+		Source:      []string{"/src"},
+		Destination: Destination{Path: "/dest"},
+	}
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"snapshot", func(c *Config) { c.Options.Mode = RsyncModeSnapshot }, false},
+		{"mirror", func(c *Config) { c.Options.Mode = RsyncModeMirror }, false},
+		{"unknown mode", func(c *Config) { c.Options.Mode = "copy" }, true},
+		{"remote snapshot", func(c *Config) {
+			c.Options.Mode = RsyncModeSnapshot
+			c.Destination.Path = "nas:/backup"
+		}, true},
+		{"mode on restic", func(c *Config) {
+			c.Type = BackupTypeRestic
+			c.Destination = Destination{Repository: "/repo"}
+			c.Options.Mode = RsyncModeSnapshot
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := base
+			tt.modify(&config)
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,14 +2,19 @@ package backup
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
 )
 
 // RunResult represents the result of a backup run
@@ -21,6 +26,17 @@ type RunResult struct {
 	Error     error
 }
 
+// RunOptions controls a backup run.
+type RunOptions struct {
+	DryRun bool
+	// Quiesce lists what to stop or pause around the backup, as resolved from
+	// the config's quiesce section by ResolveQuiesce. Dry runs leave
+	// everything running.
+	Quiesce *QuiescePlan
+	// Manager stops and starts the services in Quiesce.
+	Manager systemd.Manager
+}
+
 // CheckToolAvailable checks if a backup tool is installed and available in PATH
 func CheckToolAvailable(backupType BackupType) (bool, error) {
 	var toolName string
@@ -71,7 +87,7 @@ func GetInstallInstructions(backupType BackupType) string {
 }
 
 // Run executes a backup based on its configuration
-func Run(config *Config, dryRun bool) (*RunResult, error) {
+func Run(config *Config, opts RunOptions) (*RunResult, error) {
 	normalized := config.Normalized()
 	config = &normalized
 
@@ -102,22 +118,29 @@ func Run(config *Config, dryRun bool) (*RunResult, error) {
 		}
 	}
 
-	// Execute backup based on type
-	var output string
-	var err error
-
-	switch config.Type {
-	case BackupTypeRsync:
-		output, err = runRsyncBackup(config, dryRun)
-	case BackupTypeRestic:
-		output, err = runResticBackup(config, dryRun)
-	case BackupTypeRclone:
-		output, err = runRcloneBackup(config, dryRun)
-	default:
-		err = fmt.Errorf("unsupported backup type: %s", config.Type)
+	// Dump databases while they are still running, before anything is
+	// quiesced. The staging directory only lives as long as the run.
+	var dumpLog string
+	if config.usesStaging() {
+		cleanup, err := prepareStaging(config.Name, opts.DryRun)
+		if err != nil {
+			result.Error = err
+			result.EndTime = time.Now()
+			return result, result.Error
+		}
+		defer cleanup()
+		dumpLog, err = runDumps(config, opts.DryRun)
+		if err != nil {
+			result.Output = dumpLog
+			result.Error = fmt.Errorf("database dump failed: %w", err)
+			result.EndTime = time.Now()
+			return result, result.Error
+		}
 	}
 
-	result.Output = output
+	output, err := runQuiesced(config, opts)
+
+	result.Output = dumpLog + output
 	result.EndTime = time.Now()
 
 	if err != nil {
@@ -146,6 +169,62 @@ func Run(config *Config, dryRun bool) (*RunResult, error) {
 	return result, nil
 }
 
+// runQuiesced runs the backup itself, with the services and containers of
+// opts.Quiesce stopped and paused for its duration.
+func runQuiesced(config *Config, opts RunOptions) (string, error) {
+	if opts.DryRun || opts.Quiesce.IsEmpty() {
+		return runFromSources(config, opts.DryRun)
+	}
+
+	// Interrupting the run must not skip bringing everything back. The
+	// backup tool gets the signal too and exits, after which resume runs.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	resume, err := opts.Quiesce.Apply(opts.Manager)
+	if err != nil {
+		return "", fmt.Errorf("quiescing failed: %w", err)
+	}
+	output, err := runFromSources(config, false)
+	if resumeErr := resume(); resumeErr != nil {
+		err = errors.Join(err, fmt.Errorf("resuming after backup failed: %w", resumeErr))
+	}
+	return output, err
+}
+
+// runFromSources exports or resolves the volume sources and then runs the
+// backup tool on the resulting paths.
+func runFromSources(config *Config, dryRun bool) (string, error) {
+	exportLog, err := exportVolumes(config, dryRun)
+	if err != nil {
+		return exportLog, err
+	}
+	resolved, err := resolveVolumeSources(config)
+	if err != nil {
+		return exportLog, err
+	}
+	output, err := runBackup(resolved, dryRun)
+	return exportLog + output, err
+}
+
+// runBackup runs the backup tool for the configured backup type.
+func runBackup(config *Config, dryRun bool) (string, error) {
+	switch config.Type {
+	case BackupTypeRsync:
+		if config.Options.Mode == RsyncModeSnapshot {
+			return runRsyncSnapshot(config, dryRun)
+		}
+		return runRsyncBackup(config, dryRun)
+	case BackupTypeRestic:
+		return runResticBackup(config, dryRun)
+	case BackupTypeRclone:
+		return runRcloneBackup(config, dryRun)
+	default:
+		return "", fmt.Errorf("unsupported backup type: %s", config.Type)
+	}
+}
+
 // runRsyncBackup executes an rsync backup
 func runRsyncBackup(config *Config, dryRun bool) (string, error) {
 	output, err := runCommandStreaming("rsync", RsyncArgs(config, dryRun), BaseEnv(config))
@@ -224,32 +303,30 @@ func Cleanup(config *Config) error {
 	case BackupTypeRclone:
 		return cleanupRclone(config)
 	case BackupTypeRsync:
-		// Rsync doesn't have built-in retention, would need custom logic
-		return nil
+		// A mirror has a single copy, so only snapshots have anything to prune.
+		if config.Options.Mode != RsyncModeSnapshot {
+			return nil
+		}
+		removed, err := cleanupRsyncSnapshots(config)
+		for _, name := range removed {
+			fmt.Printf("Removed snapshot %s\n", name)
+		}
+		return err
 	default:
 		return nil
 	}
 }
 
-// cleanupRestic performs restic retention cleanup
+// cleanupRestic forgets the snapshots the retention rules do not keep. The
+// repository is pruned in the same go unless prune has a schedule of its own.
 func cleanupRestic(config *Config) error {
-	if config.Options.KeepDaily == 0 && config.Options.KeepWeekly == 0 {
+	if !config.Retention.IsSet() {
 		return nil
 	}
 
-	env := ResticEnv(config)
-
-	args := []string{"forget", "--prune"}
-
-	if config.Options.KeepDaily > 0 {
-		args = append(args, "--keep-daily", fmt.Sprintf("%d", config.Options.KeepDaily))
-	}
-	if config.Options.KeepWeekly > 0 {
-		args = append(args, "--keep-weekly", fmt.Sprintf("%d", config.Options.KeepWeekly))
-	}
-
-	cmd := exec.Command("restic", args...)
-	cmd.Env = env
+	prune := config.Retention.PruneSchedule == ""
+	cmd := exec.Command("restic", ResticForgetArgs(config.Retention, prune, false)...)
+	cmd.Env = ResticEnv(config)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -259,17 +336,15 @@ func cleanupRestic(config *Config) error {
 	return nil
 }
 
-// cleanupRclone performs rclone retention cleanup
+// cleanupRclone deletes files older than the retention's within duration.
 func cleanupRclone(config *Config) error {
-	if config.Retention.KeepDays == 0 {
+	duration := config.Retention.Within()
+	if duration == "" {
 		return nil
 	}
-
-	// Use rclone delete with --min-age to remove old files
-	args := []string{
-		"delete",
-		config.Destination.Remote,
-		"--min-age", fmt.Sprintf("%dd", config.Retention.KeepDays),
+	args, err := RcloneCleanupArgs(config, time.Now())
+	if err != nil {
+		return err
 	}
 
 	cmd := exec.Command("rclone", args...)
@@ -283,6 +358,21 @@ func cleanupRclone(config *Config) error {
 	return nil
 }
 
+// RcloneCleanupArgs returns the "rclone delete" arguments that remove files
+// older than the retention's within duration. rclone reads "m" as minutes,
+// so the duration is passed in hours.
+func RcloneCleanupArgs(config *Config, now time.Time) ([]string, error) {
+	w, err := parseWithin(config.Retention.Within())
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention: %w", err)
+	}
+	hours := int(now.Sub(w.before(now)).Hours())
+	if hours <= 0 {
+		return nil, fmt.Errorf("invalid retention: %q would delete every file on %s", config.Retention.Within(), config.Destination.Remote)
+	}
+	return []string{"delete", config.Destination.Remote, "--min-age", fmt.Sprintf("%dh", hours)}, nil
+}
+
 // syncWriter serialises writes from concurrent stdout/stderr goroutines.
 type syncWriter struct {
 	mu  sync.Mutex
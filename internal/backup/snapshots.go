@@ -0,0 +1,271 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Snapshot is a restic snapshot as reported by "restic snapshots --json", or
+// an rsync snapshot directory.
+type Snapshot struct {
+	ID       string    `json:"id" yaml:"id"`
+	ShortID  string    `json:"short_id" yaml:"short_id"`
+	Time     time.Time `json:"time" yaml:"time"`
+	Hostname string    `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	Username string    `json:"username,omitempty" yaml:"username,omitempty"`
+	Paths    []string  `json:"paths" yaml:"paths"`
+	Tags     []string  `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// FileEntry is a file or directory in a snapshot or backup destination.
+type FileEntry struct {
+	Path    string    `json:"path" yaml:"path"`
+	Type    string    `json:"type" yaml:"type"` // file, dir or symlink
+	Size    int64     `json:"size" yaml:"size"`
+	ModTime time.Time `json:"mod_time" yaml:"mod_time"`
+}
+
+// runCommandOutput runs a command and returns its standard output. Standard
+// error is included in the returned error.
+func runCommandOutput(name string, args []string, env []string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return output, fmt.Errorf("%s %s: %w\n%s", name, args[0], err, msg)
+		}
+		return output, fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return output, nil
+}
+
+// requireTool returns an error if the tool for config is not installed.
+func requireTool(config *Config) error {
+	available, err := CheckToolAvailable(config.Type)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return fmt.Errorf("%s is not installed or not in PATH\n\n%s", config.Type, GetInstallInstructions(config.Type))
+	}
+	return nil
+}
+
+// ListSnapshots returns the snapshots of a restic repository or an rsync
+// snapshot destination, oldest first.
+func ListSnapshots(config *Config) ([]Snapshot, error) {
+	normalized := config.Normalized()
+	config = &normalized
+
+	if !config.IsSnapshotMode() {
+		return nil, fmt.Errorf("snapshots are only available for restic backups and rsync backups in snapshot mode")
+	}
+	if config.Type == BackupTypeRsync {
+		return rsyncSnapshots(config)
+	}
+	if err := requireTool(config); err != nil {
+		return nil, err
+	}
+	output, err := runCommandOutput("restic", []string{"snapshots", "--json"}, ResticEnv(config))
+	if err != nil {
+		return nil, err
+	}
+	return parseSnapshots(output)
+}
+
+func parseSnapshots(data []byte) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, fmt.Errorf("parsing restic snapshots: %w", err)
+	}
+	slices.SortStableFunc(snapshots, func(a, b Snapshot) int {
+		return a.Time.Compare(b.Time)
+	})
+	return snapshots, nil
+}
+
+// ListFiles lists the files below dir (the whole backup if empty), sorted by
+// path. For snapshot backups it lists the given snapshot ("latest" if empty);
+// rsync mirrors and rclone list their destination.
+func ListFiles(config *Config, snapshot, dir string) ([]FileEntry, error) {
+	normalized := config.Normalized()
+	config = &normalized
+
+	if snapshot != "" && !config.IsSnapshotMode() {
+		return nil, fmt.Errorf("snapshots are only available for restic backups and rsync backups in snapshot mode")
+	}
+	if err := requireTool(config); err != nil {
+		return nil, err
+	}
+
+	var entries []FileEntry
+	var err error
+	switch config.Type {
+	case BackupTypeRestic:
+		if snapshot == "" {
+			snapshot = "latest"
+		}
+		args := []string{"ls", "--json", "--recursive", snapshot}
+		if dir != "" {
+			args = append(args, dir)
+		}
+		var output []byte
+		if output, err = runCommandOutput("restic", args, ResticEnv(config)); err == nil {
+			entries, err = parseResticLs(output)
+		}
+	case BackupTypeRclone:
+		var output []byte
+		if output, err = runCommandOutput("rclone", []string{"lsjson", "-R", joinRemote(config.Destination.Remote, dir)}, BaseEnv(config)); err == nil {
+			entries, err = parseRcloneLsjson(output)
+		}
+	case BackupTypeRsync:
+		var output []byte
+		root := joinRemote(config.RsyncSnapshotPath(snapshot), dir)
+		if output, err = runCommandOutput("rsync", []string{"--list-only", "-r", strings.TrimRight(root, "/") + "/"}, BaseEnv(config)); err == nil {
+			entries, err = parseRsyncList(output)
+		}
+	default:
+		err = fmt.Errorf("unsupported backup type: %s", config.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(entries, func(a, b FileEntry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return entries, nil
+}
+
+// joinRemote appends dir to an rsync or rclone location such as
+// "remote:bucket" or "host:/srv/backup", which filepath.Join would mangle.
+func joinRemote(location, dir string) string {
+	dir = strings.Trim(dir, "/")
+	if dir == "" {
+		return location
+	}
+	if strings.HasSuffix(location, ":") || strings.HasSuffix(location, "/") {
+		return location + dir
+	}
+	return location + "/" + dir
+}
+
+// parseResticLs parses the JSON lines printed by "restic ls --json", whose
+// first line describes the snapshot rather than a file.
+func parseResticLs(data []byte) ([]FileEntry, error) {
+	var entries []FileEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var node struct {
+			StructType string    `json:"struct_type"`
+			Type       string    `json:"type"`
+			Path       string    `json:"path"`
+			Size       int64     `json:"size"`
+			MTime      time.Time `json:"mtime"`
+		}
+		if err := json.Unmarshal(line, &node); err != nil {
+			return nil, fmt.Errorf("parsing restic ls output: %w", err)
+		}
+		if node.StructType == "snapshot" || node.Path == "" {
+			continue
+		}
+		entries = append(entries, FileEntry{Path: node.Path, Type: node.Type, Size: node.Size, ModTime: node.MTime})
+	}
+	return entries, scanner.Err()
+}
+
+// parseRcloneLsjson parses the output of "rclone lsjson".
+func parseRcloneLsjson(data []byte) ([]FileEntry, error) {
+	var items []struct {
+		Path    string    `json:"Path"`
+		Size    int64     `json:"Size"`
+		ModTime time.Time `json:"ModTime"`
+		IsDir   bool      `json:"IsDir"`
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parsing rclone lsjson output: %w", err)
+	}
+	entries := make([]FileEntry, len(items))
+	for i, item := range items {
+		entry := FileEntry{Path: item.Path, Type: "file", Size: item.Size, ModTime: item.ModTime}
+		if item.IsDir {
+			entry.Type, entry.Size = "dir", 0
+		}
+		entries[i] = entry
+	}
+	return entries, nil
+}
+
+// rsyncListRe matches a line of "rsync --list-only", e.g.
+// "-rw-r--r--          1,234 2024/01/02 10:20:30 docs/a.txt".
+var rsyncListRe = regexp.MustCompile(`^([-dlcbps])[-rwxsStT]{9}\s+([\d,.]+)\s+(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2})\s+(.+)$`)
+
+// parseRsyncList parses the output of "rsync --list-only -r". Times are in the
+// local time zone, as rsync prints them.
+func parseRsyncList(data []byte) ([]FileEntry, error) {
+	var entries []FileEntry
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimRight(line, "\r\n")
+		m := rsyncListRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := m[4]
+		entry := FileEntry{Type: "file"}
+		switch m[1] {
+		case "d":
+			entry.Type = "dir"
+		case "l":
+			entry.Type = "symlink"
+			name, _, _ = strings.Cut(name, " -> ")
+		}
+		if name == "." {
+			continue
+		}
+		entry.Path = path.Clean(name)
+		if entry.Type != "dir" {
+			size, err := ParseSize(m[2])
+			if err != nil {
+				return nil, fmt.Errorf("parsing rsync listing: %w", err)
+			}
+			entry.Size = size
+		}
+		modTime, err := time.ParseInLocation("2006/01/02 15:04:05", m[3], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("parsing rsync listing: %w", err)
+		}
+		entry.ModTime = modTime
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// rsyncSnapshots describes the snapshot directories of an rsync backup in the
+// same terms as restic snapshots. Their ID is the directory name.
+func rsyncSnapshots(config *Config) ([]Snapshot, error) {
+	dirs, err := listRsyncSnapshots(config.Destination.Path)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]Snapshot, len(dirs))
+	for i, d := range dirs {
+		snapshots[i] = Snapshot{ID: d.Name, ShortID: d.Name, Time: d.Time, Paths: config.Source}
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,98 @@
+package backup
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseSnapshots(t *testing.T) {
+	data := []byte(`[
+  {"time":"2026-02-01T03:00:00.5Z","paths":["/srv/data"],"hostname":"nas","username":"me","id":"bbbb2222","short_id":"bbbb2222"},
+  {"time":"2026-01-01T03:00:00Z","paths":["/srv/data"],"hostname":"nas","tags":["weekly"],"id":"aaaa1111","short_id":"aaaa1111"}
+]`)
+	snapshots, err := parseSnapshots(data)
+	if err != nil {
+		t.Fatalf("parseSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].ShortID != "aaaa1111" || snapshots[1].ShortID != "bbbb2222" {
+		t.Fatalf("parseSnapshots() = %+v, want both snapshots oldest first", snapshots)
+	}
+	if !slices.Equal(snapshots[0].Tags, []string{"weekly"}) || snapshots[1].Hostname != "nas" {
+		t.Errorf("parseSnapshots() fields = %+v", snapshots)
+	}
+}
+
+func TestParseResticLs(t *testing.T) {
+	data := []byte(`{"time":"2026-01-01T03:00:00Z","tree":"abc","paths":["/srv/data"],"id":"aaaa","short_id":"aaaa","struct_type":"snapshot"}
+{"name":"data","type":"dir","path":"/srv/data","mtime":"2026-01-01T02:00:00Z","struct_type":"node"}
+{"name":"a.txt","type":"file","path":"/srv/data/a.txt","size":42,"mtime":"2026-01-01T01:00:00Z","struct_type":"node"}
+`)
+	entries, err := parseResticLs(data)
+	if err != nil {
+		t.Fatalf("parseResticLs() error = %v", err)
+	}
+	want := []FileEntry{
+		{Path: "/srv/data", Type: "dir", ModTime: time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)},
+		{Path: "/srv/data/a.txt", Type: "file", Size: 42, ModTime: time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC)},
+	}
+	if !slices.EqualFunc(entries, want, fileEntryEqual) {
+		t.Errorf("parseResticLs() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestParseRcloneLsjson(t *testing.T) {
+	data := []byte(`[
+{"Path":"docs","Name":"docs","Size":-1,"ModTime":"2026-01-01T00:00:00Z","IsDir":true},
+{"Path":"docs/a.txt","Name":"a.txt","Size":1024,"ModTime":"2026-01-02T00:00:00Z","IsDir":false}
+]`)
+	entries, err := parseRcloneLsjson(data)
+	if err != nil {
+		t.Fatalf("parseRcloneLsjson() error = %v", err)
+	}
+	want := []FileEntry{
+		{Path: "docs", Type: "dir", ModTime: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Path: "docs/a.txt", Type: "file", Size: 1024, ModTime: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	if !slices.EqualFunc(entries, want, fileEntryEqual) {
+		t.Errorf("parseRcloneLsjson() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestParseRsyncList(t *testing.T) {
+	data := []byte(`drwxr-xr-x          4,096 2026/01/01 10:00:00 .
+drwxr-xr-x          4,096 2026/01/01 10:00:00 docs
+-rw-r--r--      1,234,567 2026/01/02 11:30:00 docs/my file.txt
+lrwxrwxrwx              5 2026/01/03 12:00:00 docs/link -> my file.txt
+`)
+	entries, err := parseRsyncList(data)
+	if err != nil {
+		t.Fatalf("parseRsyncList() error = %v", err)
+	}
+	want := []FileEntry{
+		{Path: "docs", Type: "dir", ModTime: time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)},
+		{Path: "docs/my file.txt", Type: "file", Size: 1234567, ModTime: time.Date(2026, 1, 2, 11, 30, 0, 0, time.Local)},
+		{Path: "docs/link", Type: "symlink", Size: 5, ModTime: time.Date(2026, 1, 3, 12, 0, 0, 0, time.Local)},
+	}
+	if !slices.EqualFunc(entries, want, fileEntryEqual) {
+		t.Errorf("parseRsyncList() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestJoinRemote(t *testing.T) {
+	tests := []struct{ location, dir, want string }{
+		{"b2:bucket", "", "b2:bucket"},
+		{"b2:bucket", "/docs/", "b2:bucket/docs"},
+		{"remote:", "docs", "remote:docs"},
+		{"host:/srv/backup/", "docs", "host:/srv/backup/docs"},
+	}
+	for _, tt := range tests {
+		if got := joinRemote(tt.location, tt.dir); got != tt.want {
+			t.Errorf("joinRemote(%q, %q) = %q, want %q", tt.location, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func fileEntryEqual(a, b FileEntry) bool {
+	return a.Path == b.Path && a.Type == b.Type && a.Size == b.Size && a.ModTime.Equal(b.ModTime)
+}
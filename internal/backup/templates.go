@@ -12,11 +12,22 @@ func GetServiceTemplate(executablePath, backupName string, config *Config) strin
 	fmt.Fprintf(&template, `[Unit]
 Description=Backup: %s
 Wants=network-online.target
-After=network-online.target
+After=network-online.target`, safeBackupName)
+
+	// Notifications are sent by the notify units, so that they also go out
+	// when systemd kills the backup. "backup notify" applies the filters.
+	runArgs := ""
+	if config.Notifications.Enabled {
+		notifyUnit := BackupNotifyUnitName(backupName)
+		fmt.Fprintf(&template, "\nOnFailure=%s\nOnSuccess=%s", notifyUnit, notifyUnit)
+		runArgs = " --no-notify"
+	}
+
+	fmt.Fprintf(&template, `
 
 [Service]
 Type=oneshot
-ExecStart=%q backup run %q`, safeBackupName, executablePath, backupName)
+ExecStart=%q backup run%s %q`, executablePath, runArgs, backupName)
 
 	// Prepend user's .local/bin to PATH for tools like restic, rclone installed locally
 	template.WriteString("\nEnvironment=PATH=%%h/.local/bin:%%h/.local/share/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin:/snap/bin")
@@ -38,7 +49,7 @@ StandardError=journal`)
 	}
 
 	// Add cleanup step if retention is configured
-	if config.Retention.KeepDays > 0 || config.Retention.KeepDaily > 0 {
+	if config.Retention.IsSet() {
 		fmt.Fprintf(&template, "\nExecStopPost=%q backup cleanup %q", executablePath, backupName)
 	}
 
@@ -68,20 +79,126 @@ WantedBy=timers.target
 	return fmt.Sprintf(template, safeBackupName, onCalendar, safeBackupName), nil
 }
 
-// GetNotificationServiceTemplate returns the systemd notification service template
-func GetNotificationServiceTemplate(executablePath, backupName string) string {
+// GetPruneServiceTemplate returns the systemd service template that prunes a
+// restic backup's repository.
+func GetPruneServiceTemplate(executablePath, backupName string, config *Config) string {
+	safeBackupName := sanitizeUnitLine(backupName)
+	var template strings.Builder
+	fmt.Fprintf(&template, `[Unit]
+Description=Prune backup repository: %s
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart=%q backup prune %q`, safeBackupName, executablePath, backupName)
+
+	template.WriteString("\nEnvironment=PATH=%%h/.local/bin:%%h/.local/share/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin:/snap/bin")
+	for _, env := range config.Environment {
+		fmt.Fprintf(&template, "\nEnvironment=%q", env)
+	}
+	template.WriteString(`
+StandardOutput=journal
+StandardError=journal
+`)
+
+	return template.String()
+}
+
+// GetPruneTimerTemplate returns the systemd timer template that prunes a
+// restic backup's repository on retention.prune_schedule.
+func GetPruneTimerTemplate(backupName, schedule string) (string, error) {
+	onCalendar, err := ParseSchedule(schedule)
+	if err != nil {
+		return "", err
+	}
 	safeBackupName := sanitizeUnitLine(backupName)
+
+	template := `[Unit]
+Description=Prune timer for backup %s
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+Unit=%s
+
+[Install]
+WantedBy=timers.target
+`
+
+	return fmt.Sprintf(template, safeBackupName, onCalendar, BackupPruneServiceName(safeBackupName)), nil
+}
+
+// GetNotificationServiceTemplate returns the template service that backup
+// services start through OnFailure= and OnSuccess=. The instance is the
+// escaped backup name; the status is read from the MONITOR_SERVICE_RESULT
+// systemd sets for it.
+func GetNotificationServiceTemplate(executablePath string) string {
+	template := `[Unit]
+Description=Backup notification: %%I
+
+[Service]
+Type=oneshot
+ExecStart=%q backup notify "%%I"
+Environment=PATH=%%h/.local/bin:%%h/.local/share/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin:/snap/bin
+StandardOutput=journal
+StandardError=journal
+`
+
+	return fmt.Sprintf(template, executablePath)
+}
+
+// GetNotificationDropInTemplate returns the drop-in that passes a backup's
+// environment on to its notify instance, for password_env and the like.
+func GetNotificationDropInTemplate(config *Config) string {
+	var template strings.Builder
+	template.WriteString("[Service]\n")
+	for _, env := range config.Environment {
+		fmt.Fprintf(&template, "Environment=%q\n", env)
+	}
+	return template.String()
+}
+
+// GetDigestServiceTemplate returns the systemd service template that emails
+// the digest of every backup's runs.
+func GetDigestServiceTemplate(executablePath string) string {
 	template := `[Unit]
-Description=Email notification for failed backup: %s
+Description=Backup digest
+Wants=network-online.target
+After=network-online.target
 
 [Service]
 Type=oneshot
-ExecStart=%q backup notify %q failure
+ExecStart=%q backup digest send
+Environment=PATH=%%h/.local/bin:%%h/.local/share/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin:/snap/bin
 StandardOutput=journal
 StandardError=journal
 `
 
-	return fmt.Sprintf(template, safeBackupName, executablePath, backupName)
+	return fmt.Sprintf(template, executablePath)
+}
+
+// GetDigestTimerTemplate returns the systemd timer template that sends the
+// digest on schedule.
+func GetDigestTimerTemplate(schedule string) (string, error) {
+	onCalendar, err := ParseSchedule(schedule)
+	if err != nil {
+		return "", err
+	}
+
+	template := `[Unit]
+Description=Backup digest timer
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+Unit=%s
+
+[Install]
+WantedBy=timers.target
+`
+
+	return fmt.Sprintf(template, onCalendar, BackupDigestServiceName), nil
 }
 
 func sanitizeUnitLine(value string) string {
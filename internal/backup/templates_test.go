@@ -46,3 +46,71 @@ func TestGetTimerTemplateSanitizesName(t *testing.T) {
 		t.Fatalf("GetTimerTemplate() did not sanitize unit name:\n%s", template)
 	}
 }
+
+func TestGetPruneTemplates(t *testing.T) {
+	service := GetPruneServiceTemplate("/usr/local/bin/qh", "docs", &Config{Environment: []string{"RESTIC_CACHE_DIR=/cache"}})
+	for _, check := range []string{
+		`ExecStart="/usr/local/bin/qh" backup prune "docs"`,
+		`Environment="RESTIC_CACHE_DIR=/cache"`,
+	} {
+		if !strings.Contains(service, check) {
+			t.Errorf("GetPruneServiceTemplate() missing %q in:\n%s", check, service)
+		}
+	}
+
+	timer, err := GetPruneTimerTemplate("docs", "weekly")
+	if err != nil {
+		t.Fatalf("GetPruneTimerTemplate() error = %v", err)
+	}
+	if !strings.Contains(timer, "Unit=docs-backup-prune.service") {
+		t.Errorf("GetPruneTimerTemplate() does not start the prune service:\n%s", timer)
+	}
+}
+
+func TestGetDigestTemplates(t *testing.T) {
+	service := GetDigestServiceTemplate("/usr/local/bin/qh")
+	if !strings.Contains(service, `ExecStart="/usr/local/bin/qh" backup digest send`) {
+		t.Errorf("GetDigestServiceTemplate() does not send the digest:\n%s", service)
+	}
+
+	timer, err := GetDigestTimerTemplate("weekly")
+	if err != nil {
+		t.Fatalf("GetDigestTimerTemplate() error = %v", err)
+	}
+	for _, check := range []string{"OnCalendar=Mon *-*-* 02:00:00", "Unit=" + BackupDigestServiceName} {
+		if !strings.Contains(timer, check) {
+			t.Errorf("GetDigestTimerTemplate() missing %q in:\n%s", check, timer)
+		}
+	}
+}
+
+func TestGetNotificationTemplates(t *testing.T) {
+	service := GetNotificationServiceTemplate("/usr/local/bin/qh")
+	for _, check := range []string{`ExecStart="/usr/local/bin/qh" backup notify "%I"`, "Environment=PATH=%h/.local/bin:"} {
+		if !strings.Contains(service, check) {
+			t.Errorf("GetNotificationServiceTemplate() missing %q in:\n%s", check, service)
+		}
+	}
+
+	dropIn := GetNotificationDropInTemplate(&Config{Environment: []string{"SMTP_PASSWORD=a b"}})
+	if want := "[Service]\nEnvironment=\"SMTP_PASSWORD=a b\"\n"; dropIn != want {
+		t.Errorf("GetNotificationDropInTemplate() = %q, want %q", dropIn, want)
+	}
+}
+
+func TestGetServiceTemplateWithNotifications(t *testing.T) {
+	config := &Config{Notifications: Notifications{Enabled: true}}
+	template := GetServiceTemplate("/usr/local/bin/qh", "my-docs", config)
+	for _, check := range []string{
+		`OnFailure=qh-backup-notify@my\x2ddocs.service`,
+		`OnSuccess=qh-backup-notify@my\x2ddocs.service`,
+		`ExecStart="/usr/local/bin/qh" backup run --no-notify "my-docs"`,
+	} {
+		if !strings.Contains(template, check) {
+			t.Errorf("GetServiceTemplate() missing %q in:\n%s", check, template)
+		}
+	}
+	if !strings.Contains(template, "After=network-online.target\nOnFailure=") {
+		t.Errorf("notify units are not in the [Unit] section:\n%s", template)
+	}
+}
@@ -1,6 +1,13 @@
 package backup
 
-import "fmt"
+import (
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/mufeedali/quadlet-helper/internal/config"
+	"github.com/mufeedali/quadlet-helper/internal/notify"
+)
 
 // BackupType represents the type of backup tool
 type BackupType string
@@ -23,6 +30,8 @@ type Config struct {
 	Retention     Retention     `yaml:"retention,omitempty"`
 	Notifications Notifications `yaml:"notifications,omitempty"`
 	Hooks         Hooks         `yaml:"hooks,omitempty"`
+	Quiesce       Quiesce       `yaml:"quiesce,omitempty"`
+	Dumps         []Dump        `yaml:"dumps,omitempty"`
 	Environment   []string      `yaml:"environment,omitempty"`
 }
 
@@ -41,17 +50,36 @@ type Options struct {
 	BandwidthLimit string   `yaml:"bandwidth_limit,omitempty"`
 	Exclude        []string `yaml:"exclude,omitempty"`
 
+	// ExportVolumes backs up volume sources as tarballs made by "podman
+	// volume export" rather than reading their mountpoints, for rootless
+	// setups where the user cannot read a volume's files.
+	ExportVolumes bool `yaml:"export_volumes,omitempty"`
+
 	// Rsync options
-	Archive  bool `yaml:"archive,omitempty"`
-	Compress bool `yaml:"compress,omitempty"`
-	Delete   bool `yaml:"delete,omitempty"`
+	Archive  bool      `yaml:"archive,omitempty"`
+	Compress bool      `yaml:"compress,omitempty"`
+	Delete   bool      `yaml:"delete,omitempty"`
+	Mode     RsyncMode `yaml:"mode,omitempty"` // mirror (default) or snapshot
 
 	// Restic options
 	PasswordFile string `yaml:"password_file,omitempty"`
-	KeepDaily    int    `yaml:"keep_daily,omitempty"`
-	KeepWeekly   int    `yaml:"keep_weekly,omitempty"`
+	// Deprecated: use Retention. Still read so that older configs keep
+	// their policy; Normalized moves them into Retention.
+	KeepDaily  int `yaml:"keep_daily,omitempty"`
+	KeepWeekly int `yaml:"keep_weekly,omitempty"`
 }
 
+// RsyncMode selects how rsync backups are laid out at the destination.
+type RsyncMode string
+
+const (
+	// RsyncModeMirror keeps a single copy that every run updates in place.
+	RsyncModeMirror RsyncMode = "mirror"
+	// RsyncModeSnapshot writes every run into its own timestamped directory,
+	// hard-linking unchanged files to the previous snapshot.
+	RsyncModeSnapshot RsyncMode = "snapshot"
+)
+
 // Verification settings
 // VerificationMethod enumerates allowed verification methods.
 type VerificationMethod string
@@ -87,12 +115,23 @@ func (m *VerificationMethod) UnmarshalText(text []byte) error {
 	}
 }
 
-// Retention settings
+// Retention decides which snapshots cleanup keeps. restic and rsync snapshot
+// backups support every rule; rclone only supports keep_within and keep_days,
+// as it has no snapshots to count.
 type Retention struct {
-	KeepDays    int `yaml:"keep_days,omitempty"`
-	KeepDaily   int `yaml:"keep_daily,omitempty"`
-	KeepWeekly  int `yaml:"keep_weekly,omitempty"`
-	KeepMonthly int `yaml:"keep_monthly,omitempty"`
+	KeepLast    int      `yaml:"keep_last,omitempty"`
+	KeepHourly  int      `yaml:"keep_hourly,omitempty"`
+	KeepDaily   int      `yaml:"keep_daily,omitempty"`
+	KeepWeekly  int      `yaml:"keep_weekly,omitempty"`
+	KeepMonthly int      `yaml:"keep_monthly,omitempty"`
+	KeepYearly  int      `yaml:"keep_yearly,omitempty"`
+	KeepWithin  string   `yaml:"keep_within,omitempty"` // restic duration such as 1y6m, 14d or 36h
+	KeepDays    int      `yaml:"keep_days,omitempty"`   // shorthand for keep_within: <n>d
+	KeepTags    []string `yaml:"keep_tags,omitempty"`   // restic only
+
+	// PruneSchedule runs "restic prune" on its own timer instead of after
+	// every forget, since pruning a large repository can take a long time.
+	PruneSchedule string `yaml:"prune_schedule,omitempty"`
 }
 
 // Notifications settings
@@ -101,12 +140,39 @@ type Notifications struct {
 	OnFailure bool        `yaml:"on_failure"`
 	OnSuccess bool        `yaml:"on_success"`
 	Email     EmailConfig `yaml:"email,omitempty"`
+	// Targets are sent to in addition to the global notifications.targets.
+	Targets []notify.Target `yaml:"targets,omitempty"`
+
+	// Aggregate sends a failure once, and again only after the backup
+	// succeeded in between or RepeatAfter has passed. RepeatAfter is made of
+	// minutes, hours and days, such as 30min, 12h or 2d. The first success
+	// after failures is sent as a recovery.
+	Aggregate   bool   `yaml:"aggregate,omitempty"`
+	RepeatAfter string `yaml:"repeat_after,omitempty"`
 }
 
-// EmailConfig for email notifications
+// EmailConfig for email notifications. Recipients set here replace the
+// global ones; OnFailure and OnSuccess route a status to other recipients.
 type EmailConfig struct {
-	To   string `yaml:"to,omitempty"`
-	From string `yaml:"from,omitempty"`
+	config.Recipients `yaml:",inline"`
+	From              string            `yaml:"from,omitempty"`
+	OnFailure         config.Recipients `yaml:"on_failure,omitempty"`
+	OnSuccess         config.Recipients `yaml:"on_success,omitempty"`
+}
+
+// validate checks that every address parses.
+func (e EmailConfig) validate() error {
+	for _, r := range []config.Recipients{e.Recipients, e.OnFailure, e.OnSuccess} {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("notifications.email: %w", err)
+		}
+	}
+	if e.From != "" {
+		if _, err := mail.ParseAddress(e.From); err != nil {
+			return fmt.Errorf("notifications.email: invalid from address %q: %w", e.From, err)
+		}
+	}
+	return nil
 }
 
 // Hooks for pre/post backup scripts
@@ -115,3 +181,27 @@ type Hooks struct {
 	PostBackup string `yaml:"post_backup,omitempty"`
 	OnFailure  string `yaml:"on_failure,omitempty"`
 }
+
+// Quiesce lists what to stop or pause while a backup runs, so that databases
+// and other live data are copied in a consistent state. Everything is brought
+// back afterwards, whether the backup succeeded or not.
+type Quiesce struct {
+	Stop  []string `yaml:"stop,omitempty"`  // quadlet units, e.g. "db" or "db.container"
+	Pause []string `yaml:"pause,omitempty"` // quadlet containers or podman container names
+}
+
+// Status is the machine-readable state of a backup and its timer, as printed by
+// the list and status commands with --output json or yaml.
+type Status struct {
+	Name         string     `json:"name" yaml:"name"`
+	Type         BackupType `json:"type,omitempty" yaml:"type,omitempty"`
+	Schedule     string     `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Destination  string     `json:"destination,omitempty" yaml:"destination,omitempty"`
+	Installed    bool       `json:"installed" yaml:"installed"`
+	TimerState   string     `json:"timer_state,omitempty" yaml:"timer_state,omitempty"`     // e.g. active, inactive
+	ServiceState string     `json:"service_state,omitempty" yaml:"service_state,omitempty"` // e.g. inactive, activating, failed
+	LastResult   string     `json:"last_result,omitempty" yaml:"last_result,omitempty"`     // systemd Result= of the last run, e.g. success, exit-code
+	LastRun      *time.Time `json:"last_run,omitempty" yaml:"last_run,omitempty"`
+	NextRun      *time.Time `json:"next_run,omitempty" yaml:"next_run,omitempty"`
+	Error        string     `json:"error,omitempty" yaml:"error,omitempty"` // why the fields above could not be filled in
+}
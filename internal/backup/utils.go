@@ -33,6 +33,40 @@ func BackupServiceName(backupName string) string {
 	return fmt.Sprintf("%s-backup.service", backupName)
 }
 
+// BackupPruneTimerName returns the systemd timer name for pruning a restic
+// backup on its own schedule.
+func BackupPruneTimerName(backupName string) string {
+	return fmt.Sprintf("%s-backup-prune.timer", backupName)
+}
+
+// BackupPruneServiceName returns the systemd service name for pruning a
+// restic backup on its own schedule.
+func BackupPruneServiceName(backupName string) string {
+	return fmt.Sprintf("%s-backup-prune.service", backupName)
+}
+
+// BackupNotifyTemplateName is the template service shared by every backup
+// that sends notifications.
+const BackupNotifyTemplateName = "qh-backup-notify@.service"
+
+// BackupNotifyUnitName returns the instance of the notify template that
+// reports on a backup.
+func BackupNotifyUnitName(backupName string) string {
+	return "qh-backup-notify@" + systemd.Escape(backupName) + ".service"
+}
+
+// BackupNotifyDropInName returns the drop-in that gives a backup's notify
+// instance the backup's environment.
+func BackupNotifyDropInName(backupName string) string {
+	return BackupNotifyUnitName(backupName) + ".d/environment.conf"
+}
+
+// The service and timer that email the digest of every backup's runs.
+const (
+	BackupDigestServiceName = "qh-backup-digest.service"
+	BackupDigestTimerName   = "qh-backup-digest.timer"
+)
+
 func GetServiceFilePath(backupName string) (string, error) {
 	userDir, err := systemd.UserDir()
 	if err != nil {
@@ -49,12 +83,12 @@ func GetTimerFilePath(backupName string) (string, error) {
 	return filepath.Join(userDir, BackupTimerName(backupName)), nil
 }
 
-func GetNotificationServiceFilePath(backupName string) (string, error) {
+func GetNotificationServiceFilePath() (string, error) {
 	userDir, err := systemd.UserDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(userDir, fmt.Sprintf("backup-notify@%s.service", backupName)), nil
+	return filepath.Join(userDir, BackupNotifyTemplateName), nil
 }
 
 func BaseEnv(config *Config) []string {
@@ -71,19 +105,14 @@ func ResticEnv(config *Config) []string {
 
 func RcloneDestPath(remote, source string, totalSources int) string {
 	if totalSources > 1 {
-		return filepath.Join(remote, filepath.Base(source))
+		return filepath.Join(remote, sourceBase(source))
 	}
 	return remote
 }
 
 func RsyncDestPath(destinationPath, source string, totalSources int) string {
-	trimmedSource := strings.TrimRight(source, string(os.PathSeparator))
-	if trimmedSource == "" {
-		trimmedSource = source
-	}
-
 	if totalSources > 1 {
-		return filepath.Join(destinationPath, filepath.Base(trimmedSource))
+		return filepath.Join(destinationPath, sourceBase(source))
 	}
 
 	if strings.HasSuffix(source, string(os.PathSeparator)) {
@@ -94,10 +123,20 @@ func RsyncDestPath(destinationPath, source string, totalSources int) string {
 		return destinationPath
 	}
 
-	return filepath.Join(destinationPath, filepath.Base(trimmedSource))
+	return filepath.Join(destinationPath, sourceBase(source))
 }
 
 func RsyncArgs(config *Config, dryRun bool) []string {
+	return rsyncArgs(config, dryRun, config.Destination.Path, "")
+}
+
+// RsyncSnapshotArgs returns the rsync arguments that write a new snapshot
+// into dir, hard-linking files that are unchanged since linkDest (if any).
+func RsyncSnapshotArgs(config *Config, dryRun bool, dir, linkDest string) []string {
+	return rsyncArgs(config, dryRun, dir, linkDest)
+}
+
+func rsyncArgs(config *Config, dryRun bool, dest, linkDest string) []string {
 	args := []string{}
 	if dryRun {
 		args = append(args, "--dry-run")
@@ -111,12 +150,15 @@ func RsyncArgs(config *Config, dryRun bool) []string {
 	if config.Options.Delete {
 		args = append(args, "--delete")
 	}
-	args = append(args, "-v", "--progress")
+	args = append(args, "-v", "--progress", "--stats")
+	if linkDest != "" {
+		args = append(args, "--link-dest", linkDest)
+	}
 	for _, exclude := range config.Options.Exclude {
 		args = append(args, "--exclude", exclude)
 	}
 	args = append(args, config.Source...)
-	return append(args, config.Destination.Path)
+	return append(args, dest)
 }
 
 func ResticBackupArgs(config *Config) []string {
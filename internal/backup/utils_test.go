@@ -54,7 +54,7 @@ func TestRsyncArgs(t *testing.T) {
 	}
 
 	got := RsyncArgs(config, true)
-	want := []string{"--dry-run", "-a", "-z", "--delete", "-v", "--progress", "--exclude", "*.tmp", "/src", "/dest"}
+	want := []string{"--dry-run", "-a", "-z", "--delete", "-v", "--progress", "--stats", "--exclude", "*.tmp", "/src", "/dest"}
 	if !slices.Equal(got, want) {
 		t.Fatalf("RsyncArgs() = %v, want %v", got, want)
 	}
@@ -3,6 +3,8 @@ package backup
 import (
 	"fmt"
 	"regexp"
+	"slices"
+	"strings"
 )
 
 var validBackupName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_\-.]*$`)
@@ -20,8 +22,35 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid backup type: %s (must be rsync, restic, or rclone)", c.Type)
 	}
 
-	if len(c.Source) == 0 {
-		return fmt.Errorf("at least one source path is required")
+	if len(c.Source) == 0 && len(c.Dumps) == 0 {
+		return fmt.Errorf("at least one source path or dump is required")
+	}
+	if err := validateDumps(c.Dumps); err != nil {
+		return err
+	}
+	for _, source := range c.Source {
+		if source == VolumeSourcePrefix || source == QuadletVolumeSourcePrefix {
+			return fmt.Errorf("source %q names no volume", source)
+		}
+	}
+	if c.Options.ExportVolumes && !slices.ContainsFunc(c.Source, IsVolumeSource) {
+		return fmt.Errorf("options.export_volumes is set, but no source is a volume")
+	}
+	if err := c.Notifications.Email.validate(); err != nil {
+		return err
+	}
+	for _, target := range c.Notifications.Targets {
+		if err := target.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Notifications.RepeatAfter != "" {
+		if !c.Notifications.Aggregate {
+			return fmt.Errorf("notifications.repeat_after is only used with notifications.aggregate")
+		}
+		if _, err := ParseDuration(c.Notifications.RepeatAfter); err != nil {
+			return fmt.Errorf("invalid notifications.repeat_after: %w", err)
+		}
 	}
 
 	// Validate destination based on type
@@ -34,12 +63,41 @@ func (c *Config) Validate() error {
 		if c.Destination.Path == "" {
 			return fmt.Errorf("destination.path is required for rsync backups")
 		}
+		switch c.Options.Mode {
+		case "", RsyncModeMirror:
+		case RsyncModeSnapshot:
+			if isRemoteRsyncPath(c.Destination.Path) {
+				return fmt.Errorf("options.mode snapshot needs a local destination.path, not %q", c.Destination.Path)
+			}
+		default:
+			return fmt.Errorf("invalid rsync mode: %q (must be mirror or snapshot)", c.Options.Mode)
+		}
 	case BackupTypeRestic:
 		if c.Destination.Repository == "" {
 			return fmt.Errorf("destination.repository is required for restic backups")
 		}
 	}
 
+	if c.Options.Mode != "" && c.Type != BackupTypeRsync {
+		return fmt.Errorf("options.mode is only supported for rsync backups")
+	}
+
+	if err := c.Retention.validate(); err != nil {
+		return err
+	}
+	switch {
+	case c.Type != BackupTypeRestic && len(c.Retention.KeepTags) > 0:
+		return fmt.Errorf("retention.keep_tags is only supported for restic backups")
+	case c.Type != BackupTypeRestic && c.Retention.PruneSchedule != "":
+		return fmt.Errorf("retention.prune_schedule is only supported for restic backups")
+	}
+
+	for _, name := range slices.Concat(c.Quiesce.Stop, c.Quiesce.Pause) {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("quiesce entries must not be empty")
+		}
+	}
+
 	// Validate schedule format
 	if c.Schedule == "" {
 		return fmt.Errorf("schedule is required")
@@ -83,8 +141,41 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// Warnings returns the problems in the configuration that older versions
+// accepted, so they are reported rather than refused. The rules they name are
+// ignored by cleanup.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	switch {
+	case c.Type == BackupTypeRclone && c.Retention.HasCountRules():
+		warnings = append(warnings, "rclone backups have no snapshots to count; only retention.keep_within and retention.keep_days are used")
+	case c.Type == BackupTypeRsync && c.Options.Mode != RsyncModeSnapshot && c.Retention.IsSet():
+		warnings = append(warnings, "retention for rsync backups needs options.mode: snapshot; it is ignored for a mirror")
+	}
+	return warnings
+}
+
+// Normalized returns a copy of the configuration with defaults filled in,
+// the restic retention settings older configs kept under options moved into
+// Retention, and the staging directory added to the sources if there are
+// dumps or exported volumes, so that runs, restores and listings agree on the layout.
 func (c Config) Normalized() Config {
 	normalized := c
+	if normalized.usesStaging() {
+		if dir, err := DumpStagingDir(normalized.Name); err == nil && !slices.Contains(normalized.Source, dir) {
+			normalized.Source = append(slices.Clone(normalized.Source), dir)
+		}
+	}
+	if normalized.Type == BackupTypeRestic {
+		if normalized.Retention.KeepDaily == 0 {
+			normalized.Retention.KeepDaily = normalized.Options.KeepDaily
+		}
+		if normalized.Retention.KeepWeekly == 0 {
+			normalized.Retention.KeepWeekly = normalized.Options.KeepWeekly
+		}
+		normalized.Options.KeepDaily, normalized.Options.KeepWeekly = 0, 0
+	}
+
 	if !normalized.Verification.Enabled && normalized.Verification.Method == "" {
 		return normalized
 	}
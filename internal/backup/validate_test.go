@@ -60,3 +60,114 @@ verification:
 		t.Fatalf("Verification.Method = %q, want %q", config.Verification.Method, VerificationMethodSize)
 	}
 }
+
+func TestValidateRetention(t *testing.T) {
+	base := func(backupType BackupType, retention Retention) Config {
+		config := Config{Name: "demo", Type: backupType, Schedule: "daily", Source: []string{"/srv"}, Retention: retention}
+		switch backupType {
+		case BackupTypeRestic:
+			config.Destination.Repository = "/repo"
+		case BackupTypeRclone:
+			config.Destination.Remote = "remote:bucket"
+		case BackupTypeRsync:
+			config.Destination.Path = "/backup"
+			config.Options.Mode = RsyncModeSnapshot
+		}
+		return config
+	}
+	mirror := base(BackupTypeRsync, Retention{KeepDaily: 7})
+	mirror.Options.Mode = ""
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"restic with every rule", base(BackupTypeRestic, Retention{KeepLast: 1, KeepHourly: 24, KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12, KeepYearly: 2, KeepWithin: "1y", KeepTags: []string{"manual"}, PruneSchedule: "weekly"}), false},
+		{"rsync snapshots with counts", base(BackupTypeRsync, Retention{KeepDaily: 7, KeepWithin: "2d"}), false},
+		{"rclone with within", base(BackupTypeRclone, Retention{KeepWithin: "30d"}), false},
+		{"rclone with keep_days", base(BackupTypeRclone, Retention{KeepDays: 30}), false},
+		{"rclone with counts", base(BackupTypeRclone, Retention{KeepDaily: 7}), false},
+		{"rsync mirror", mirror, false},
+		{"tags outside restic", base(BackupTypeRsync, Retention{KeepTags: []string{"manual"}}), true},
+		{"prune schedule outside restic", base(BackupTypeRsync, Retention{KeepDaily: 1, PruneSchedule: "weekly"}), true},
+		{"invalid within", base(BackupTypeRestic, Retention{KeepWithin: "2 weeks"}), true},
+		{"zero within", base(BackupTypeRestic, Retention{KeepWithin: "0h"}), true},
+		{"zero within in parts", base(BackupTypeRclone, Retention{KeepWithin: "0y0d"}), true},
+		{"within and keep_days", base(BackupTypeRestic, Retention{KeepWithin: "2d", KeepDays: 2}), true},
+		{"negative count", base(BackupTypeRestic, Retention{KeepLast: -1}), true},
+		{"empty tag list", base(BackupTypeRestic, Retention{KeepTags: []string{""}}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   int
+	}{
+		{"restic counts", Config{Type: BackupTypeRestic, Retention: Retention{KeepDaily: 7}}, 0},
+		{"rclone within", Config{Type: BackupTypeRclone, Retention: Retention{KeepDays: 30}}, 0},
+		{"rclone counts", Config{Type: BackupTypeRclone, Retention: Retention{KeepDaily: 7}}, 1},
+		{"rsync mirror", Config{Type: BackupTypeRsync, Retention: Retention{KeepDays: 30}}, 1},
+		{"rsync snapshots", Config{Type: BackupTypeRsync, Options: Options{Mode: RsyncModeSnapshot}, Retention: Retention{KeepDays: 30}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.Warnings(); len(got) != tt.want {
+				t.Errorf("Warnings() = %q, want %d warning(s)", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizedMovesLegacyResticRetention(t *testing.T) {
+	config := Config{
+		Type:      BackupTypeRestic,
+		Options:   Options{KeepDaily: 7, KeepWeekly: 4},
+		Retention: Retention{KeepWeekly: 8, KeepMonthly: 6},
+	}
+	got := config.Normalized()
+	want := Retention{KeepDaily: 7, KeepWeekly: 8, KeepMonthly: 6}
+	if got.Retention.KeepDaily != want.KeepDaily || got.Retention.KeepWeekly != want.KeepWeekly || got.Retention.KeepMonthly != want.KeepMonthly {
+		t.Errorf("Normalized().Retention = %+v, want %+v", got.Retention, want)
+	}
+	if got.Options.KeepDaily != 0 || got.Options.KeepWeekly != 0 {
+		t.Errorf("Normalized().Options still has keep_daily/keep_weekly: %+v", got.Options)
+	}
+}
+
+func TestValidateNotifications(t *testing.T) {
+	tests := []struct {
+		name          string
+		notifications Notifications
+		wantErr       bool
+	}{
+		{"aggregate", Notifications{Enabled: true, Aggregate: true}, false},
+		{"aggregate with repeat", Notifications{Enabled: true, Aggregate: true, RepeatAfter: "12h"}, false},
+		{"repeat without aggregate", Notifications{Enabled: true, RepeatAfter: "12h"}, true},
+		{"repeat in minutes", Notifications{Enabled: true, Aggregate: true, RepeatAfter: "30min"}, false},
+		{"repeat in bare m", Notifications{Enabled: true, Aggregate: true, RepeatAfter: "30m"}, true},
+		{"repeat in days", Notifications{Enabled: true, Aggregate: true, RepeatAfter: "2d"}, false},
+		{"invalid repeat", Notifications{Enabled: true, Aggregate: true, RepeatAfter: "soon"}, true},
+		{"repeat in months", Notifications{Enabled: true, Aggregate: true, RepeatAfter: "1m2d"}, true},
+		{"zero repeat", Notifications{Enabled: true, Aggregate: true, RepeatAfter: "0h"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Name: "demo", Type: BackupTypeRsync, Schedule: "daily", Source: []string{"/srv"}, Destination: Destination{Path: "/backup"}, Notifications: tt.notifications}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -31,6 +31,12 @@ func Verify(config *Config) (*VerifyResult, error) {
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
+	// Volume sources are compared through the directories they were backed
+	// up from.
+	config, err := resolveVolumeSources(config)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if the required tool is available
 	available, err := CheckToolAvailable(config.Type)
@@ -76,7 +82,7 @@ func verifyRsyncSize(config *Config) (*VerifyResult, error) {
 	result := &VerifyResult{Success: true}
 	var details strings.Builder
 
-	for _, source := range config.Source {
+	for _, source := range config.liveSources() {
 		// Get source size
 		srcSize, err := getDirSize(source)
 		if err != nil {
@@ -93,7 +99,7 @@ func verifyRsyncSize(config *Config) (*VerifyResult, error) {
 			fmt.Fprintf(&details, "Source %s: %d bytes (remote verification not supported)\n", source, srcSize)
 			continue
 		} else {
-			destPath = RsyncDestPath(config.Destination.Path, source, len(config.Source))
+			destPath = RsyncDestPath(config.RsyncSnapshotPath(""), source, len(config.Source))
 		}
 
 		// Get destination size
@@ -138,10 +144,10 @@ func verifyRsyncChecksum(config *Config) (*VerifyResult, error) {
 	args := []string{"--dry-run", "--checksum", "-n", "-i"}
 
 	// Add sources
-	args = append(args, config.Source...)
+	args = append(args, config.liveSources()...)
 
 	// Add destination
-	args = append(args, config.Destination.Path)
+	args = append(args, config.RsyncSnapshotPath(""))
 
 	cmd := exec.Command("rsync", args...)
 	output, err := cmd.CombinedOutput()
@@ -212,7 +218,7 @@ func runRcloneCheck(config *Config, verb VerificationMethod) (*VerifyResult, err
 	var allOutput strings.Builder
 	success := true
 
-	for _, source := range config.Source {
+	for _, source := range config.liveSources() {
 		destPath := RcloneDestPath(config.Destination.Remote, source, len(config.Source))
 
 		args := []string{v, source, destPath}
@@ -253,7 +259,7 @@ func verifyRcloneSize(config *Config) (*VerifyResult, error) {
 		Bytes int64 `json:"bytes"`
 	}
 
-	for _, source := range config.Source {
+	for _, source := range config.liveSources() {
 		destPath := RcloneDestPath(config.Destination.Remote, source, len(config.Source))
 
 		// source
@@ -0,0 +1,176 @@
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mufeedali/quadlet-helper/internal/quadlet"
+)
+
+const (
+	// VolumeSourcePrefix marks a source as a podman volume, e.g.
+	// "volume:immich-data".
+	VolumeSourcePrefix = "volume:"
+	// QuadletVolumeSourcePrefix marks a source as the volume a quadlet
+	// .volume file creates, e.g. "quadlet-volume:immich.volume".
+	QuadletVolumeSourcePrefix = "quadlet-volume:"
+)
+
+// volumeSource returns the volume name of a "volume:" source.
+func volumeSource(source string) (string, bool) {
+	name, ok := strings.CutPrefix(source, VolumeSourcePrefix)
+	return name, ok
+}
+
+// IsVolumeSource reports whether source names a podman volume, directly or
+// through a quadlet .volume file.
+func IsVolumeSource(source string) bool {
+	return strings.HasPrefix(source, VolumeSourcePrefix) || strings.HasPrefix(source, QuadletVolumeSourcePrefix)
+}
+
+// sourceBase returns the name a source is stored under at the destination
+// when a backup has several sources: the last path element, or the volume
+// name for volume sources.
+func sourceBase(source string) string {
+	if name, ok := volumeSource(source); ok {
+		return name
+	}
+	trimmed := strings.TrimRight(source, "/")
+	if trimmed == "" {
+		trimmed = source
+	}
+	return filepath.Base(trimmed)
+}
+
+// exportsVolumes reports whether the backup exports volume sources into the
+// staging directory instead of reading their mountpoints.
+func (c *Config) exportsVolumes() bool {
+	return c.Options.ExportVolumes && slices.ContainsFunc(c.Source, IsVolumeSource)
+}
+
+// usesStaging reports whether the backup writes dumps or exported volumes to
+// its staging directory.
+func (c *Config) usesStaging() bool {
+	return len(c.Dumps) > 0 || c.exportsVolumes()
+}
+
+// layoutSources returns the sources as they are laid out at the destination.
+// Exported volumes are left out, as they are stored in the staging directory.
+func (c *Config) layoutSources() []string {
+	if !c.Options.ExportVolumes {
+		return c.Source
+	}
+	return slices.DeleteFunc(slices.Clone(c.Source), IsVolumeSource)
+}
+
+// ResolveQuadletVolumes rewrites "quadlet-volume:" sources into "volume:"
+// sources naming the volume each .volume file creates.
+func ResolveQuadletVolumes(sources []string, units []*quadlet.UnitFile) ([]string, error) {
+	resolved := slices.Clone(sources)
+	for i, source := range sources {
+		name, ok := strings.CutPrefix(source, QuadletVolumeSourcePrefix)
+		if !ok {
+			continue
+		}
+		if !strings.HasSuffix(name, ".volume") {
+			name += ".volume"
+		}
+		idx := slices.IndexFunc(units, func(u *quadlet.UnitFile) bool { return u.Name() == name })
+		if idx < 0 {
+			return nil, fmt.Errorf("source %s: quadlet volume %s not found", source, name)
+		}
+		resolved[i] = VolumeSourcePrefix + units[idx].VolumeName()
+	}
+	return resolved, nil
+}
+
+// ListVolumes returns the names of the podman volumes.
+func ListVolumes() ([]string, error) {
+	output, err := podman("volume", "ls", "--format", "{{.Name}}")
+	if err != nil {
+		return nil, fmt.Errorf("listing podman volumes: %w: %s", err, output)
+	}
+	return strings.Fields(output), nil
+}
+
+// volumeDir returns the directory a volume's data is read from: the parent of
+// its mountpoint for local volumes, whose mountpoint is always named _data,
+// so that the volume is stored under its own name.
+func volumeDir(name string) (string, error) {
+	output, err := podman("volume", "inspect", "--format", "{{.Mountpoint}}", name)
+	if err != nil {
+		return "", fmt.Errorf("inspecting volume %s: %w: %s", name, err, output)
+	}
+	if output == "" {
+		return "", fmt.Errorf("volume %s has no mountpoint", name)
+	}
+	if filepath.Base(output) == "_data" {
+		return filepath.Dir(output), nil
+	}
+	return output, nil
+}
+
+// resolveVolumeSources returns a copy of config whose sources are all paths:
+// volume sources are replaced by their directories, and exported volumes are
+// left out, as their tarballs are in the staging directory.
+func resolveVolumeSources(config *Config) (*Config, error) {
+	if !slices.ContainsFunc(config.Source, IsVolumeSource) {
+		return config, nil
+	}
+
+	resolved := *config
+	resolved.Source = nil
+	for _, source := range config.layoutSources() {
+		if strings.HasPrefix(source, QuadletVolumeSourcePrefix) {
+			return nil, fmt.Errorf("source %s was not resolved to a volume", source)
+		}
+		name, ok := volumeSource(source)
+		if !ok {
+			resolved.Source = append(resolved.Source, source)
+			continue
+		}
+		dir, err := volumeDir(name)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Source = append(resolved.Source, dir)
+	}
+	return &resolved, nil
+}
+
+// exportVolumes writes every volume source into the staging directory as a
+// tarball with "podman volume export", which works where a rootless user
+// cannot read the volume's files directly. It returns a log of the exports.
+func exportVolumes(config *Config, dryRun bool) (string, error) {
+	if !config.exportsVolumes() {
+		return "", nil
+	}
+	staging, err := DumpStagingDir(config.Name)
+	if err != nil {
+		return "", err
+	}
+
+	var log strings.Builder
+	for _, source := range config.Source {
+		name, ok := volumeSource(source)
+		if !ok {
+			continue
+		}
+		tarball := filepath.Join(staging, name+".tar")
+		line := fmt.Sprintf("Exporting volume %s to %s\n", name, filepath.Base(tarball))
+		if dryRun {
+			line = "Would export" + strings.TrimPrefix(line, "Exporting")
+		}
+		fmt.Print(line)
+		log.WriteString(line)
+		if dryRun {
+			continue
+		}
+		if output, err := podman("volume", "export", name, "--output", tarball); err != nil {
+			return log.String(), fmt.Errorf("volume export of %s failed: %w: %s", name, err, output)
+		}
+	}
+	return log.String(), nil
+}
@@ -0,0 +1,162 @@
+package backup
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSourceBase(t *testing.T) {
+	tests := map[string]string{
+		"/srv/immich":        "immich",
+		"/srv/immich/":       "immich",
+		"/":                  "/",
+		"volume:immich-data": "immich-data",
+	}
+	for source, want := range tests {
+		if got := sourceBase(source); got != want {
+			t.Errorf("sourceBase(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestResolveQuadletVolumes(t *testing.T) {
+	units := testUnits(t, map[string]string{
+		"immich.volume": "[Volume]\n",
+		"db.volume":     "[Volume]\nVolumeName=%N-data\n",
+	})
+
+	got, err := ResolveQuadletVolumes([]string{"/srv/config", "quadlet-volume:immich.volume", "quadlet-volume:db", "volume:other"}, units)
+	if err != nil {
+		t.Fatalf("ResolveQuadletVolumes() error = %v", err)
+	}
+	want := []string{"/srv/config", "volume:systemd-immich", "volume:db-data", "volume:other"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ResolveQuadletVolumes() = %v, want %v", got, want)
+	}
+
+	if _, err := ResolveQuadletVolumes([]string{"quadlet-volume:missing.volume"}, units); err == nil {
+		t.Error("ResolveQuadletVolumes() with a missing file error = nil, want an error")
+	}
+}
+
+// fakeVolumes replaces podman with one that knows the given volume
+// mountpoints and records every call.
+func fakeVolumes(t *testing.T, mountpoints map[string]string) *[]string {
+	t.Helper()
+	var calls []string
+	old := podman
+	podman = func(args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		switch {
+		case args[0] == "volume" && args[1] == "inspect":
+			if mountpoint, ok := mountpoints[args[len(args)-1]]; ok {
+				return mountpoint, nil
+			}
+			return "no such volume", errors.New("exit status 125")
+		case args[0] == "volume" && args[1] == "export":
+			return "", nil
+		}
+		return "", errors.New("unexpected podman call")
+	}
+	t.Cleanup(func() { podman = old })
+	return &calls
+}
+
+func TestResolveVolumeSources(t *testing.T) {
+	fakeVolumes(t, map[string]string{
+		"immich-data": "/home/user/.local/share/containers/storage/volumes/immich-data/_data",
+		"nfs":         "/mnt/nfs",
+	})
+
+	config := &Config{Source: []string{"/srv/config", "volume:immich-data", "volume:nfs"}}
+	resolved, err := resolveVolumeSources(config)
+	if err != nil {
+		t.Fatalf("resolveVolumeSources() error = %v", err)
+	}
+	want := []string{"/srv/config", "/home/user/.local/share/containers/storage/volumes/immich-data", "/mnt/nfs"}
+	if !slices.Equal(resolved.Source, want) {
+		t.Errorf("resolveVolumeSources().Source = %v, want %v", resolved.Source, want)
+	}
+	if config.Source[1] != "volume:immich-data" {
+		t.Errorf("resolveVolumeSources() changed the original sources to %v", config.Source)
+	}
+
+	if _, err := resolveVolumeSources(&Config{Source: []string{"volume:missing"}}); err == nil {
+		t.Error("resolveVolumeSources() with a missing volume error = nil, want an error")
+	}
+	if _, err := resolveVolumeSources(&Config{Source: []string{"quadlet-volume:db.volume"}}); err == nil {
+		t.Error("resolveVolumeSources() with an unresolved quadlet volume error = nil, want an error")
+	}
+}
+
+func TestExportVolumes(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	calls := fakeVolumes(t, nil)
+
+	config := &Config{Name: "immich", Source: []string{"/srv/config", "volume:immich-data"}, Options: Options{ExportVolumes: true}}
+	normalized := config.Normalized()
+	staging, err := DumpStagingDir("immich")
+	if err != nil {
+		t.Fatalf("DumpStagingDir() error = %v", err)
+	}
+	if want := []string{"/srv/config", "volume:immich-data", staging}; !slices.Equal(normalized.Source, want) {
+		t.Errorf("Normalized().Source = %v, want %v", normalized.Source, want)
+	}
+	if want := []string{"/srv/config", staging}; !slices.Equal(normalized.layoutSources(), want) {
+		t.Errorf("layoutSources() = %v, want %v", normalized.layoutSources(), want)
+	}
+
+	log, err := exportVolumes(&normalized, false)
+	if err != nil {
+		t.Fatalf("exportVolumes() error = %v", err)
+	}
+	if !strings.Contains(log, "Exporting volume immich-data to immich-data.tar") {
+		t.Errorf("exportVolumes() log = %q", log)
+	}
+	if want := []string{"volume export immich-data --output " + staging + "/immich-data.tar"}; !slices.Equal(*calls, want) {
+		t.Errorf("podman calls = %v, want %v", *calls, want)
+	}
+
+	resolved, err := resolveVolumeSources(&normalized)
+	if err != nil {
+		t.Fatalf("resolveVolumeSources() error = %v", err)
+	}
+	if want := []string{"/srv/config", staging}; !slices.Equal(resolved.Source, want) {
+		t.Errorf("resolveVolumeSources().Source = %v, want %v", resolved.Source, want)
+	}
+}
+
+func TestValidateVolumeSources(t *testing.T) {
+	base := func(sources []string, export bool) Config {
+		return Config{
+			Name:        "demo",
+			Type:        BackupTypeRestic,
+			Schedule:    "daily",
+			Source:      sources,
+			Destination: Destination{Repository: "/repo"},
+			Options:     Options{ExportVolumes: export},
+		}
+	}
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"volume", base([]string{"volume:immich-data"}, false), false},
+		{"quadlet volume", base([]string{"quadlet-volume:immich.volume"}, false), false},
+		{"exported volume", base([]string{"/srv", "volume:immich-data"}, true), false},
+		{"volume without a name", base([]string{"volume:"}, false), true},
+		{"quadlet volume without a name", base([]string{"quadlet-volume:"}, false), true},
+		{"export without volumes", base([]string{"/srv"}, true), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package cmdutil
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/mufeedali/quadlet-helper/internal/shared"
@@ -19,7 +20,8 @@ func Wrap(err error, format string, args ...any) error {
 }
 
 func PrintError(err error) {
-	if err == nil {
+	var exitErr *ExitError
+	if err == nil || errors.As(err, &exitErr) && exitErr.Silent {
 		return
 	}
 	fmt.Println(shared.ErrorStyle.Render(err.Error()))
@@ -0,0 +1,49 @@
+package cmdutil
+
+import "errors"
+
+// Exit codes used by commands that report more than success or failure.
+const (
+	ExitFailure = 1   // the default for any error
+	ExitTimeout = 124 // a wait timed out, as with timeout(1)
+)
+
+// ExitError is an error that makes qh exit with a specific status code.
+type ExitError struct {
+	Code int
+	Err  error
+
+	// Silent keeps the error from being printed, for commands whose output
+	// already reports the failure.
+	Silent bool
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// WithExitCode wraps err so that qh exits with code when it is returned from
+// a command.
+func WithExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// SilentExit wraps err so that qh exits with code without printing it.
+func SilentExit(code int, err error) error {
+	return &ExitError{Code: code, Err: err, Silent: true}
+}
+
+// ExitCode returns the status code qh should exit with for err.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
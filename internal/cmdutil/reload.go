@@ -1,25 +1,24 @@
-package unit
+package cmdutil
 
 import (
 	"fmt"
 
-	"github.com/mufeedali/quadlet-helper/internal/cmdutil"
 	"github.com/mufeedali/quadlet-helper/internal/shared"
 	"github.com/mufeedali/quadlet-helper/internal/systemd"
 )
 
-// reloadDaemon performs a systemctl daemon-reload and prints UI messages.
+// ReloadDaemon performs a systemctl daemon-reload and prints UI messages.
 // Callers should decide whether to call it (i.e., respect --no-reload) so the
 // function itself does not print skip messages.
-func reloadDaemon() error {
+func ReloadDaemon(manager systemd.Manager) error {
 	fmt.Println(shared.TitleStyle.Render("Reloading systemctl daemon..."))
-	output, err := systemd.DaemonReload()
+	output, err := manager.DaemonReload()
 	if err != nil {
 		fmt.Println(shared.InfoMark + " Please reload manually: systemctl --user daemon-reload")
 		if output != "" {
-			return cmdutil.Errorf("reloading systemctl daemon: %v\n%s", err, output)
+			return Errorf("reloading systemctl daemon: %v\n%s", err, output)
 		}
-		return cmdutil.Wrap(err, "reloading systemctl daemon")
+		return Wrap(err, "reloading systemctl daemon")
 	}
 	if len(output) > 0 {
 		fmt.Println(string(output))
@@ -28,7 +27,7 @@ func reloadDaemon() error {
 	return nil
 }
 
-// printSkipReload prints the message shown when --no-reload is used.
-func printSkipReload() {
+// PrintSkipReload prints the message shown when --no-reload is used.
+func PrintSkipReload() {
 	fmt.Println(shared.InfoMark + " Skipping systemctl daemon reload ( --no-reload )")
 }
@@ -1,27 +1,68 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mufeedali/quadlet-helper/internal/notify"
+	"github.com/spf13/viper"
+)
 
 // EmailConfig holds the global email settings
 type EmailConfig struct {
-	Host         string
-	Port         int
-	Username     string
-	PasswordFile string
-	TLS          bool
-	From         string
-	To           string
+	Host     string
+	Port     int
+	Username string
+	Auth     string // plain (the default), login or cram-md5
+
+	// The SMTP password is read from at most one of these.
+	PasswordFile       string
+	PasswordCredential string // name of a systemd credential
+	PasswordEnv        string // name of an environment variable
+	PasswordCommand    string // shell command printing the password, e.g. "pass show smtp"
+
+	// TLS is starttls, implicit or none. Left empty, it is implicit on port
+	// 465 and starttls on any other.
+	TLS                   string
+	TLSCAFile             string
+	TLSInsecureSkipVerify bool
+
+	From string
+
+	// Recipients get every email unless OnFailure or OnSuccess names
+	// recipients for the status being reported.
+	Recipients
+	OnFailure Recipients
+	OnSuccess Recipients
 }
 
 // LoadEmailConfig loads the email configuration from Viper
 func LoadEmailConfig() EmailConfig {
 	return EmailConfig{
-		Host:         viper.GetString("email.host"),
-		Port:         viper.GetInt("email.port"),
-		Username:     viper.GetString("email.user"),
-		PasswordFile: viper.GetString("email.passwordfile"),
-		TLS:          viper.GetBool("email.tls"),
-		From:         viper.GetString("email.from"),
-		To:           viper.GetString("email.to"),
+		Host:                  viper.GetString("email.host"),
+		Port:                  viper.GetInt("email.port"),
+		Username:              viper.GetString("email.user"),
+		Auth:                  strings.ToLower(viper.GetString("email.auth")),
+		PasswordFile:          viper.GetString("email.passwordfile"),
+		PasswordCredential:    viper.GetString("email.password_credential"),
+		PasswordEnv:           viper.GetString("email.password_env"),
+		PasswordCommand:       viper.GetString("email.password_command"),
+		TLS:                   loadTLSMode(),
+		TLSCAFile:             viper.GetString("email.tls_ca_file"),
+		TLSInsecureSkipVerify: viper.GetBool("email.tls_insecure_skip_verify"),
+		From:                  viper.GetString("email.from"),
+		Recipients:            loadRecipients("email"),
+		OnFailure:             loadRecipients("email.on_failure"),
+		OnSuccess:             loadRecipients("email.on_success"),
+	}
+}
+
+// LoadNotifyTargets loads the notification targets every backup sends to
+// from notifications.targets.
+func LoadNotifyTargets() ([]notify.Target, error) {
+	var targets []notify.Target
+	if err := viper.UnmarshalKey("notifications.targets", &targets); err != nil {
+		return nil, fmt.Errorf("invalid notifications.targets: %w", err)
 	}
+	return targets, nil
 }
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// TLS modes of the SMTP connection.
+const (
+	TLSStartTLS = "starttls" // plain connection upgraded with STARTTLS
+	TLSImplicit = "implicit" // TLS from the start, usually on port 465
+	TLSNone     = "none"
+)
+
+// SMTP authentication mechanisms.
+const (
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+)
+
+// TLSMode returns the TLS mode of the connection, deriving it from the port
+// when it is not set.
+func (c EmailConfig) TLSMode() string {
+	if c.TLS != "" {
+		return c.TLS
+	}
+	if c.Port == 465 {
+		return TLSImplicit
+	}
+	return TLSStartTLS
+}
+
+// Validate checks the SMTP settings.
+func (c EmailConfig) Validate() error {
+	switch c.TLS {
+	case "", TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return fmt.Errorf("invalid email.tls: %q (must be starttls, implicit or none)", c.TLS)
+	}
+	switch c.Auth {
+	case "", AuthPlain, AuthLogin, AuthCRAMMD5:
+	default:
+		return fmt.Errorf("invalid email.auth: %q (must be plain, login or cram-md5)", c.Auth)
+	}
+	sources := 0
+	for _, s := range []string{c.PasswordFile, c.PasswordCredential, c.PasswordEnv, c.PasswordCommand} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return errors.New("only one of email.passwordfile, email.password_credential, email.password_env and email.password_command can be set")
+	}
+	if strings.Contains(c.PasswordCredential, "/") {
+		return fmt.Errorf("invalid email.password_credential: %q is not a credential name", c.PasswordCredential)
+	}
+	return nil
+}
+
+// Password reads the SMTP password from the configured source, or returns ""
+// if there is none.
+func (c EmailConfig) Password() (string, error) {
+	switch {
+	case c.PasswordFile != "":
+		data, err := os.ReadFile(c.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read SMTP password file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case c.PasswordCredential != "":
+		dir := os.Getenv("CREDENTIALS_DIRECTORY")
+		if dir == "" {
+			return "", fmt.Errorf("failed to read SMTP password credential %q: $CREDENTIALS_DIRECTORY is not set", c.PasswordCredential)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, c.PasswordCredential))
+		if err != nil {
+			return "", fmt.Errorf("failed to read SMTP password credential: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case c.PasswordEnv != "":
+		password, ok := os.LookupEnv(c.PasswordEnv)
+		if !ok {
+			return "", fmt.Errorf("SMTP password variable $%s is not set", c.PasswordEnv)
+		}
+		return password, nil
+	case c.PasswordCommand != "":
+		cmd := exec.Command("/bin/sh", "-c", c.PasswordCommand)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("SMTP password command failed: %w", err)
+		}
+		// Like pass, password managers print the password on the first line
+		// and anything else after it.
+		first, _, _ := strings.Cut(string(out), "\n")
+		return strings.TrimSpace(first), nil
+	}
+	return "", nil
+}
+
+// loadTLSMode reads email.tls. Older configs and the --email-tls flag set it
+// to true or false, which select the mode from the port and no TLS.
+func loadTLSMode() string {
+	switch v := viper.Get("email.tls").(type) {
+	case bool:
+		if !v {
+			return TLSNone
+		}
+	case string:
+		switch mode := strings.ToLower(strings.TrimSpace(v)); mode {
+		case "true":
+		case "false":
+			return TLSNone
+		default:
+			return mode
+		}
+	}
+	return ""
+}
+
+// AddressList is a list of email addresses in RFC 5322 form, such as
+// "Ops <ops@example.org>". Each entry may itself be a comma-separated list,
+// and in YAML a single string is accepted in place of a list.
+type AddressList []string
+
+// UnmarshalYAML accepts a single string as well as a sequence.
+func (l *AddressList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = nil
+		if strings.TrimSpace(node.Value) != "" {
+			*l = AddressList{node.Value}
+		}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Parse parses every address in the list.
+func (l AddressList) Parse() ([]*mail.Address, error) {
+	var addrs []*mail.Address
+	for _, entry := range l {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parsed, err := mail.ParseAddressList(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address %q: %w", entry, err)
+		}
+		addrs = append(addrs, parsed...)
+	}
+	return addrs, nil
+}
+
+// Recipients are the addresses an email is sent to.
+type Recipients struct {
+	To  AddressList `yaml:"to,omitempty"`
+	CC  AddressList `yaml:"cc,omitempty"`
+	BCC AddressList `yaml:"bcc,omitempty"`
+}
+
+// IsEmpty reports whether no recipient is set.
+func (r Recipients) IsEmpty() bool {
+	return len(r.To) == 0 && len(r.CC) == 0 && len(r.BCC) == 0
+}
+
+// Validate checks that every address parses.
+func (r Recipients) Validate() error {
+	for _, list := range []AddressList{r.To, r.CC, r.BCC} {
+		if _, err := list.Parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadRecipients reads the to, cc and bcc lists under prefix.
+func loadRecipients(prefix string) Recipients {
+	return Recipients{
+		To:  loadAddressList(prefix + ".to"),
+		CC:  loadAddressList(prefix + ".cc"),
+		BCC: loadAddressList(prefix + ".bcc"),
+	}
+}
+
+// loadAddressList reads an address list that may be given as a single string,
+// as the --email-to flag does, or as a list.
+func loadAddressList(key string) AddressList {
+	if s, ok := viper.Get(key).(string); ok {
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		return AddressList{s}
+	}
+	return viper.GetStringSlice(key)
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+func TestAddressListYAML(t *testing.T) {
+	tests := []struct {
+		in   string
+		want AddressList
+	}{
+		{`to: admin@example.org`, AddressList{"admin@example.org"}},
+		{`to: [a@example.org, "Ops <ops@example.org>"]`, AddressList{"a@example.org", "Ops <ops@example.org>"}},
+		{`to: ""`, nil},
+	}
+	for _, tt := range tests {
+		var r Recipients
+		if err := yaml.Unmarshal([]byte(tt.in), &r); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", tt.in, err)
+		}
+		if !slices.Equal(r.To, tt.want) {
+			t.Errorf("Unmarshal(%q).To = %q, want %q", tt.in, r.To, tt.want)
+		}
+	}
+}
+
+func TestAddressListParse(t *testing.T) {
+	addrs, err := AddressList{"a@example.org, Ops <ops@example.org>", "", "b@example.org"}.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var got []string
+	for _, a := range addrs {
+		got = append(got, a.Address)
+	}
+	if want := []string{"a@example.org", "ops@example.org", "b@example.org"}; !slices.Equal(got, want) {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+	if _, err := (AddressList{"not an address"}).Parse(); err == nil {
+		t.Error("Parse() of an invalid address error = nil, want an error")
+	}
+}
+
+func TestPassword(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "smtp"), []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+	t.Setenv("QH_TEST_SMTP_PASSWORD", " from env ")
+
+	tests := []struct {
+		name string
+		conf EmailConfig
+		want string
+	}{
+		{"none", EmailConfig{}, ""},
+		{"file", EmailConfig{PasswordFile: filepath.Join(dir, "smtp")}, "from-file"},
+		{"credential", EmailConfig{PasswordCredential: "smtp"}, "from-file"},
+		{"env", EmailConfig{PasswordEnv: "QH_TEST_SMTP_PASSWORD"}, " from env "},
+		{"command", EmailConfig{PasswordCommand: "printf 'from-command\\nurl: example.org\\n'"}, "from-command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.conf.Password()
+			if err != nil {
+				t.Fatalf("Password() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Password() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, conf := range []EmailConfig{
+		{PasswordCredential: "missing"},
+		{PasswordEnv: "QH_TEST_UNSET_PASSWORD"},
+		{PasswordCommand: "exit 1"},
+	} {
+		if _, err := conf.Password(); err == nil {
+			t.Errorf("Password() for %+v error = nil, want an error", conf)
+		}
+	}
+}
+
+func TestEmailConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    EmailConfig
+		wantErr bool
+	}{
+		{"defaults", EmailConfig{}, false},
+		{"login over implicit TLS", EmailConfig{Auth: AuthLogin, TLS: TLSImplicit}, false},
+		{"unknown TLS mode", EmailConfig{TLS: "ssl"}, true},
+		{"unknown auth", EmailConfig{Auth: "xoauth2"}, true},
+		{"two password sources", EmailConfig{PasswordFile: "/pw", PasswordEnv: "PW"}, true},
+		{"credential path", EmailConfig{PasswordCredential: "../pw"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.conf.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadTLSMode(t *testing.T) {
+	tests := []struct {
+		value any
+		port  int
+		want  string
+	}{
+		{nil, 587, TLSStartTLS},
+		{nil, 465, TLSImplicit},
+		{true, 465, TLSImplicit},
+		{false, 587, TLSNone},
+		{"true", 465, TLSImplicit}, // a bare --email-tls
+		{"false", 587, TLSNone},
+		{"STARTTLS", 465, TLSStartTLS},
+		{"none", 25, TLSNone},
+	}
+	for _, tt := range tests {
+		viper.Reset()
+		viper.Set("email.port", tt.port)
+		if tt.value != nil {
+			viper.Set("email.tls", tt.value)
+		}
+		if got := LoadEmailConfig().TLSMode(); got != tt.want {
+			t.Errorf("TLSMode() with email.tls = %v on port %d = %q, want %q", tt.value, tt.port, got, tt.want)
+		}
+	}
+	viper.Reset()
+}
@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ntfy publishes to an ntfy topic, given by its full URL.
+type ntfy struct{ Target }
+
+func (n ntfy) Send(msg Message) error {
+	token, err := n.token()
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{
+		// Header values must be ASCII; ntfy decodes RFC 2047 words.
+		"Title": mime.BEncoding.Encode("UTF-8", msg.Title),
+		"Tags":  statusTag(msg.Status),
+	}
+	if priority := n.priority(msg.Status, 4, 3); priority > 0 {
+		headers["Priority"] = strconv.Itoa(priority)
+	}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return send(http.MethodPost, n.URL, []byte(msg.Body), headers)
+}
+
+func statusTag(status string) string {
+	switch status {
+	case StatusSuccess, StatusRecovery:
+		return "white_check_mark"
+	case StatusFailure:
+		return "rotating_light"
+	}
+	return "information_source"
+}
+
+// priority returns the configured priority, or the default for the status.
+func (t Target) priority(status string, failure, other int) int {
+	if t.Priority > 0 {
+		return t.Priority
+	}
+	if status == StatusFailure {
+		return failure
+	}
+	return other
+}
+
+// gotify posts to a Gotify server with an application token.
+type gotify struct{ Target }
+
+func (g gotify) Send(msg Message) error {
+	token, err := g.token()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]any{
+		"title":    msg.Title,
+		"message":  msg.Body,
+		"priority": g.priority(msg.Status, 8, 5),
+	})
+	if err != nil {
+		return err
+	}
+	return send(http.MethodPost, strings.TrimRight(g.URL, "/")+"/message", body, map[string]string{
+		"Content-Type": "application/json",
+		"X-Gotify-Key": token,
+	})
+}
+
+// chat posts to a Slack or Discord incoming webhook. Mattermost and Rocket.Chat
+// accept the Slack format too.
+type chat struct{ Target }
+
+// discordLimit is the most characters a Discord message may hold.
+const discordLimit = 2000
+
+func (c chat) Send(msg Message) error {
+	text := msg.Title + "\n" + msg.Body
+	payload := map[string]string{"text": text}
+	if c.Type == TypeDiscord {
+		if runes := []rune(text); len(runes) > discordLimit {
+			text = string(runes[:discordLimit-1]) + "…"
+		}
+		payload = map[string]string{"content": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return send(http.MethodPost, c.URL, body, map[string]string{"Content-Type": "application/json"})
+}
+
+// matrix sends a text message to a Matrix room through the client-server API.
+type matrix struct{ Target }
+
+func (m matrix) Send(msg Message) error {
+	token, err := m.token()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]string{
+		"msgtype": "m.text",
+		"body":    msg.Title + "\n\n" + msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/qh-%d",
+		strings.TrimRight(m.URL, "/"), url.PathEscape(m.Room), time.Now().UnixNano())
+	return send(http.MethodPut, endpoint, body, map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + token,
+	})
+}
+
+// webhook posts a JSON body built from a template to any URL.
+type webhook struct {
+	Target
+	body *template.Template
+}
+
+// defaultBody sends the message as a JSON object.
+const defaultBody = `{"backup":{{json .Backup}},"status":{{json .Status}},"title":{{json .Title}},"message":{{json .Body}}}`
+
+func parseBody(body string) (*template.Template, error) {
+	if body == "" {
+		body = defaultBody
+	}
+	return template.New("body").Funcs(template.FuncMap{
+		// json quotes a value for use inside a JSON document.
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(body)
+}
+
+func newWebhook(t Target) (webhook, error) {
+	body, err := parseBody(t.Body)
+	if err != nil {
+		return webhook{}, err
+	}
+	return webhook{t, body}, nil
+}
+
+func (w webhook) Send(msg Message) error {
+	var body bytes.Buffer
+	if err := w.body.Execute(&body, msg); err != nil {
+		return fmt.Errorf("rendering webhook body: %w", err)
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	maps.Copy(headers, w.Headers)
+	return send(http.MethodPost, w.URL, body.Bytes(), headers)
+}
@@ -0,0 +1,197 @@
+// Package notify sends short status messages to chat and push services such
+// as ntfy, Gotify, Slack, Discord, Matrix or any JSON webhook.
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Type is the service a target sends to.
+type Type string
+
+const (
+	TypeNtfy    Type = "ntfy"
+	TypeGotify  Type = "gotify"
+	TypeWebhook Type = "webhook"
+	TypeSlack   Type = "slack"
+	TypeDiscord Type = "discord"
+	TypeMatrix  Type = "matrix"
+)
+
+// Statuses a message can report.
+const (
+	StatusSuccess  = "success"
+	StatusFailure  = "failure"
+	StatusRecovery = "recovery" // first success after failures
+	StatusTest     = "test"
+)
+
+// Target is a configured notification destination. Targets are read both
+// from the global config through viper and from backup configs, hence the
+// two sets of tags.
+type Target struct {
+	// Type may be left out if URL is a service URL such as
+	// ntfys://ntfy.example.org/backups, which names it.
+	Type Type `yaml:"type,omitempty" mapstructure:"type"`
+	// URL is the ntfy topic URL, the Gotify or Matrix server, or the
+	// webhook URL, or a service URL that includes the token too.
+	URL string `yaml:"url" mapstructure:"url"`
+	// TokenFile holds the ntfy access token, Gotify application token or
+	// Matrix access token.
+	TokenFile string `yaml:"token_file,omitempty" mapstructure:"token_file"`
+	Room      string `yaml:"room,omitempty" mapstructure:"room"`         // matrix: room ID, e.g. "!abc:example.org"
+	Priority  int    `yaml:"priority,omitempty" mapstructure:"priority"` // ntfy (1-5) and gotify (0-10)
+	// Body is a text/template for the webhook's request body, executed with
+	// the Message. It defaults to the Message as JSON.
+	Body    string            `yaml:"body,omitempty" mapstructure:"body"`
+	Headers map[string]string `yaml:"headers,omitempty" mapstructure:"headers"` // webhook: extra request headers
+
+	// OnSuccess and OnFailure filter the messages the target is sent. Left
+	// unset, they follow the backup's own on_success and on_failure.
+	OnSuccess *bool `yaml:"on_success,omitempty" mapstructure:"on_success"`
+	OnFailure *bool `yaml:"on_failure,omitempty" mapstructure:"on_failure"`
+
+	secret string // token taken from a service URL
+}
+
+// Message is what a notification says.
+type Message struct {
+	Backup string `json:"backup"`
+	Status string `json:"status"` // success, failure, recovery or test
+	Title  string `json:"title"`
+	Body   string `json:"message"`
+}
+
+// Notifier sends messages to one target.
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// New returns the notifier for a target.
+func New(t Target) (Notifier, error) {
+	t, err := t.resolve()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	switch t.Type {
+	case TypeNtfy:
+		return ntfy{t}, nil
+	case TypeGotify:
+		return gotify{t}, nil
+	case TypeWebhook:
+		return newWebhook(t)
+	case TypeSlack, TypeDiscord:
+		return chat{t}, nil
+	case TypeMatrix:
+		return matrix{t}, nil
+	}
+	return nil, fmt.Errorf("unknown notification type: %q", t.Type)
+}
+
+// Validate checks that the target has what its type needs.
+func (t Target) Validate() error {
+	t, err := t.resolve()
+	if err != nil {
+		return err
+	}
+	switch t.Type {
+	case TypeNtfy, TypeGotify, TypeWebhook, TypeSlack, TypeDiscord, TypeMatrix:
+	case "":
+		return fmt.Errorf("notification type is required unless url is a service URL such as ntfy://ntfy.sh/topic")
+	default:
+		return fmt.Errorf("invalid notification type: %q (must be ntfy, gotify, webhook, slack, discord or matrix)", t.Type)
+	}
+	u, err := url.Parse(t.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s notification: url must be an http or https URL, or a service URL such as %s://", t.Type, t.Type)
+	}
+	if (t.Type == TypeGotify || t.Type == TypeMatrix) && t.TokenFile == "" && t.secret == "" {
+		return fmt.Errorf("%s notification: token_file is required", t.Type)
+	}
+	if t.Type == TypeMatrix && t.Room == "" {
+		return fmt.Errorf("matrix notification: room is required")
+	}
+	if t.Body != "" && t.Type != TypeWebhook {
+		return fmt.Errorf("%s notification: body is only used by webhooks", t.Type)
+	}
+	if t.Type == TypeWebhook {
+		if _, err := parseBody(t.Body); err != nil {
+			return fmt.Errorf("webhook notification: invalid body template: %w", err)
+		}
+	}
+	return nil
+}
+
+// Wants reports whether the target is sent a message with the given status.
+// Filters the target leaves unset fall back to onSuccess and onFailure.
+// Recoveries go wherever failures do, and test messages are always sent.
+func (t Target) Wants(status string, onSuccess, onFailure bool) bool {
+	switch status {
+	case StatusSuccess:
+		return boolOr(t.OnSuccess, onSuccess)
+	case StatusFailure, StatusRecovery:
+		return boolOr(t.OnFailure, onFailure)
+	}
+	return true
+}
+
+func boolOr(b *bool, fallback bool) bool {
+	if b == nil {
+		return fallback
+	}
+	return *b
+}
+
+// token returns the token from the target's service URL or token file, or ""
+// if it has none.
+func (t Target) token() (string, error) {
+	if t.secret != "" {
+		return t.secret, nil
+	}
+	if t.TokenFile == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(t.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+const httpTimeout = 10 * time.Second
+
+var client = &http.Client{Timeout: httpTimeout}
+
+// send makes a request and fails unless the server answers with a 2xx
+// status.
+func send(method, target string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected response from %s: %s %s", req.URL.Host, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// scheme is a service URL scheme in the style of Apprise, which names the
+// target's type and the scheme its real URL uses.
+type scheme struct {
+	typ    Type
+	scheme string
+}
+
+var schemes = map[string]scheme{
+	"ntfy":    {TypeNtfy, "http"},
+	"ntfys":   {TypeNtfy, "https"},
+	"gotify":  {TypeGotify, "http"},
+	"gotifys": {TypeGotify, "https"},
+	"matrix":  {TypeMatrix, "http"},
+	"matrixs": {TypeMatrix, "https"},
+	"json":    {TypeWebhook, "http"},
+	"jsons":   {TypeWebhook, "https"},
+	"discord": {TypeDiscord, "https"},
+	"slack":   {TypeSlack, "https"},
+}
+
+// resolve returns the target with a service URL such as
+// ntfys://token@ntfy.example.org/backups turned into its type, its http or
+// https URL and its token. Other targets are returned as they are. The
+// service URLs are:
+//
+//	ntfy://[token@]host/topic         ntfy, or ntfy://topic for ntfy.sh
+//	gotify://host[/path]/token        Gotify application token
+//	matrix://token@host/room          Matrix access token and room ID
+//	json://host/path                  JSON webhook
+//	discord://webhook_id/webhook_token
+//	slack://token_a/token_b/token_c   Slack incoming webhook
+//
+// The ntfys, gotifys, matrixs and jsons schemes use https.
+func (t Target) resolve() (Target, error) {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return t, nil
+	}
+	s, ok := schemes[u.Scheme]
+	if !ok {
+		return t, nil
+	}
+	if t.Type != "" && t.Type != s.typ {
+		return t, fmt.Errorf("%s notification: url is a %s:// URL", t.Type, u.Scheme)
+	}
+	if _, ok := u.User.Password(); ok {
+		return t, fmt.Errorf("%s notification: url must not contain a password; give a token instead", s.typ)
+	}
+	resolved := t
+	resolved.Type = s.typ
+	resolved.secret = u.User.Username()
+	if resolved.secret != "" && resolved.TokenFile != "" {
+		return t, fmt.Errorf("%s notification: set either a token in the url or token_file, not both", s.typ)
+	}
+	path := strings.Trim(u.EscapedPath(), "/")
+	real := url.URL{Scheme: s.scheme, Host: u.Host, RawQuery: u.RawQuery}
+	switch s.typ {
+	case TypeNtfy:
+		if path == "" {
+			real.Scheme, real.Host, path = "https", "ntfy.sh", u.Host
+		}
+		real.RawPath = "/" + path
+	case TypeGotify:
+		prefix, token, _ := cutLast(path, "/")
+		if token == "" {
+			return t, fmt.Errorf("gotify notification: url must end in the application token")
+		}
+		resolved.secret = token
+		real.RawPath = strings.TrimSuffix("/"+prefix, "/")
+	case TypeMatrix:
+		room := path
+		if u.Fragment != "" {
+			room = "#" + u.Fragment
+		}
+		if room != "" {
+			if resolved.Room, err = url.PathUnescape(room); err != nil {
+				return t, fmt.Errorf("matrix notification: invalid room in url: %w", err)
+			}
+		}
+	case TypeWebhook:
+		real.RawPath = "/" + path
+	case TypeDiscord:
+		real.Host, real.RawPath = "discord.com", "/api/webhooks/"+u.Host+"/"+path
+	case TypeSlack:
+		real.Host, real.RawPath = "hooks.slack.com", "/services/"+u.Host+"/"+path
+	}
+	if real.Path, err = url.PathUnescape(real.RawPath); err != nil {
+		return t, fmt.Errorf("%s notification: invalid url: %w", s.typ, err)
+	}
+	resolved.URL = real.String()
+	return resolved, nil
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return "", s, false
+}
+
+// Kind returns the target's type, taken from its URL if it is a service URL
+// such as ntfy://ntfy.sh/backups.
+func (t Target) Kind() Type {
+	if resolved, err := t.resolve(); err == nil {
+		return resolved.Type
+	}
+	return t.Type
+}
@@ -0,0 +1,87 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// Format selects how listing and status commands print their results.
+type Format string
+
+const (
+	Table Format = "table" // styled output for humans (default)
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	Plain Format = "plain" // tab-separated rows without colour or borders
+)
+
+// Formats lists every valid --output value.
+var Formats = []string{string(Table), string(JSON), string(YAML), string(Plain)}
+
+// ParseFormat validates an --output value.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case Table, JSON, YAML, Plain:
+		return f, nil
+	case "":
+		return Table, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q (must be %s)", s, strings.Join(Formats, ", "))
+	}
+}
+
+// Current returns the format selected with the global --output flag, falling
+// back to Table for invalid values.
+func Current() Format {
+	f, err := ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return Table
+	}
+	return f
+}
+
+// Structured reports whether f is a machine-readable encoding of the result
+// structs rather than a text rendering.
+func (f Format) Structured() bool {
+	return f == JSON || f == YAML
+}
+
+// Encode writes v as JSON or YAML.
+func Encode(w io.Writer, f Format, v any) error {
+	switch f {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("format %q is not a structured format", f)
+	}
+}
+
+// WritePlain writes rows as tab-separated lines. Tabs and newlines inside
+// cells are replaced with spaces so every row stays on one line.
+func WritePlain(w io.Writer, rows [][]string) error {
+	clean := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = clean.Replace(cell)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{in: "", want: Table},
+		{in: "table", want: Table},
+		{in: "JSON", want: JSON},
+		{in: "yaml", want: YAML},
+		{in: "plain", want: Plain},
+		{in: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseFormat(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	type result struct {
+		Name    string `json:"name" yaml:"name"`
+		Enabled bool   `json:"enabled" yaml:"enabled"`
+	}
+	v := []result{{Name: "web", Enabled: true}}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{format: JSON, want: "[\n  {\n    \"name\": \"web\",\n    \"enabled\": true\n  }\n]\n"},
+		{format: YAML, want: "- name: web\n  enabled: true\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, tt.format, v); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Encode() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	if err := Encode(&bytes.Buffer{}, Plain, v); err == nil {
+		t.Error("Encode(Plain) error = nil, want error")
+	}
+}
+
+func TestWritePlain(t *testing.T) {
+	var buf bytes.Buffer
+	rows := [][]string{{"web", "container", "active"}, {"multi\nline", "a\tb", ""}}
+	if err := WritePlain(&buf, rows); err != nil {
+		t.Fatalf("WritePlain() error = %v", err)
+	}
+	want := "web\tcontainer\tactive\nmulti line\ta b\t\n"
+	if buf.String() != want {
+		t.Errorf("WritePlain() = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,76 @@
+package quadlet
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mufeedali/quadlet-helper/internal/shared"
+)
+
+// UnitFile is a quadlet file found on disk together with its parsed contents.
+// Unlike Unit, it does not need podman or a running user manager.
+type UnitFile struct {
+	Path string
+	File *File // nil if the file could not be read or parsed
+	Err  error
+}
+
+// Name returns the quadlet file name, e.g. "actual.container".
+func (u *UnitFile) Name() string {
+	return filepath.Base(u.Path)
+}
+
+// UnitType returns the quadlet file type, e.g. "container".
+func (u *UnitFile) UnitType() string {
+	return strings.TrimPrefix(filepath.Ext(u.Path), ".")
+}
+
+// BaseName returns the file name without its extension.
+func (u *UnitFile) BaseName() string {
+	return strings.TrimSuffix(u.Name(), filepath.Ext(u.Path))
+}
+
+// ServiceName returns the systemd service generated for the file.
+func (u *UnitFile) ServiceName() string {
+	return ServiceName(u.BaseName(), u.UnitType())
+}
+
+// Info returns the unit's status given the active and sub-state of its
+// service, which must be queried from systemd by the caller.
+func (u *UnitFile) Info(activeState, subState string) UnitStatus {
+	return UnitStatus{
+		Name:        u.BaseName(),
+		Type:        u.UnitType(),
+		Path:        u.Path,
+		Service:     u.ServiceName(),
+		Enabled:     u.File != nil && u.File.HasSection("Install"),
+		ActiveState: activeState,
+		SubState:    subState,
+	}
+}
+
+// Discover walks root (following symlinks) and parses every quadlet file it
+// finds. Files that fail to parse are returned with Err set. The result is
+// sorted by path.
+func Discover(root string) ([]*UnitFile, error) {
+	var units []*UnitFile
+	err := shared.WalkWithSymlinks(root, func(path string, d fs.DirEntry) error {
+		if d.IsDir() || MainSection(strings.TrimPrefix(filepath.Ext(path), ".")) == "" {
+			return nil
+		}
+		unit := &UnitFile{Path: path}
+		unit.File, unit.Err = ParseFile(path)
+		units = append(units, unit)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(units, func(a, b *UnitFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return units, nil
+}
@@ -0,0 +1,393 @@
+package quadlet
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// sectionForType maps a quadlet file type to the name of its main section.
+var sectionForType = map[string]string{
+	"artifact":  "Artifact",
+	"build":     "Build",
+	"container": "Container",
+	"image":     "Image",
+	"kube":      "Kube",
+	"network":   "Network",
+	"pod":       "Pod",
+	"volume":    "Volume",
+}
+
+// MainSection returns the name of the type-specific section for a quadlet
+// file type, e.g. "Container" for "container". It returns "" for unknown types.
+func MainSection(unitType string) string {
+	return sectionForType[unitType]
+}
+
+// Entry is a single logical line of a quadlet file. Blank lines and comments
+// are kept as entries with an empty Key so that files round-trip unchanged.
+type Entry struct {
+	Key   string
+	Value string
+	Line  int // 1-based line number in the parsed file; 0 for added entries
+
+	// raw holds the original physical lines (more than one for continuation
+	// lines). It is nil for entries created or modified after parsing.
+	raw []string
+}
+
+// IsSetting reports whether the entry is a Key=Value setting.
+func (e *Entry) IsSetting() bool {
+	return e.Key != ""
+}
+
+func (e *Entry) lines() []string {
+	if e.raw != nil {
+		return e.raw
+	}
+	if e.Key == "" {
+		return []string{e.Value}
+	}
+	return []string{e.Key + "=" + e.Value}
+}
+
+// Section is a "[Name]" block together with every entry up to the next header.
+type Section struct {
+	Name    string
+	Entries []*Entry
+	Line    int // line number of the header; 0 for added sections
+
+	header string // original header line, kept verbatim
+}
+
+// File is a parsed quadlet unit file. It preserves comments, continuation
+// lines, repeated keys and section order, so a file can be read, modified and
+// written back without losing anything.
+type File struct {
+	// Preamble holds comments and blank lines before the first section.
+	Preamble []*Entry
+	Sections []*Section
+
+	noFinalNewline bool
+}
+
+// Parse reads a quadlet file in systemd's INI-style unit syntax.
+func Parse(r io.Reader) (*File, error) {
+	f := &File{}
+	var current *Section
+	var pending []string // physical lines of an unfinished continuation
+	pendingLine := 0
+
+	add := func(e *Entry) {
+		if current == nil {
+			f.Preamble = append(f.Preamble, e)
+			return
+		}
+		current.Entries = append(current.Entries, e)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	scanner.Split(scanRawLines)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if pending != nil {
+			pending = append(pending, line)
+			// Comment lines inside a continuation are skipped, not ended on.
+			if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") || strings.HasSuffix(trimmed, `\`) {
+				continue
+			}
+			add(settingFromLines(pending, pendingLine))
+			pending = nil
+			continue
+		}
+
+		switch {
+		case trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';':
+			add(&Entry{Value: line, Line: lineNo, raw: []string{line}})
+		case trimmed[0] == '[':
+			if !strings.HasSuffix(trimmed, "]") || len(trimmed) < 3 {
+				return nil, fmt.Errorf("line %d: invalid section header %q", lineNo, trimmed)
+			}
+			current = &Section{Name: trimmed[1 : len(trimmed)-1], Line: lineNo, header: line}
+			f.Sections = append(f.Sections, current)
+		default:
+			if current == nil {
+				return nil, fmt.Errorf("line %d: assignment outside of a section", lineNo)
+			}
+			if !strings.Contains(trimmed, "=") {
+				return nil, fmt.Errorf("line %d: missing '=' in %q", lineNo, trimmed)
+			}
+			if strings.HasSuffix(trimmed, `\`) {
+				pending = []string{line}
+				pendingLine = lineNo
+				continue
+			}
+			add(settingFromLines([]string{line}, lineNo))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		add(settingFromLines(pending, pendingLine))
+	}
+
+	return f, nil
+}
+
+// scanRawLines is bufio.ScanLines without the carriage-return stripping, so
+// CRLF files are written back byte for byte.
+func scanRawLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// settingFromLines builds a Key=Value entry from one or more physical lines.
+// As in systemd, a trailing backslash joins a line to the next with a space,
+// and comment lines inside a continuation are ignored.
+func settingFromLines(lines []string, lineNo int) *Entry {
+	var parts []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if i > 0 && (strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";")) {
+			continue
+		}
+		parts = append(parts, strings.TrimSpace(strings.TrimSuffix(trimmed, `\`)))
+	}
+	joined := strings.Join(parts, " ")
+	key, value, _ := strings.Cut(joined, "=")
+	return &Entry{
+		Key:   strings.TrimSpace(key),
+		Value: strings.TrimSpace(value),
+		Line:  lineNo,
+		raw:   lines,
+	}
+}
+
+// ParseBytes parses a quadlet file held in memory.
+func ParseBytes(data []byte) (*File, error) {
+	f, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	f.noFinalNewline = len(data) > 0 && data[len(data)-1] != '\n'
+	return f, nil
+}
+
+// ParseFile reads and parses the quadlet file at path.
+func ParseFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Bytes renders the file back to its textual form.
+func (f *File) Bytes() []byte {
+	var lines []string
+	for _, e := range f.Preamble {
+		lines = append(lines, e.lines()...)
+	}
+	for _, s := range f.Sections {
+		header := s.header
+		if header == "" {
+			header = "[" + s.Name + "]"
+		}
+		lines = append(lines, header)
+		for _, e := range s.Entries {
+			lines = append(lines, e.lines()...)
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	out := strings.Join(lines, "\n")
+	if !f.noFinalNewline {
+		out += "\n"
+	}
+	return []byte(out)
+}
+
+// Section returns the first section with the given name, or nil.
+func (f *File) Section(name string) *Section {
+	for _, s := range f.Sections {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// HasSection reports whether the file contains a section with the given name.
+func (f *File) HasSection(name string) bool {
+	return f.Section(name) != nil
+}
+
+// AddSection appends a new, empty section and returns it. A blank line is
+// inserted before the header if the file does not already end with one.
+func (f *File) AddSection(name string) *Section {
+	var tail []*Entry
+	if n := len(f.Sections); n > 0 {
+		tail = f.Sections[n-1].Entries
+	} else {
+		tail = f.Preamble
+	}
+
+	separator := &Entry{}
+	if n := len(f.Sections); n > 0 {
+		if len(tail) == 0 || strings.TrimSpace(tail[len(tail)-1].Value) != "" || tail[len(tail)-1].IsSetting() {
+			f.Sections[n-1].Entries = append(f.Sections[n-1].Entries, separator)
+		}
+	} else if len(tail) > 0 && strings.TrimSpace(tail[len(tail)-1].Value) != "" {
+		f.Preamble = append(f.Preamble, separator)
+	}
+
+	s := &Section{Name: name}
+	f.Sections = append(f.Sections, s)
+	f.noFinalNewline = false
+	return s
+}
+
+// RemoveSection removes every section with the given name and reports
+// whether anything was removed.
+func (f *File) RemoveSection(name string) bool {
+	kept := f.Sections[:0]
+	removed := false
+	for _, s := range f.Sections {
+		if s.Name == name {
+			removed = true
+			continue
+		}
+		kept = append(kept, s)
+	}
+	f.Sections = kept
+	return removed
+}
+
+// Get returns the effective value of key in the named section. When a key is
+// repeated, the last assignment wins, as it does for single-valued systemd keys.
+func (f *File) Get(section, key string) (string, bool) {
+	values := f.GetAll(section, key)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetAll returns every value assigned to key across all sections with the
+// given name, in file order.
+func (f *File) GetAll(section, key string) []string {
+	var values []string
+	for _, s := range f.Sections {
+		if s.Name == section {
+			values = append(values, s.GetAll(key)...)
+		}
+	}
+	return values
+}
+
+// Settings returns the Key=Value entries of the section, skipping blank lines
+// and comments.
+func (s *Section) Settings() []*Entry {
+	var settings []*Entry
+	for _, e := range s.Entries {
+		if e.IsSetting() {
+			settings = append(settings, e)
+		}
+	}
+	return settings
+}
+
+// Get returns the last value assigned to key in the section.
+func (s *Section) Get(key string) (string, bool) {
+	values := s.GetAll(key)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetAll returns every value assigned to key in the section, in order.
+func (s *Section) GetAll(key string) []string {
+	var values []string
+	for _, e := range s.Entries {
+		if e.Key == key {
+			values = append(values, e.Value)
+		}
+	}
+	return values
+}
+
+// Set assigns a single value to key. The first existing assignment is
+// rewritten in place and any repeats are dropped; otherwise the key is added.
+func (s *Section) Set(key, value string) {
+	kept := s.Entries[:0]
+	found := false
+	for _, e := range s.Entries {
+		if e.Key != key {
+			kept = append(kept, e)
+			continue
+		}
+		if found {
+			continue
+		}
+		found = true
+		if e.Value != value {
+			e.Value = value
+			e.raw = nil
+		}
+		kept = append(kept, e)
+	}
+	s.Entries = kept
+	if !found {
+		s.Add(key, value)
+	}
+}
+
+// Add appends a new assignment for key directly after the last setting in
+// the section, so trailing comments and blank lines stay where they were.
+func (s *Section) Add(key, value string) {
+	e := &Entry{Key: key, Value: value}
+	idx := len(s.Entries)
+	for idx > 0 && !s.Entries[idx-1].IsSetting() {
+		idx--
+	}
+	s.Entries = append(s.Entries[:idx], append([]*Entry{e}, s.Entries[idx:]...)...)
+}
+
+// Remove deletes every assignment of key and returns how many were removed.
+func (s *Section) Remove(key string) int {
+	kept := s.Entries[:0]
+	removed := 0
+	for _, e := range s.Entries {
+		if e.Key == key {
+			removed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.Entries = kept
+	return removed
+}
@@ -0,0 +1,149 @@
+package quadlet
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const sampleContainer = `# Immich server
+[Unit]
+Description=Immich
+After=immich-db.service
+
+[Container]
+Image=ghcr.io/immich-app/immich-server:release
+PublishPort=2283:2283
+PublishPort=2284:2284
+Exec=start \
+  --verbose
+; trailing comment
+
+[Install]
+WantedBy=default.target
+`
+
+func TestParseRoundTrip(t *testing.T) {
+	inputs := []string{
+		sampleContainer,
+		"[Unit]\r\nDescription=crlf\r\n",
+		"[Container]\nImage=demo",
+		"",
+	}
+
+	for _, input := range inputs {
+		f, err := ParseBytes([]byte(input))
+		if err != nil {
+			t.Fatalf("ParseBytes() error = %v", err)
+		}
+		if got := string(f.Bytes()); got != input {
+			t.Fatalf("Bytes() = %q, want %q", got, input)
+		}
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	f, err := ParseBytes([]byte(sampleContainer))
+	if err != nil {
+		t.Fatalf("ParseBytes() error = %v", err)
+	}
+
+	if got := f.GetAll("Container", "PublishPort"); !slices.Equal(got, []string{"2283:2283", "2284:2284"}) {
+		t.Fatalf("GetAll(PublishPort) = %v", got)
+	}
+	if got, _ := f.Get("Container", "Exec"); got != "start --verbose" {
+		t.Fatalf("Get(Exec) = %q, want continuation joined", got)
+	}
+	if !f.HasSection("Install") {
+		t.Fatal("HasSection(Install) = false, want true")
+	}
+	if _, ok := f.Get("Container", "Missing"); ok {
+		t.Fatal("Get(Missing) ok = true, want false")
+	}
+}
+
+func TestParseContinuations(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"single line", "[Container]\nExec=start\n", "start"},
+		{"continued", "[Container]\nExec=start \\\n  --verbose\n", "start --verbose"},
+		{"comment inside", "[Container]\nExec=start \\\n# a comment\n  --verbose\n", "start --verbose"},
+		{"semicolon comment inside", "[Container]\nExec=start \\\n; a comment\n  --verbose \\\n  --debug\n", "start --verbose --debug"},
+		{"continued at end of file", "[Container]\nExec=start \\", "start"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseBytes([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("ParseBytes() error = %v", err)
+			}
+			if got, _ := f.Get("Container", "Exec"); got != tt.want {
+				t.Errorf("Get(Exec) = %q, want %q", got, tt.want)
+			}
+			if got := string(f.Bytes()); got != tt.input {
+				t.Errorf("Bytes() = %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidInput(t *testing.T) {
+	inputs := []string{
+		"Image=outside\n",
+		"[Container\nImage=demo\n",
+		"[Container]\nnot a setting\n",
+	}
+	for _, input := range inputs {
+		if _, err := ParseBytes([]byte(input)); err == nil {
+			t.Fatalf("ParseBytes(%q) error = nil, want non-nil", input)
+		}
+	}
+}
+
+func TestSectionSetAddRemove(t *testing.T) {
+	f, err := ParseBytes([]byte(sampleContainer))
+	if err != nil {
+		t.Fatalf("ParseBytes() error = %v", err)
+	}
+
+	container := f.Section("Container")
+	container.Set("PublishPort", "8080:80")
+	container.Add("Volume", "/srv/immich:/data:Z")
+	if n := container.Remove("Exec"); n != 1 {
+		t.Fatalf("Remove(Exec) = %d, want 1", n)
+	}
+
+	got := string(f.Bytes())
+	want := `[Container]
+Image=ghcr.io/immich-app/immich-server:release
+PublishPort=8080:80
+Volume=/srv/immich:/data:Z
+; trailing comment
+`
+	if !strings.Contains(got, want) {
+		t.Fatalf("Bytes() = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestAddAndRemoveSection(t *testing.T) {
+	f, err := ParseBytes([]byte("[Container]\nImage=demo"))
+	if err != nil {
+		t.Fatalf("ParseBytes() error = %v", err)
+	}
+
+	f.AddSection("Install").Set("WantedBy", "default.target")
+	want := "[Container]\nImage=demo\n\n[Install]\nWantedBy=default.target\n"
+	if got := string(f.Bytes()); got != want {
+		t.Fatalf("Bytes() after AddSection = %q, want %q", got, want)
+	}
+
+	if !f.RemoveSection("Install") {
+		t.Fatal("RemoveSection(Install) = false, want true")
+	}
+	if f.RemoveSection("Install") {
+		t.Fatal("RemoveSection(Install) twice = true, want false")
+	}
+}
@@ -0,0 +1,448 @@
+package quadlet
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Edge kinds, named after the key that creates the reference.
+const (
+	EdgeNetwork  = "network"
+	EdgeVolume   = "volume"
+	EdgePod      = "pod"
+	EdgeImage    = "image"
+	EdgeRequires = "requires"
+	EdgeWants    = "wants"
+	EdgeBindsTo  = "binds-to"
+	EdgeAfter    = "after"
+)
+
+// unitDependencyKeys maps [Unit] keys to the edge kind they create.
+var unitDependencyKeys = map[string]string{
+	"Requires":  EdgeRequires,
+	"Requisite": EdgeRequires,
+	"Wants":     EdgeWants,
+	"BindsTo":   EdgeBindsTo,
+	"After":     EdgeAfter,
+}
+
+// Edge is a reference from one quadlet file to another, by file name.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// Graph models the references between quadlet files.
+type Graph struct {
+	Units    map[string]*UnitFile // keyed by file name, e.g. "web.container"
+	Edges    []Edge               // references that resolve to a known unit
+	Dangling []Edge               // references to quadlet files that do not exist
+}
+
+// BuildGraph resolves Network=, Volume=, Mount=, Pod=, Image= and
+// [Unit] dependency references between the given units. References to
+// regular systemd units such as network-online.target are ignored.
+func BuildGraph(units []*UnitFile) *Graph {
+	g := &Graph{Units: make(map[string]*UnitFile, len(units))}
+	services := make(map[string]string, len(units)) // service name -> file name
+	for _, u := range units {
+		g.Units[u.Name()] = u
+		services[u.ServiceName()] = u.Name()
+	}
+
+	seen := make(map[Edge]bool)
+	add := func(from, to, kind string) {
+		if to == "" || to == from {
+			return
+		}
+		if name, ok := services[to]; ok {
+			to = name
+		}
+		if !isQuadletName(to) {
+			return
+		}
+		e := Edge{From: from, To: to, Kind: kind}
+		if seen[e] {
+			return
+		}
+		seen[e] = true
+		if _, ok := g.Units[to]; ok {
+			g.Edges = append(g.Edges, e)
+		} else {
+			g.Dangling = append(g.Dangling, e)
+		}
+	}
+
+	for _, u := range units {
+		if u.File == nil {
+			continue
+		}
+		name := u.Name()
+		main := MainSection(u.UnitType())
+
+		for _, value := range u.File.GetAll(main, "Network") {
+			ref, _, _ := strings.Cut(value, ":")
+			add(name, ref, EdgeNetwork)
+		}
+		for _, value := range u.File.GetAll(main, "Volume") {
+			ref, _, _ := strings.Cut(value, ":")
+			add(name, ref, EdgeVolume)
+		}
+		for _, value := range u.File.GetAll(main, "Mount") {
+			for field := range strings.SplitSeq(value, ",") {
+				key, ref, ok := strings.Cut(field, "=")
+				if ok && (key == "source" || key == "src") {
+					add(name, ref, EdgeVolume)
+				}
+			}
+		}
+		for _, value := range u.File.GetAll(main, "Pod") {
+			add(name, value, EdgePod)
+		}
+		for _, value := range u.File.GetAll(main, "Image") {
+			add(name, value, EdgeImage)
+		}
+		for key, kind := range unitDependencyKeys {
+			for _, value := range u.File.GetAll("Unit", key) {
+				for ref := range strings.FieldsSeq(value) {
+					add(name, ref, kind)
+				}
+			}
+		}
+	}
+
+	sortEdges := func(edges []Edge) {
+		slices.SortFunc(edges, func(a, b Edge) int {
+			if c := strings.Compare(a.From, b.From); c != 0 {
+				return c
+			}
+			if c := strings.Compare(a.To, b.To); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Kind, b.Kind)
+		})
+	}
+	sortEdges(g.Edges)
+	sortEdges(g.Dangling)
+	return g
+}
+
+// isQuadletName reports whether name has a quadlet file extension.
+func isQuadletName(name string) bool {
+	idx := strings.LastIndex(name, ".")
+	return idx > 0 && MainSection(name[idx+1:]) != ""
+}
+
+// Names returns every unit name in the graph, sorted.
+func (g *Graph) Names() []string {
+	names := make([]string, 0, len(g.Units))
+	for name := range g.Units {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Dependencies returns the direct dependencies of a unit, sorted and without
+// duplicates.
+func (g *Graph) Dependencies(name string) []string {
+	var deps []string
+	for _, e := range g.Edges {
+		if e.From == name && !slices.Contains(deps, e.To) {
+			deps = append(deps, e.To)
+		}
+	}
+	slices.Sort(deps)
+	return deps
+}
+
+// Dependents returns the units that directly depend on name, sorted.
+func (g *Graph) Dependents(name string) []string {
+	var dependents []string
+	for _, e := range g.Edges {
+		if e.To == name && !slices.Contains(dependents, e.From) {
+			dependents = append(dependents, e.From)
+		}
+	}
+	slices.Sort(dependents)
+	return dependents
+}
+
+// Closure returns names together with everything they depend on,
+// transitively, sorted.
+func (g *Graph) Closure(names []string) []string {
+	return g.closure(names, g.Dependencies)
+}
+
+// DependentClosure returns names together with everything that depends on
+// them, transitively, sorted.
+func (g *Graph) DependentClosure(names []string) []string {
+	return g.closure(names, g.Dependents)
+}
+
+func (g *Graph) closure(names []string, next func(string) []string) []string {
+	seen := make(map[string]bool)
+	var visit func(string)
+	visit = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		for _, n := range next(name) {
+			visit(n)
+		}
+	}
+	for _, name := range names {
+		visit(name)
+	}
+
+	closure := make([]string, 0, len(seen))
+	for name := range seen {
+		closure = append(closure, name)
+	}
+	slices.Sort(closure)
+	return closure
+}
+
+// Cycles returns every dependency cycle as a sorted list of unit names,
+// using Tarjan's strongly connected components algorithm.
+func (g *Graph) Cycles() [][]string {
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var connect func(string)
+	connect = func(v string) {
+		indices[v] = index
+		lowlink[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.Dependencies(v) {
+			if _, visited := indices[w]; !visited {
+				connect(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], indices[w])
+			}
+		}
+
+		if lowlink[v] != indices[v] {
+			return
+		}
+		var component []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		if len(component) > 1 {
+			slices.Sort(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, name := range g.Names() {
+		if _, visited := indices[name]; !visited {
+			connect(name)
+		}
+	}
+
+	slices.SortFunc(cycles, func(a, b []string) int {
+		return strings.Compare(a[0], b[0])
+	})
+	return cycles
+}
+
+// TopoSort orders names so that every unit comes after the units it depends
+// on. Only edges between units in names are considered; ties are broken by
+// name so the order is stable. It fails if names contain a dependency cycle.
+func (g *Graph) TopoSort(names []string) ([]string, error) {
+	pending := make(map[string]int, len(names)) // unit -> unsorted dependency count
+	for _, name := range names {
+		pending[name] = 0
+	}
+	for _, name := range names {
+		for _, dep := range g.Dependencies(name) {
+			if _, ok := pending[dep]; ok {
+				pending[name]++
+			}
+		}
+	}
+
+	var ready []string
+	for name, count := range pending {
+		if count == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order := make([]string, 0, len(names))
+	for len(ready) > 0 {
+		slices.Sort(ready)
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, dependent := range g.Dependents(name) {
+			if _, ok := pending[dependent]; !ok {
+				continue
+			}
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) != len(pending) {
+		var cyclic []string
+		for name, count := range pending {
+			if count > 0 {
+				cyclic = append(cyclic, name)
+			}
+		}
+		slices.Sort(cyclic)
+		return nil, fmt.Errorf("dependency cycle among %s", strings.Join(cyclic, ", "))
+	}
+	return order, nil
+}
+
+// Roots returns the units among names that no other unit in names depends on.
+// If every unit is part of a cycle, all of names are returned.
+func (g *Graph) Roots(names []string) []string {
+	var roots []string
+	for _, name := range names {
+		hasDependent := false
+		for _, dependent := range g.Dependents(name) {
+			if slices.Contains(names, dependent) {
+				hasDependent = true
+				break
+			}
+		}
+		if !hasDependent {
+			roots = append(roots, name)
+		}
+	}
+	if len(roots) == 0 {
+		return names
+	}
+	return roots
+}
+
+// edgeKinds returns the kinds of every edge from one unit to another, joined
+// for display.
+func (g *Graph) edgeKinds(from, to string) string {
+	var kinds []string
+	for _, e := range g.Edges {
+		if e.From == from && e.To == to {
+			kinds = append(kinds, e.Kind)
+		}
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// WriteTree renders the dependencies of each root as an indented tree.
+func (g *Graph) WriteTree(w io.Writer, roots []string) error {
+	var walk func(name, prefix string, path []string) error
+	walk = func(name, prefix string, path []string) error {
+		deps := g.Dependencies(name)
+		for i, dep := range deps {
+			branch, indent := "├── ", "│   "
+			if i == len(deps)-1 {
+				branch, indent = "└── ", "    "
+			}
+			suffix := ""
+			if slices.Contains(path, dep) {
+				suffix = " [cycle]"
+			}
+			if _, err := fmt.Fprintf(w, "%s%s%s (%s)%s\n", prefix, branch, dep, g.edgeKinds(name, dep), suffix); err != nil {
+				return err
+			}
+			if suffix != "" {
+				continue
+			}
+			if err := walk(dep, prefix+indent, append(path, dep)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range roots {
+		if _, err := fmt.Fprintln(w, root); err != nil {
+			return err
+		}
+		if err := walk(root, "", []string{root}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteDOT renders the graph restricted to names in Graphviz DOT format.
+// Dangling references are drawn as dashed red nodes.
+func (g *Graph) WriteDOT(w io.Writer, names []string) error {
+	var b strings.Builder
+	b.WriteString("digraph quadlet {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %q;\n", name)
+	}
+	for _, e := range g.Edges {
+		if slices.Contains(names, e.From) {
+			fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.From, e.To, e.Kind)
+		}
+	}
+	for _, e := range g.Dangling {
+		if slices.Contains(names, e.From) {
+			fmt.Fprintf(&b, "  %q [style=dashed, color=red];\n", e.To)
+			fmt.Fprintf(&b, "  %q -> %q [label=%q, style=dashed, color=red];\n", e.From, e.To, e.Kind)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid renders the graph restricted to names as a Mermaid flowchart.
+func (g *Graph) WriteMermaid(w io.Writer, names []string) error {
+	ids := make(map[string]string)
+	id := func(name string) string {
+		if existing, ok := ids[name]; ok {
+			return existing
+		}
+		ids[name] = fmt.Sprintf("n%d", len(ids))
+		return ids[name]
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id(name), name)
+	}
+	for _, e := range g.Edges {
+		if slices.Contains(names, e.From) {
+			fmt.Fprintf(&b, "  %s -->|%s| %s\n", id(e.From), e.Kind, id(e.To))
+		}
+	}
+	for _, e := range g.Dangling {
+		if slices.Contains(names, e.From) {
+			fmt.Fprintf(&b, "  %s[\"%s (missing)\"]\n", id(e.To), e.To)
+			fmt.Fprintf(&b, "  %s -.->|%s| %s\n", id(e.From), e.Kind, id(e.To))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
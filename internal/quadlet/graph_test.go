@@ -0,0 +1,95 @@
+package quadlet
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func buildTestGraph(t *testing.T) *Graph {
+	t.Helper()
+	dir := t.TempDir()
+	writeUnit(t, dir, "app/web.container", `[Unit]
+Requires=db.service
+After=db.service network-online.target
+
+[Container]
+Image=app.build
+Network=proxy.network:ip=10.0.0.2
+Volume=data.volume:/data
+Pod=missing.pod
+`)
+	writeUnit(t, dir, "app/db.container", "[Unit]\nAfter=web.container\n\n[Container]\nImage=postgres\nMount=type=volume,source=data.volume,destination=/var\n")
+	writeUnit(t, dir, "app/app.build", "[Build]\nImageTag=localhost/app\n")
+	writeUnit(t, dir, "app/data.volume", "[Volume]\n")
+	writeUnit(t, dir, "proxy/proxy.network", "[Network]\n")
+
+	units, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	return BuildGraph(units)
+}
+
+func TestBuildGraph(t *testing.T) {
+	g := buildTestGraph(t)
+
+	if got := g.Dependencies("web.container"); !slices.Equal(got, []string{"app.build", "data.volume", "db.container", "proxy.network"}) {
+		t.Fatalf("Dependencies(web) = %v", got)
+	}
+	if got := g.Dependencies("db.container"); !slices.Equal(got, []string{"data.volume", "web.container"}) {
+		t.Fatalf("Dependencies(db) = %v", got)
+	}
+	if got := g.Dependents("data.volume"); !slices.Equal(got, []string{"db.container", "web.container"}) {
+		t.Fatalf("Dependents(data) = %v", got)
+	}
+	if want := []Edge{{From: "web.container", To: "missing.pod", Kind: EdgePod}}; !slices.Equal(g.Dangling, want) {
+		t.Fatalf("Dangling = %v, want %v", g.Dangling, want)
+	}
+	if got := g.Cycles(); len(got) != 1 || !slices.Equal(got[0], []string{"db.container", "web.container"}) {
+		t.Fatalf("Cycles() = %v", got)
+	}
+	if got := g.Closure([]string{"db.container"}); len(got) != 5 {
+		t.Fatalf("Closure(db) = %v, want all 5 units", got)
+	}
+	if got := g.DependentClosure([]string{"proxy.network"}); !slices.Equal(got, []string{"db.container", "proxy.network", "web.container"}) {
+		t.Fatalf("DependentClosure(proxy) = %v", got)
+	}
+}
+
+func TestGraphWriteTree(t *testing.T) {
+	g := buildTestGraph(t)
+
+	var buf bytes.Buffer
+	if err := g.WriteTree(&buf, []string{"web.container"}); err != nil {
+		t.Fatalf("WriteTree() error = %v", err)
+	}
+	want := `web.container
+├── app.build (image)
+├── data.volume (volume)
+├── db.container (after, requires)
+│   ├── data.volume (volume)
+│   └── web.container (after) [cycle]
+└── proxy.network (network)
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("WriteTree() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGraphTopoSort(t *testing.T) {
+	g := buildTestGraph(t)
+
+	got, err := g.TopoSort([]string{"web.container", "app.build", "data.volume", "proxy.network"})
+	if err != nil {
+		t.Fatalf("TopoSort() error = %v", err)
+	}
+	want := []string{"app.build", "data.volume", "proxy.network", "web.container"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("TopoSort() = %v, want %v", got, want)
+	}
+
+	if _, err := g.TopoSort([]string{"web.container", "db.container"}); err == nil {
+		t.Fatal("TopoSort() with cycle error = nil, want non-nil")
+	}
+}
@@ -0,0 +1,44 @@
+package quadlet
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ContainerName returns the name of the container a .container file runs:
+// its ContainerName= value, or "systemd-<name>" as quadlet names it by
+// default. It returns "" for other unit types.
+func (u *UnitFile) ContainerName() string {
+	if u.UnitType() != "container" {
+		return ""
+	}
+	if u.File != nil {
+		if name, ok := u.File.Get("Container", "ContainerName"); ok && strings.TrimSpace(name) != "" {
+			r := strings.NewReplacer("%N", u.BaseName(), "%p", u.BaseName())
+			return r.Replace(strings.TrimSpace(name))
+		}
+	}
+	return "systemd-" + u.BaseName()
+}
+
+// HasHealthCheck reports whether a .container file sets HealthCmd=.
+func (u *UnitFile) HasHealthCheck() bool {
+	if u.File == nil || u.UnitType() != "container" {
+		return false
+	}
+	cmd, ok := u.File.Get("Container", "HealthCmd")
+	cmd = strings.TrimSpace(cmd)
+	return ok && cmd != "" && cmd != "none"
+}
+
+// HealthStatus returns podman's health status for a container: "starting",
+// "healthy" or "unhealthy", or "" if it has no health check.
+func HealthStatus(container string) (string, error) {
+	cmd := exec.Command("podman", "inspect", "--type", "container", "--format", "{{.State.Health.Status}}", container)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("inspecting container %s: %w: %s", container, err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
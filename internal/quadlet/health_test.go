@@ -0,0 +1,29 @@
+package quadlet
+
+import "testing"
+
+func TestContainerHealth(t *testing.T) {
+	tests := []struct {
+		path, content string
+		wantName      string
+		wantHealth    bool
+	}{
+		{"/c/web.container", "[Container]\nImage=nginx\n", "systemd-web", false},
+		{"/c/web.container", "[Container]\nContainerName=frontend\nHealthCmd=curl -f localhost\n", "frontend", true},
+		{"/c/web.container", "[Container]\nContainerName=%N-app\nHealthCmd=none\n", "web-app", false},
+		{"/c/data.volume", "[Volume]\n", "", false},
+	}
+	for _, tt := range tests {
+		file, err := ParseBytes([]byte(tt.content))
+		if err != nil {
+			t.Fatalf("ParseBytes() error = %v", err)
+		}
+		u := &UnitFile{Path: tt.path, File: file}
+		if got := u.ContainerName(); got != tt.wantName {
+			t.Errorf("ContainerName() for %q = %q, want %q", tt.content, got, tt.wantName)
+		}
+		if got := u.HasHealthCheck(); got != tt.wantHealth {
+			t.Errorf("HasHealthCheck() for %q = %v, want %v", tt.content, got, tt.wantHealth)
+		}
+	}
+}
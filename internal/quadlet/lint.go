@@ -0,0 +1,366 @@
+package quadlet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Severity is the level of a lint finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rule describes a lint check.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+}
+
+// Lint rule IDs. These are stable and intended for machine consumption.
+const (
+	RuleParseError        = "parse-error"
+	RuleUnknownSection    = "unknown-section"
+	RuleUnknownKey        = "unknown-key"
+	RuleDeprecatedKey     = "deprecated-key"
+	RuleMissingImage      = "missing-image"
+	RuleVolumePathMissing = "volume-path-missing"
+	RuleNetworkNotFound   = "network-not-found"
+	RuleDuplicateHostPort = "duplicate-host-port"
+	RuleEnvFileUnreadable = "env-file-unreadable"
+)
+
+// LintRules lists every rule Lint can report, in a stable order.
+var LintRules = []Rule{
+	{RuleParseError, SeverityError, "The file is not valid unit file syntax."},
+	{RuleUnknownSection, SeverityWarning, "The section is not used by quadlet for this file type."},
+	{RuleUnknownKey, SeverityWarning, "The key is not known to quadlet for this section."},
+	{RuleDeprecatedKey, SeverityWarning, "The key is deprecated and may be removed in a future podman release."},
+	{RuleMissingImage, SeverityError, "A container unit needs Image= or Rootfs=."},
+	{RuleVolumePathMissing, SeverityError, "A Volume= host path does not exist."},
+	{RuleNetworkNotFound, SeverityError, "A Network= reference points to a .network unit that does not exist."},
+	{RuleDuplicateHostPort, SeverityError, "The same host port is published by more than one unit."},
+	{RuleEnvFileUnreadable, SeverityError, "An EnvironmentFile= path cannot be read."},
+}
+
+// Finding is a single problem reported by Lint.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// systemdSections are sections passed through to the generated service.
+var systemdSections = []string{"Unit", "Service", "Install", "Quadlet"}
+
+// knownKeys lists the keys quadlet accepts in each type-specific section.
+var knownKeys = map[string][]string{
+	"Container": {
+		"AddCapability", "AddDevice", "AddHost", "Annotation", "AutoUpdate", "CgroupsMode",
+		"ContainerName", "ContainersConfModule", "DNS", "DNSOption", "DNSSearch", "DropCapability",
+		"Entrypoint", "Environment", "EnvironmentFile", "EnvironmentHost", "Exec", "ExposeHostPort",
+		"GIDMap", "GlobalArgs", "Group", "GroupAdd", "HealthCmd", "HealthInterval",
+		"HealthLogDestination", "HealthMaxLogCount", "HealthMaxLogSize", "HealthOnFailure",
+		"HealthRetries", "HealthStartPeriod", "HealthStartupCmd", "HealthStartupInterval",
+		"HealthStartupRetries", "HealthStartupSuccess", "HealthStartupTimeout", "HealthTimeout",
+		"HostName", "HttpProxy", "Image", "IP", "IP6", "Label", "LogDriver", "LogOpt", "Mask",
+		"Memory", "Mount", "Network", "NetworkAlias", "NoNewPrivileges", "Notify", "PidsLimit",
+		"Pod", "PodmanArgs", "PublishPort", "Pull", "ReadOnly", "ReadOnlyTmpfs", "ReloadCmd",
+		"ReloadSignal", "Retry", "RetryDelay", "Rootfs", "RunInit", "SeccompProfile", "Secret",
+		"SecurityLabelDisable", "SecurityLabelFileType", "SecurityLabelLevel", "SecurityLabelNested",
+		"SecurityLabelType", "ShmSize", "StartWithPod", "StopSignal", "StopTimeout", "SubGIDMap",
+		"SubUIDMap", "Sysctl", "Timezone", "Tmpfs", "UIDMap", "Ulimit", "Unmask", "User", "UserNS",
+		"Volume", "WorkingDir",
+	},
+	"Pod": {
+		"AddHost", "ContainersConfModule", "DNS", "DNSOption", "DNSSearch", "ExitPolicy", "GIDMap",
+		"GlobalArgs", "HostName", "IP", "IP6", "Label", "Network", "NetworkAlias", "PodName",
+		"PodmanArgs", "PublishPort", "ServiceName", "ShmSize", "StopTimeout", "SubGIDMap",
+		"SubUIDMap", "UIDMap", "UserNS", "Volume",
+	},
+	"Network": {
+		"ContainersConfModule", "DisableDNS", "DNS", "Driver", "Gateway", "GlobalArgs",
+		"InterfaceName", "Internal", "IPAMDriver", "IPRange", "IPv6", "Label",
+		"NetworkDeleteOnStop", "NetworkName", "Options", "PodmanArgs", "Subnet",
+	},
+	"Volume": {
+		"ContainersConfModule", "Copy", "Device", "Driver", "GlobalArgs", "Group", "Image",
+		"Label", "Options", "PodmanArgs", "Type", "User", "VolumeName",
+	},
+	"Kube": {
+		"AutoUpdate", "ConfigMap", "ContainersConfModule", "ExitCodePropagation", "GlobalArgs",
+		"KubeDownForce", "LogDriver", "Network", "PodmanArgs", "PublishPort",
+		"SetWorkingDirectory", "UserNS", "Yaml",
+	},
+	"Image": {
+		"AllTags", "Arch", "AuthFile", "CertDir", "ContainersConfModule", "Creds",
+		"DecryptionKey", "GlobalArgs", "Image", "ImageTag", "OS", "PodmanArgs", "Policy",
+		"Retry", "RetryDelay", "TLSVerify", "Variant",
+	},
+	"Build": {
+		"Annotation", "Arch", "AuthFile", "BuildArg", "ContainersConfModule", "DNS", "DNSOption",
+		"DNSSearch", "Environment", "File", "ForceRM", "GlobalArgs", "GroupAdd", "IgnoreFile",
+		"ImageTag", "Label", "Network", "PodmanArgs", "Pull", "Retry", "RetryDelay", "Secret",
+		"SetWorkingDirectory", "Target", "TLSVerify", "Variant", "Volume",
+	},
+	"Artifact": {
+		"Artifact", "AuthFile", "CertDir", "ContainersConfModule", "Creds", "DecryptionKey",
+		"GlobalArgs", "PodmanArgs", "Quiet", "Retry", "RetryDelay", "ServiceName", "TLSVerify",
+	},
+}
+
+// deprecatedKeys maps deprecated keys to a replacement hint.
+var deprecatedKeys = map[string]map[string]string{
+	"Container": {
+		"RemapUid":     "use UserNS= instead",
+		"RemapGid":     "use UserNS= instead",
+		"RemapUidSize": "use UserNS= instead",
+		"RemapUsers":   "use UserNS= instead",
+	},
+	"Kube": {
+		"RemapUid":     "use UserNS= instead",
+		"RemapGid":     "use UserNS= instead",
+		"RemapUidSize": "use UserNS= instead",
+		"RemapUsers":   "use UserNS= instead",
+	},
+}
+
+// Lint checks quadlet files without invoking podman or systemd. Cross-unit
+// rules such as network references and duplicate host ports consider every
+// unit passed in, so callers should pass the full set and filter the findings.
+func Lint(units []*UnitFile) []Finding {
+	var findings []Finding
+	report := func(rule string, unit *UnitFile, line int, format string, args ...any) {
+		findings = append(findings, Finding{
+			Rule:     rule,
+			Severity: ruleSeverity(rule),
+			Path:     unit.Path,
+			Line:     line,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	names := make(map[string]bool, len(units))
+	for _, unit := range units {
+		names[unit.Name()] = true
+	}
+
+	type portOwner struct {
+		unit *UnitFile
+		line int
+		ip   string
+	}
+	ports := make(map[string][]portOwner) // "port/proto" -> publishers
+
+	for _, unit := range units {
+		if unit.Err != nil {
+			report(RuleParseError, unit, 0, "%v", unit.Err)
+			continue
+		}
+
+		unitType := unit.UnitType()
+		main := MainSection(unitType)
+		dir := filepath.Dir(unit.Path)
+
+		for _, section := range unit.File.Sections {
+			if section.Name != main && !slices.Contains(systemdSections, section.Name) && !strings.HasPrefix(section.Name, "X-") {
+				report(RuleUnknownSection, unit, section.Line, "section [%s] is not used in .%s files", section.Name, unitType)
+				continue
+			}
+			if section.Name != main {
+				continue
+			}
+
+			for _, e := range section.Settings() {
+				if hint, ok := deprecatedKeys[main][e.Key]; ok {
+					report(RuleDeprecatedKey, unit, e.Line, "%s= is deprecated; %s", e.Key, hint)
+					continue
+				}
+				if !slices.Contains(knownKeys[main], e.Key) && !strings.HasPrefix(e.Key, "X-") {
+					report(RuleUnknownKey, unit, e.Line, "unknown key %s= in [%s]", e.Key, main)
+				}
+			}
+		}
+
+		section := unit.File.Section(main)
+		if section == nil {
+			if unitType == "container" {
+				report(RuleMissingImage, unit, 0, "missing [Container] section with Image=")
+			}
+			continue
+		}
+
+		if unitType == "container" {
+			_, hasImage := unit.File.Get(main, "Image")
+			_, hasRootfs := unit.File.Get(main, "Rootfs")
+			if !hasImage && !hasRootfs {
+				report(RuleMissingImage, unit, section.Line, "container has neither Image= nor Rootfs=")
+			}
+		}
+
+		for _, e := range section.Settings() {
+			switch e.Key {
+			case "Volume":
+				source, _, _ := strings.Cut(e.Value, ":")
+				if !isHostPath(source) {
+					continue
+				}
+				path, ok := resolveUnitPath(dir, source)
+				if !ok {
+					continue
+				}
+				if _, err := os.Stat(path); err != nil {
+					report(RuleVolumePathMissing, unit, e.Line, "volume host path %s does not exist", path)
+				}
+
+			case "Network":
+				name, _, _ := strings.Cut(e.Value, ":")
+				if strings.HasSuffix(name, ".network") && !names[name] {
+					report(RuleNetworkNotFound, unit, e.Line, "network unit %s not found", name)
+				}
+
+			case "EnvironmentFile":
+				path, ok := resolveUnitPath(dir, e.Value)
+				if !ok {
+					continue
+				}
+				f, err := os.Open(path)
+				if err != nil {
+					report(RuleEnvFileUnreadable, unit, e.Line, "environment file %s cannot be read: %v", path, err)
+					continue
+				}
+				_ = f.Close()
+
+			case "PublishPort":
+				ip, hostPorts, proto := parsePublishPort(e.Value)
+				for _, port := range hostPorts {
+					key := port + "/" + proto
+					for _, owner := range ports[key] {
+						if owner.ip != "" && ip != "" && owner.ip != ip {
+							continue
+						}
+						other := owner.unit.Name()
+						if owner.unit == unit {
+							other = fmt.Sprintf("line %d", owner.line)
+						}
+						report(RuleDuplicateHostPort, unit, e.Line, "host port %s is already published by %s", key, other)
+						break
+					}
+					ports[key] = append(ports[key], portOwner{unit: unit, line: e.Line, ip: ip})
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return a.Line - b.Line
+	})
+	return findings
+}
+
+// HasErrors reports whether any finding has error severity.
+func HasErrors(findings []Finding) bool {
+	return slices.ContainsFunc(findings, func(f Finding) bool {
+		return f.Severity == SeverityError
+	})
+}
+
+func ruleSeverity(id string) Severity {
+	for _, rule := range LintRules {
+		if rule.ID == id {
+			return rule.Severity
+		}
+	}
+	return SeverityWarning
+}
+
+// isHostPath reports whether a Volume= source is a host path rather than a
+// named volume or a .volume reference.
+func isHostPath(source string) bool {
+	return strings.HasPrefix(source, "/") || strings.HasPrefix(source, "./") ||
+		strings.HasPrefix(source, "../") || strings.HasPrefix(source, "%h")
+}
+
+// resolveUnitPath expands %h and makes relative paths relative to the unit
+// file's directory, as quadlet does. It returns false for paths with other
+// specifiers, which can only be resolved by systemd.
+func resolveUnitPath(dir, path string) (string, bool) {
+	if strings.Contains(path, "%h") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		path = strings.ReplaceAll(path, "%h", home)
+	}
+	if strings.Contains(path, "%") {
+		return "", false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path, true
+}
+
+// parsePublishPort splits a PublishPort= value of the form
+// [[ip:][hostPort]:]containerPort[/protocol] and returns the host IP, the
+// expanded host ports and the protocol. Values without a host port publish on
+// a random port and return no host ports.
+func parsePublishPort(value string) (string, []string, string) {
+	proto := "tcp"
+	if spec, p, ok := strings.Cut(value, "/"); ok {
+		value, proto = spec, p
+	}
+
+	var ip string
+	if strings.HasPrefix(value, "[") {
+		end := strings.Index(value, "]:")
+		if end < 0 {
+			return "", nil, proto
+		}
+		ip, value = value[1:end], value[end+2:]
+	}
+
+	parts := strings.Split(value, ":")
+	var host string
+	switch len(parts) {
+	case 2:
+		host = parts[0]
+	case 3:
+		ip, host = parts[0], parts[1]
+	default:
+		return "", nil, proto
+	}
+	if ip == "0.0.0.0" || ip == "::" {
+		ip = ""
+	}
+	if host == "" {
+		return ip, nil, proto
+	}
+
+	start, end, isRange := strings.Cut(host, "-")
+	if !isRange {
+		return ip, []string{host}, proto
+	}
+	lo, err1 := strconv.Atoi(start)
+	hi, err2 := strconv.Atoi(end)
+	if err1 != nil || err2 != nil || hi < lo {
+		return ip, []string{host}, proto
+	}
+	hostPorts := make([]string, 0, hi-lo+1)
+	for p := lo; p <= hi; p++ {
+		hostPorts = append(hostPorts, strconv.Itoa(p))
+	}
+	return ip, hostPorts, proto
+}
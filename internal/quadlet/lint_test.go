@@ -0,0 +1,142 @@
+package quadlet
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeUnit(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func findingRules(findings []Finding) []string {
+	rules := make([]string, 0, len(findings))
+	for _, f := range findings {
+		rules = append(rules, f.Rule)
+	}
+	return rules
+}
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	writeUnit(t, dir, "web/web.container", `[Container]
+Image=nginx
+Network=proxy.network
+Network=missing.network
+Volume=./data:/data
+Volume=./missing:/missing
+Volume=named:/named
+EnvironmentFile=.env
+EnvironmentFile=missing.env
+PublishPort=8080:80
+RemapUid=1000
+Bogus=1
+X-Custom=1
+
+[Foo]
+`)
+	writeUnit(t, dir, "web/.env", "KEY=value\n")
+	if err := os.Mkdir(filepath.Join(dir, "web", "data"), 0755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	writeUnit(t, dir, "proxy/proxy.network", "[Network]\n")
+	writeUnit(t, dir, "db/db.container", "[Container]\nPublishPort=0.0.0.0:8080:5432\nPublishPort=127.0.0.1::5433\n")
+	writeUnit(t, dir, "broken/broken.container", "Image=outside\n")
+
+	units, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if len(units) != 4 {
+		t.Fatalf("Discover() found %d units, want 4", len(units))
+	}
+
+	findings := Lint(units)
+	got := findingRules(findings)
+	want := []string{
+		RuleParseError,
+		RuleMissingImage,
+		RuleNetworkNotFound,
+		RuleVolumePathMissing,
+		RuleEnvFileUnreadable,
+		RuleDuplicateHostPort,
+		RuleDeprecatedKey,
+		RuleUnknownKey,
+		RuleUnknownSection,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Lint() rules = %v, want %v", got, want)
+	}
+	if !HasErrors(findings) {
+		t.Fatal("HasErrors() = false, want true")
+	}
+}
+
+func TestParsePublishPort(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantIP    string
+		wantPorts []string
+		wantProto string
+	}{
+		{value: "80", wantProto: "tcp"},
+		{value: "8080:80", wantPorts: []string{"8080"}, wantProto: "tcp"},
+		{value: "127.0.0.1:8080:80/udp", wantIP: "127.0.0.1", wantPorts: []string{"8080"}, wantProto: "udp"},
+		{value: "127.0.0.1::80", wantIP: "127.0.0.1", wantProto: "tcp"},
+		{value: "[::1]:53:53/udp", wantIP: "::1", wantPorts: []string{"53"}, wantProto: "udp"},
+		{value: "9000-9002:9000-9002", wantPorts: []string{"9000", "9001", "9002"}, wantProto: "tcp"},
+	}
+
+	for _, tt := range tests {
+		ip, ports, proto := parsePublishPort(tt.value)
+		if ip != tt.wantIP || !slices.Equal(ports, tt.wantPorts) || proto != tt.wantProto {
+			t.Fatalf("parsePublishPort(%q) = %q, %v, %q", tt.value, ip, ports, proto)
+		}
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteSARIF(&buf, "/x", []Finding{
+		{Rule: RuleMissingImage, Severity: SeverityError, Path: "/x/web app/a.container", Line: 3, Message: "missing"},
+		{Rule: RuleEnvFileUnreadable, Severity: SeverityError, Path: "/elsewhere/b.env", Message: "missing"},
+	})
+	if err != nil {
+		t.Fatalf("WriteSARIF() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("WriteSARIF() produced invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 2 {
+		t.Fatalf("WriteSARIF() = %s", buf.String())
+	}
+	if got := log.Runs[0].OriginalURIBaseIDs[sarifRootID].URI; got != "file:///x/" {
+		t.Errorf("originalUriBaseIds = %q, want file:///x/", got)
+	}
+	result := log.Runs[0].Results[0]
+	if result.RuleID != RuleMissingImage || result.Locations[0].PhysicalLocation.Region.StartLine != 3 {
+		t.Fatalf("WriteSARIF() result = %+v", result)
+	}
+	want := sarifArtifactLocation{URI: "web%20app/a.container", URIBaseID: sarifRootID}
+	if got := result.Locations[0].PhysicalLocation.ArtifactLocation; got != want {
+		t.Errorf("artifactLocation = %+v, want %+v", got, want)
+	}
+	// Files outside the containers directory get an absolute URI.
+	want = sarifArtifactLocation{URI: "file:///elsewhere/b.env"}
+	if got := log.Runs[0].Results[1].Locations[0].PhysicalLocation.ArtifactLocation; got != want {
+		t.Errorf("artifactLocation = %+v, want %+v", got, want)
+	}
+}
@@ -21,6 +21,18 @@ func (u Unit) IsActive() bool {
 	return strings.HasPrefix(u.Status, "active/")
 }
 
+// ActiveState returns the systemd active state from Status, e.g. "active".
+func (u Unit) ActiveState() string {
+	state, _, _ := strings.Cut(u.Status, "/")
+	return state
+}
+
+// SubState returns the systemd sub-state from Status, e.g. "running".
+func (u Unit) SubState() string {
+	_, sub, _ := strings.Cut(u.Status, "/")
+	return sub
+}
+
 // UnitType returns the quadlet file type derived from the Name extension,
 // e.g. "container", "network", "volume".
 func (u Unit) UnitType() string {
@@ -38,6 +50,36 @@ func (u Unit) BaseName() string {
 	return u.Name
 }
 
+// UnitStatus is the machine-readable state of a quadlet unit, as printed by
+// the listing and status commands with --output json or yaml.
+type UnitStatus struct {
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type" yaml:"type"`
+	Path        string `json:"path" yaml:"path"`
+	Service     string `json:"service" yaml:"service"`
+	Enabled     bool   `json:"enabled" yaml:"enabled"` // has an [Install] section
+	ActiveState string `json:"active_state" yaml:"active_state"`
+	SubState    string `json:"sub_state" yaml:"sub_state"`
+}
+
+// Info returns the unit's status. Enabled is read from the quadlet file and is
+// false if it cannot be parsed.
+func (u Unit) Info() UnitStatus {
+	enabled := false
+	if file, err := ParseFile(u.Path); err == nil {
+		enabled = file.HasSection("Install")
+	}
+	return UnitStatus{
+		Name:        u.BaseName(),
+		Type:        u.UnitType(),
+		Path:        u.Path,
+		Service:     u.UnitName,
+		Enabled:     enabled,
+		ActiveState: u.ActiveState(),
+		SubState:    u.SubState(),
+	}
+}
+
 // List returns all quadlet units reported by "podman quadlet list --format json".
 // If pathPrefix is non-empty, only units whose Path starts with that prefix are returned.
 func List(pathPrefix string) ([]Unit, error) {
@@ -65,3 +107,14 @@ func List(pathPrefix string) ([]Unit, error) {
 	}
 	return filtered, nil
 }
+
+// ServiceName returns the systemd service that quadlet generates for a unit
+// file, e.g. "web.service" for a container or "data-volume.service" for a volume.
+func ServiceName(baseName, unitType string) string {
+	switch unitType {
+	case "container", "kube":
+		return baseName + ".service"
+	default:
+		return baseName + "-" + unitType + ".service"
+	}
+}
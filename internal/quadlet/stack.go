@@ -0,0 +1,73 @@
+package quadlet
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// StackKey is the [Unit] key that assigns a quadlet file to a stack
+// explicitly, overriding the directory it lives in.
+const StackKey = "X-Qh-Stack"
+
+// Stack is a named group of quadlet files that are managed together.
+type Stack struct {
+	Name  string
+	Units []*UnitFile
+}
+
+// Services returns the systemd services generated for the stack's units.
+func (s Stack) Services() []string {
+	services := make([]string, len(s.Units))
+	for i, u := range s.Units {
+		services[i] = u.ServiceName()
+	}
+	return services
+}
+
+// StackName returns the stack a unit belongs to: the X-Qh-Stack= value if
+// set, otherwise the first directory below root. Files reached through a
+// symlinked directory outside root use the name of their own directory.
+// Units placed directly in root without the key belong to no stack.
+func StackName(root string, u *UnitFile) string {
+	if u.File != nil {
+		if name, ok := u.File.Get("Unit", StackKey); ok && strings.TrimSpace(name) != "" {
+			return strings.TrimSpace(name)
+		}
+	}
+
+	rel, err := filepath.Rel(root, u.Path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Base(filepath.Dir(u.Path))
+	}
+	dir, _, found := strings.Cut(filepath.ToSlash(rel), "/")
+	if !found {
+		return ""
+	}
+	return dir
+}
+
+// Stacks groups units by StackName, sorted by stack name. Units that belong to
+// no stack are left out.
+func Stacks(root string, units []*UnitFile) []Stack {
+	index := make(map[string]int)
+	var stacks []Stack
+	for _, u := range units {
+		name := StackName(root, u)
+		if name == "" {
+			continue
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(stacks)
+			index[name] = i
+			stacks = append(stacks, Stack{Name: name})
+		}
+		stacks[i].Units = append(stacks[i].Units, u)
+	}
+
+	slices.SortFunc(stacks, func(a, b Stack) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return stacks
+}
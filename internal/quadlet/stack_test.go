@@ -0,0 +1,68 @@
+package quadlet
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStacks(t *testing.T) {
+	dir := t.TempDir()
+	writeUnit(t, dir, "media/jellyfin.container", "[Container]\nImage=jellyfin\n")
+	writeUnit(t, dir, "media/media.network", "[Network]\n")
+	writeUnit(t, dir, "media/sub/sonarr.container", "[Container]\nImage=sonarr\n")
+	writeUnit(t, dir, "proxy/traefik.container", "[Unit]\nX-Qh-Stack=media\n\n[Container]\nImage=traefik\n")
+	writeUnit(t, dir, "proxy/proxy.network", "[Network]\n")
+	writeUnit(t, dir, "loose.container", "[Container]\nImage=loose\n")
+	writeUnit(t, dir, "tagged.container", "[Unit]\nX-Qh-Stack=tools\n\n[Container]\nImage=tagged\n")
+
+	outside := t.TempDir()
+	writeUnit(t, outside, "linked/linked.container", "[Container]\nImage=linked\n")
+	if err := os.Symlink(filepath.Join(outside, "linked"), filepath.Join(dir, "linked")); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+
+	units, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	got := make(map[string][]string)
+	var names []string
+	for _, s := range Stacks(dir, units) {
+		names = append(names, s.Name)
+		for _, u := range s.Units {
+			got[s.Name] = append(got[s.Name], u.Name())
+		}
+	}
+
+	wantNames := []string{"linked", "media", "proxy", "tools"}
+	if !slices.Equal(names, wantNames) {
+		t.Fatalf("Stacks() names = %v, want %v", names, wantNames)
+	}
+
+	want := map[string][]string{
+		"linked": {"linked.container"},
+		"media":  {"jellyfin.container", "media.network", "sonarr.container", "traefik.container"},
+		"proxy":  {"proxy.network"},
+		"tools":  {"tagged.container"},
+	}
+	for name, units := range want {
+		if !slices.Equal(got[name], units) {
+			t.Errorf("stack %q units = %v, want %v", name, got[name], units)
+		}
+	}
+}
+
+func TestStackServices(t *testing.T) {
+	s := Stack{Name: "app", Units: []*UnitFile{
+		{Path: "/c/app/web.container"},
+		{Path: "/c/app/app.network"},
+		{Path: "/c/app/data.volume"},
+	}}
+	want := []string{"web.service", "app-network.service", "data-volume.service"}
+	if got := s.Services(); !slices.Equal(got, want) {
+		t.Errorf("Services() = %v, want %v", got, want)
+	}
+}
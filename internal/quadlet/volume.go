@@ -0,0 +1,19 @@
+package quadlet
+
+import "strings"
+
+// VolumeName returns the name of the podman volume a .volume file creates:
+// its VolumeName= value, or "systemd-<name>" as quadlet names it by default.
+// It returns "" for other unit types.
+func (u *UnitFile) VolumeName() string {
+	if u.UnitType() != "volume" {
+		return ""
+	}
+	if u.File != nil {
+		if name, ok := u.File.Get("Volume", "VolumeName"); ok && strings.TrimSpace(name) != "" {
+			r := strings.NewReplacer("%N", u.BaseName(), "%p", u.BaseName())
+			return r.Replace(strings.TrimSpace(name))
+		}
+	}
+	return "systemd-" + u.BaseName()
+}
@@ -0,0 +1,25 @@
+package quadlet
+
+import "testing"
+
+func TestVolumeName(t *testing.T) {
+	tests := []struct {
+		path, content string
+		want          string
+	}{
+		{"/c/data.volume", "[Volume]\n", "systemd-data"},
+		{"/c/data.volume", "[Volume]\nVolumeName=immich-data\n", "immich-data"},
+		{"/c/data.volume", "[Volume]\nVolumeName=%N-v\n", "data-v"},
+		{"/c/web.container", "[Container]\nImage=nginx\n", ""},
+	}
+	for _, tt := range tests {
+		file, err := ParseBytes([]byte(tt.content))
+		if err != nil {
+			t.Fatalf("ParseBytes() error = %v", err)
+		}
+		u := &UnitFile{Path: tt.path, File: file}
+		if got := u.VolumeName(); got != tt.want {
+			t.Errorf("VolumeName() for %q = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
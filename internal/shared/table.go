@@ -0,0 +1,61 @@
+package shared
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// PrintTable prints rows as a box-drawn table with styled headers.
+func PrintTable(headers []string, rows [][]string) {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				if n := utf8.RuneCountInString(cell); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+	}
+
+	// border wraps each box-drawing character in grey; cell text is left unstyled.
+	border := func(s string) string {
+		if os.Getenv("NO_COLOR") != "" {
+			return s
+		}
+		return "\x1b[38;5;238m" + s + "\x1b[0m"
+	}
+
+	hline := func(left, mid, right string) {
+		segs := make([]string, len(widths))
+		for i, w := range widths {
+			segs[i] = strings.Repeat("─", w+2)
+		}
+		fmt.Println(border(left + strings.Join(segs, mid) + right))
+	}
+
+	printRow := func(cells []string, isHeader bool) {
+		var b strings.Builder
+		for i, cell := range cells {
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			if isHeader {
+				cell = TitleStyle.Render(cell)
+			}
+			b.WriteString(border("│") + " " + cell + pad + " ")
+		}
+		fmt.Println(b.String() + border("│"))
+	}
+
+	hline("╭", "┬", "╮")
+	printRow(headers, true)
+	hline("├", "┼", "┤")
+	for _, row := range rows {
+		printRow(row, false)
+	}
+	hline("╰", "┴", "╯")
+}
@@ -0,0 +1,236 @@
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	dbusDestination  = "org.freedesktop.systemd1"
+	dbusManagerPath  = dbus.ObjectPath("/org/freedesktop/systemd1")
+	dbusManagerIface = "org.freedesktop.systemd1.Manager"
+	dbusUnitIface    = "org.freedesktop.systemd1.Unit"
+	dbusPropsGetAll  = "org.freedesktop.DBus.Properties.GetAll"
+)
+
+// unitInterfaces maps unit suffixes to the D-Bus interface carrying their
+// type-specific properties, such as a service's Result or a timer's
+// NextElapseUSecRealtime.
+var unitInterfaces = map[string]string{
+	"service":   "org.freedesktop.systemd1.Service",
+	"socket":    "org.freedesktop.systemd1.Socket",
+	"timer":     "org.freedesktop.systemd1.Timer",
+	"path":      "org.freedesktop.systemd1.Path",
+	"mount":     "org.freedesktop.systemd1.Mount",
+	"automount": "org.freedesktop.systemd1.Automount",
+	"swap":      "org.freedesktop.systemd1.Swap",
+	"slice":     "org.freedesktop.systemd1.Slice",
+	"scope":     "org.freedesktop.systemd1.Scope",
+}
+
+// busConn is the part of *dbus.Conn the D-Bus backend uses, so tests can run
+// against a stub bus.
+type busConn interface {
+	Object(dest string, path dbus.ObjectPath) dbus.BusObject
+	AddMatchSignal(options ...dbus.MatchOption) error
+	Signal(ch chan<- *dbus.Signal)
+	RemoveSignal(ch chan<- *dbus.Signal)
+	Close() error
+}
+
+// connectBus opens a private connection to the session bus without
+// autolaunching one.
+var connectBus = func() (busConn, error) {
+	conn, err := dbus.SessionBusPrivateNoAutoStartup()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Auth(nil); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := conn.Hello(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+var (
+	managerOnce sync.Once
+	manager     *dbusManager
+)
+
+// dbusBackend returns the D-Bus connection to the user manager, or nil if it
+// is unavailable and callers should fall back to systemctl. Setting
+// QH_SYSTEMD_BACKEND=exec forces the fallback.
+func dbusBackend() *dbusManager {
+	managerOnce.Do(func() {
+		if os.Getenv("QH_SYSTEMD_BACKEND") == "exec" {
+			return
+		}
+		manager, _ = newDBusManager()
+	})
+	return manager
+}
+
+// dbusManager talks to the systemd user manager over D-Bus.
+type dbusManager struct {
+	conn busConn
+	obj  dbus.BusObject
+}
+
+// newDBusManager connects to the session bus and subscribes to job signals,
+// which also checks that a user manager is listening.
+func newDBusManager() (*dbusManager, error) {
+	conn, err := connectBus()
+	if err != nil {
+		return nil, fmt.Errorf("connecting to session bus: %w", err)
+	}
+	m := &dbusManager{conn: conn, obj: conn.Object(dbusDestination, dbusManagerPath)}
+
+	if err := m.obj.Call(dbusManagerIface+".Subscribe", 0).Err; err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("subscribing to systemd: %w", err)
+	}
+	err = conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(dbusManagerPath),
+		dbus.WithMatchInterface(dbusManagerIface),
+		dbus.WithMatchMember("JobRemoved"),
+	)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("watching systemd jobs: %w", err)
+	}
+	return m, nil
+}
+
+// reload is the D-Bus equivalent of "systemctl --user daemon-reload".
+func (m *dbusManager) reload() error {
+	if err := m.obj.Call(dbusManagerIface+".Reload", 0).Err; err != nil {
+		return fmt.Errorf("error reloading systemd: %w", err)
+	}
+	return nil
+}
+
+// runJobs queues method (StartUnit, StopUnit or RestartUnit) for every unit
+// and waits until all of the jobs have finished, like systemctl does.
+func (m *dbusManager) runJobs(method string, units []string) error {
+	signals := make(chan *dbus.Signal, 16)
+	m.conn.Signal(signals)
+	defer m.conn.RemoveSignal(signals)
+
+	var errs []error
+	pending := make(map[dbus.ObjectPath]string, len(units))
+	for _, unit := range units {
+		var job dbus.ObjectPath
+		if err := m.obj.Call(dbusManagerIface+"."+method, 0, unit, "replace").Store(&job); err != nil {
+			errs = append(errs, fmt.Errorf("error running %s %s: %w", method, unit, err))
+			continue
+		}
+		pending[job] = unit
+	}
+
+	for len(pending) > 0 {
+		signal, ok := <-signals
+		if !ok {
+			errs = append(errs, errors.New("D-Bus connection closed while waiting for systemd jobs"))
+			break
+		}
+		if signal.Name != dbusManagerIface+".JobRemoved" || len(signal.Body) < 4 {
+			continue
+		}
+		job, _ := signal.Body[1].(dbus.ObjectPath)
+		result, _ := signal.Body[3].(string)
+		unit, ok := pending[job]
+		if !ok {
+			continue
+		}
+		delete(pending, job)
+		if result != "done" {
+			errs = append(errs, fmt.Errorf("error running %s %s: job %s", method, unit, result))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// properties returns the named properties of a unit, formatted the way
+// ShowProperties reports them. Units that are not loaded are loaded first, so
+// unknown units report LoadState=not-found like systemctl show does.
+func (m *dbusManager) properties(unit string, names []string) (map[string]string, error) {
+	var path dbus.ObjectPath
+	if err := m.obj.Call(dbusManagerIface+".LoadUnit", 0, unit).Store(&path); err != nil {
+		return nil, fmt.Errorf("error loading %s: %w", unit, err)
+	}
+	obj := m.conn.Object(dbusDestination, path)
+
+	all := make(map[string]dbus.Variant)
+	ifaces := []string{dbusUnitIface}
+	if idx := strings.LastIndex(unit, "."); idx >= 0 {
+		if iface, ok := unitInterfaces[unit[idx+1:]]; ok {
+			ifaces = append(ifaces, iface)
+		}
+	}
+	for _, iface := range ifaces {
+		var props map[string]dbus.Variant
+		if err := obj.Call(dbusPropsGetAll, 0, iface).Store(&props); err != nil {
+			return nil, fmt.Errorf("error reading properties of %s: %w", unit, err)
+		}
+		maps.Copy(all, props)
+	}
+
+	result := make(map[string]string, len(names))
+	for _, name := range names {
+		if value, ok := all[name]; ok {
+			result[name] = formatProperty(name, value)
+		}
+	}
+	return result, nil
+}
+
+// isTimestampProperty reports whether a uint64 property holds a wall-clock
+// time in microseconds rather than a duration or monotonic time.
+func isTimestampProperty(name string) bool {
+	return strings.HasSuffix(name, "Timestamp") || strings.HasSuffix(name, "USecRealtime") || name == "LastTriggerUSec"
+}
+
+// formatProperty renders a property value the way "systemctl show
+// --timestamp=unix" prints it.
+func formatProperty(name string, value dbus.Variant) string {
+	switch v := value.Value().(type) {
+	case string:
+		return v
+	case dbus.ObjectPath:
+		return string(v)
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case uint64:
+		if isTimestampProperty(name) {
+			if v == 0 || v == ^uint64(0) {
+				return "n/a"
+			}
+			return "@" + strconv.FormatUint(v/1_000_000, 10)
+		}
+		return strconv.FormatUint(v, 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []string:
+		return strings.Join(v, " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,279 @@
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// stubUnit is a unit known to the stub bus.
+type stubUnit struct {
+	props     map[string]map[string]any // interface -> property -> value
+	jobResult string                    // result reported in JobRemoved; "done" if empty
+}
+
+// stubBus imitates the systemd user manager on the session bus.
+type stubBus struct {
+	mu        sync.Mutex
+	units     map[string]stubUnit
+	calls     []string
+	signals   []chan<- *dbus.Signal
+	jobs      int
+	subscribe error
+	closed    bool
+}
+
+func (b *stubBus) Object(dest string, path dbus.ObjectPath) dbus.BusObject {
+	return &stubObject{bus: b, path: path}
+}
+
+func (b *stubBus) AddMatchSignal(options ...dbus.MatchOption) error { return nil }
+
+func (b *stubBus) Signal(ch chan<- *dbus.Signal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.signals = append(b.signals, ch)
+}
+
+func (b *stubBus) RemoveSignal(ch chan<- *dbus.Signal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.signals = slices.DeleteFunc(b.signals, func(c chan<- *dbus.Signal) bool { return c == ch })
+}
+
+func (b *stubBus) Close() error {
+	b.closed = true
+	return nil
+}
+
+func unitPath(unit string) dbus.ObjectPath {
+	return dbus.ObjectPath("/org/freedesktop/systemd1/unit/" + strings.NewReplacer(".", "_2e", "-", "_2d", "@", "_40").Replace(unit))
+}
+
+// call handles a method call on the manager or on a unit object.
+func (b *stubBus) call(path dbus.ObjectPath, method string, args []any) ([]any, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	call := strings.TrimPrefix(method, dbusManagerIface+".")
+	if len(args) > 0 {
+		call += fmt.Sprint(" ", args)
+	}
+	b.calls = append(b.calls, call)
+
+	if path != dbusManagerPath {
+		if method != dbusPropsGetAll {
+			return nil, fmt.Errorf("unexpected method %s on %s", method, path)
+		}
+		iface := args[0].(string)
+		for name, u := range b.units {
+			if unitPath(name) == path {
+				props := make(map[string]dbus.Variant)
+				for key, value := range u.props[iface] {
+					props[key] = dbus.MakeVariant(value)
+				}
+				return []any{props}, nil
+			}
+		}
+		if iface == dbusUnitIface {
+			return []any{map[string]dbus.Variant{
+				"LoadState":   dbus.MakeVariant("not-found"),
+				"ActiveState": dbus.MakeVariant("inactive"),
+			}}, nil
+		}
+		return []any{map[string]dbus.Variant{}}, nil
+	}
+
+	switch strings.TrimPrefix(method, dbusManagerIface+".") {
+	case "Subscribe":
+		return nil, b.subscribe
+	case "Reload":
+		return nil, nil
+	case "LoadUnit":
+		return []any{unitPath(args[0].(string))}, nil
+	case "StartUnit", "StopUnit", "RestartUnit":
+		unit := args[0].(string)
+		u, ok := b.units[unit]
+		if !ok {
+			return nil, dbus.Error{Name: "org.freedesktop.systemd1.NoSuchUnit", Body: []any{"Unit " + unit + " not found."}}
+		}
+		b.jobs++
+		job := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/systemd1/job/%d", b.jobs))
+		result := u.jobResult
+		if result == "" {
+			result = "done"
+		}
+		signal := &dbus.Signal{
+			Path: dbusManagerPath,
+			Name: dbusManagerIface + ".JobRemoved",
+			Body: []any{uint32(b.jobs), job, unit, result},
+		}
+		// Jobs finish after the call returns, as they do on a real bus.
+		for _, ch := range b.signals {
+			go func() { ch <- signal }()
+		}
+		return []any{job}, nil
+	default:
+		return nil, fmt.Errorf("unexpected method %s", method)
+	}
+}
+
+// stubObject routes calls to the stub bus. Methods the backend does not use
+// are left to the embedded nil interface.
+type stubObject struct {
+	dbus.BusObject
+	bus  *stubBus
+	path dbus.ObjectPath
+}
+
+func (o *stubObject) Call(method string, flags dbus.Flags, args ...any) *dbus.Call {
+	body, err := o.bus.call(o.path, method, args)
+	return &dbus.Call{Path: o.path, Method: method, Args: args, Body: body, Err: err}
+}
+
+// useStubBus makes the package-level functions talk to bus for the rest of
+// the test.
+func useStubBus(t *testing.T, bus *stubBus) {
+	t.Helper()
+	oldConnect := connectBus
+	connectBus = func() (busConn, error) { return bus, nil }
+	m, err := newDBusManager()
+	connectBus = oldConnect
+	if err != nil {
+		t.Fatalf("newDBusManager() error = %v", err)
+	}
+
+	managerOnce.Do(func() {})
+	manager = m
+	t.Cleanup(func() { manager = nil })
+}
+
+func newStubBus() *stubBus {
+	return &stubBus{units: map[string]stubUnit{
+		"web.service": {props: map[string]map[string]any{
+			dbusUnitIface:                      {"ActiveState": "active", "SubState": "running", "LoadState": "loaded"},
+			"org.freedesktop.systemd1.Service": {"Result": "success", "MainPID": uint32(42)},
+		}},
+		"db.service": {
+			jobResult: "failed",
+			props: map[string]map[string]any{
+				dbusUnitIface: {"ActiveState": "failed", "SubState": "failed"},
+			},
+		},
+		"backup.timer": {props: map[string]map[string]any{
+			dbusUnitIface: {"ActiveState": "active", "ActiveEnterTimestamp": uint64(1_700_000_000_123_456)},
+			"org.freedesktop.systemd1.Timer": {
+				"LastTriggerUSec":         uint64(0),
+				"NextElapseUSecRealtime":  uint64(1_700_086_400_000_000),
+				"NextElapseUSecMonotonic": uint64(5_000_000),
+				"Persistent":              true,
+				"Unit":                    "backup.service",
+			},
+		}},
+	}}
+}
+
+func TestDBusJobs(t *testing.T) {
+	bus := newStubBus()
+	useStubBus(t, bus)
+
+	if _, err := Start("web.service"); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if _, err := RestartMultiple([]string{"web.service", "backup.timer"}); err != nil {
+		t.Fatalf("RestartMultiple() error = %v", err)
+	}
+
+	_, err := StartMultiple([]string{"web.service", "db.service", "missing.service"})
+	if err == nil {
+		t.Fatal("StartMultiple() error = nil, want failures for db.service and missing.service")
+	}
+	for _, want := range []string{"StartUnit db.service: job failed", "StartUnit missing.service: Unit missing.service not found."} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("StartMultiple() error = %q, want it to contain %q", err, want)
+		}
+	}
+
+	if _, err := DaemonReload(); err != nil {
+		t.Fatalf("DaemonReload() error = %v", err)
+	}
+
+	wantCalls := []string{
+		"Subscribe",
+		"StartUnit [web.service replace]",
+		"RestartUnit [web.service replace]",
+		"RestartUnit [backup.timer replace]",
+		"StartUnit [web.service replace]",
+		"StartUnit [db.service replace]",
+		"StartUnit [missing.service replace]",
+		"Reload",
+	}
+	if !slices.Equal(bus.calls, wantCalls) {
+		t.Errorf("calls = %q, want %q", bus.calls, wantCalls)
+	}
+	if len(bus.signals) != 0 {
+		t.Errorf("%d signal channel(s) still registered after jobs finished", len(bus.signals))
+	}
+}
+
+func TestDBusShowProperties(t *testing.T) {
+	useStubBus(t, newStubBus())
+
+	got, err := ShowProperties("backup.timer", "ActiveState", "ActiveEnterTimestamp", "LastTriggerUSec", "NextElapseUSecRealtime", "NextElapseUSecMonotonic", "Persistent", "Unit", "Bogus")
+	if err != nil {
+		t.Fatalf("ShowProperties() error = %v", err)
+	}
+	want := map[string]string{
+		"ActiveState":             "active",
+		"ActiveEnterTimestamp":    "@1700000000",
+		"LastTriggerUSec":         "n/a",
+		"NextElapseUSecRealtime":  "@1700086400",
+		"NextElapseUSecMonotonic": "5000000",
+		"Persistent":              "yes",
+		"Unit":                    "backup.service",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("ShowProperties() = %v, want %v", got, want)
+	}
+
+	value, err := Show("web.service", "MainPID")
+	if err != nil || value != "42\n" {
+		t.Errorf("Show() = %q, %v, want %q", value, err, "42\n")
+	}
+}
+
+func TestDBusIsActive(t *testing.T) {
+	useStubBus(t, newStubBus())
+
+	got, err := IsActiveMultiple([]string{"web.service", "db.service", "missing.service"})
+	if err != nil {
+		t.Fatalf("IsActiveMultiple() error = %v", err)
+	}
+	if want := []bool{true, false, false}; !slices.Equal(got, want) {
+		t.Errorf("IsActiveMultiple() = %v, want %v", got, want)
+	}
+}
+
+func TestNewDBusManagerFailures(t *testing.T) {
+	oldConnect := connectBus
+	t.Cleanup(func() { connectBus = oldConnect })
+
+	connectBus = func() (busConn, error) { return nil, errors.New("no bus") }
+	if _, err := newDBusManager(); err == nil {
+		t.Error("newDBusManager() error = nil without a bus")
+	}
+
+	bus := &stubBus{subscribe: dbus.Error{Name: "org.freedesktop.DBus.Error.ServiceUnknown"}}
+	connectBus = func() (busConn, error) { return bus, nil }
+	if _, err := newDBusManager(); err == nil {
+		t.Error("newDBusManager() error = nil without a user manager")
+	}
+	if !bus.closed {
+		t.Error("newDBusManager() left the connection open after failing")
+	}
+}
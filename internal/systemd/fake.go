@@ -0,0 +1,203 @@
+package systemd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Fake is an in-memory Manager for tests. It records unit files, enable and
+// active state, and every call made to it.
+type Fake struct {
+	Dir     string                       // reported by UserDir and used in returned paths
+	Files   map[string]string            // unit file name -> content
+	Enabled map[string]bool              // unit -> enabled
+	Active  map[string]bool              // unit -> active
+	Props   map[string]map[string]string // extra ShowProperties values per unit
+	Logs    map[string]string            // unit -> journal returned by Journal
+	Fail    map[string]error             // "verb unit" (e.g. "start web.service") -> error to return
+	Reloads int                          // number of DaemonReload calls
+	Calls   []string                     // every call, e.g. "start web.service"
+}
+
+// NewFake returns an empty Fake whose user unit directory is dir.
+func NewFake(dir string) *Fake {
+	return &Fake{
+		Dir:     dir,
+		Files:   make(map[string]string),
+		Enabled: make(map[string]bool),
+		Active:  make(map[string]bool),
+		Props:   make(map[string]map[string]string),
+		Logs:    make(map[string]string),
+		Fail:    make(map[string]error),
+	}
+}
+
+// record logs a call and returns the error configured for it, if any.
+func (f *Fake) record(verb, unit string) error {
+	call := strings.TrimSpace(verb + " " + unit)
+	f.Calls = append(f.Calls, call)
+	if err := f.Fail[call]; err != nil {
+		return fmt.Errorf("error running systemctl %s: %w", call, err)
+	}
+	return nil
+}
+
+func (f *Fake) UserDir() (string, error) { return f.Dir, nil }
+
+func (f *Fake) WriteUnitFile(name, content string, mode os.FileMode) (string, error) {
+	if err := f.record("write", name); err != nil {
+		return "", err
+	}
+	f.Files[name] = content
+	return filepath.Join(f.Dir, name), nil
+}
+
+func (f *Fake) RemoveUnitFile(name string) (string, error) {
+	path := filepath.Join(f.Dir, name)
+	if err := f.record("remove", name); err != nil {
+		return path, err
+	}
+	if _, ok := f.Files[name]; !ok {
+		return path, &fs.PathError{Op: "remove", Path: path, Err: fs.ErrNotExist}
+	}
+	delete(f.Files, name)
+	return path, nil
+}
+
+func (f *Fake) HasUnitFile(name string) bool {
+	_, ok := f.Files[name]
+	return ok
+}
+
+func (f *Fake) DaemonReload() (string, error) {
+	if err := f.record("daemon-reload", ""); err != nil {
+		return "", err
+	}
+	f.Reloads++
+	return "", nil
+}
+
+func (f *Fake) setActive(verb string, units []string, active bool) (string, error) {
+	for _, unit := range units {
+		if err := f.record(verb, unit); err != nil {
+			return "", err
+		}
+		f.Active[unit] = active
+	}
+	return "", nil
+}
+
+func (f *Fake) Start(unit string) (string, error) {
+	return f.setActive("start", []string{unit}, true)
+}
+
+func (f *Fake) Stop(unit string) (string, error) {
+	return f.setActive("stop", []string{unit}, false)
+}
+
+func (f *Fake) Restart(unit string) (string, error) {
+	return f.setActive("restart", []string{unit}, true)
+}
+
+func (f *Fake) StartMultiple(units []string) (string, error) {
+	return f.setActive("start", units, true)
+}
+
+func (f *Fake) StopMultiple(units []string) (string, error) {
+	return f.setActive("stop", units, false)
+}
+
+func (f *Fake) RestartMultiple(units []string) (string, error) {
+	return f.setActive("restart", units, true)
+}
+
+func (f *Fake) Enable(unit string) (string, error) {
+	if err := f.record("enable", unit); err != nil {
+		return "", err
+	}
+	f.Enabled[unit] = true
+	return "", nil
+}
+
+func (f *Fake) Disable(unit string) (string, error) {
+	if err := f.record("disable", unit); err != nil {
+		return "", err
+	}
+	delete(f.Enabled, unit)
+	return "", nil
+}
+
+func (f *Fake) Status(units ...string) (string, error) {
+	var b strings.Builder
+	for _, unit := range units {
+		fmt.Fprintf(&b, "● %s\n     Active: %s\n", unit, f.activeState(unit))
+	}
+	return b.String(), nil
+}
+
+func (f *Fake) ListTimers(timer string) (string, error) {
+	return "", nil
+}
+
+// Journal returns the last lines of Logs[unit].
+func (f *Fake) Journal(unit string, lines int) (string, error) {
+	all := strings.Split(strings.TrimRight(f.Logs[unit], "\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	return strings.Join(all, "\n"), nil
+}
+
+func (f *Fake) IsActive(unit string) (bool, error) {
+	return f.Active[unit], nil
+}
+
+func (f *Fake) IsActiveMultiple(units []string) ([]bool, error) {
+	result := make([]bool, len(units))
+	for i, unit := range units {
+		result[i] = f.Active[unit]
+	}
+	return result, nil
+}
+
+func (f *Fake) ShowProperties(unit string, properties ...string) (map[string]string, error) {
+	sub := "dead"
+	if f.Active[unit] {
+		sub = "running"
+	}
+	known := map[string]string{"ActiveState": f.activeState(unit), "SubState": sub}
+	for key, value := range f.Props[unit] {
+		known[key] = value
+	}
+
+	result := make(map[string]string, len(properties))
+	for _, name := range properties {
+		if value, ok := known[name]; ok {
+			result[name] = value
+		}
+	}
+	return result, nil
+}
+
+func (f *Fake) activeState(unit string) string {
+	if f.Active[unit] {
+		return "active"
+	}
+	return "inactive"
+}
+
+// UnitFiles returns the names of the recorded unit files, sorted.
+func (f *Fake) UnitFiles() []string {
+	names := make([]string, 0, len(f.Files))
+	for name := range f.Files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+var _ Manager = (*Fake)(nil)
@@ -0,0 +1,113 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Manager is the systemd user manager as seen by qh's commands: the user unit
+// directory plus the operations the commands run on units. NewManager returns
+// the real one; tests use a Fake.
+type Manager interface {
+	// UserDir returns the directory user unit files are installed to.
+	UserDir() (string, error)
+	// WriteUnitFile writes a unit file to UserDir and returns its path.
+	WriteUnitFile(name, content string, mode os.FileMode) (string, error)
+	// RemoveUnitFile removes a unit file from UserDir and returns its path.
+	// The error wraps fs.ErrNotExist if there was no such file.
+	RemoveUnitFile(name string) (string, error)
+	// HasUnitFile reports whether a unit file exists in UserDir.
+	HasUnitFile(name string) bool
+
+	DaemonReload() (string, error)
+	Start(unit string) (string, error)
+	Stop(unit string) (string, error)
+	Restart(unit string) (string, error)
+	StartMultiple(units []string) (string, error)
+	StopMultiple(units []string) (string, error)
+	RestartMultiple(units []string) (string, error)
+	Enable(unit string) (string, error)
+	Disable(unit string) (string, error)
+
+	Status(units ...string) (string, error)
+	ListTimers(timer string) (string, error)
+	// Journal returns the last lines of a unit's journal.
+	Journal(unit string, lines int) (string, error)
+	IsActive(unit string) (bool, error)
+	IsActiveMultiple(units []string) ([]bool, error)
+	ShowProperties(unit string, properties ...string) (map[string]string, error)
+}
+
+// NewManager returns the Manager for the current user, which talks to systemd
+// over D-Bus or through systemctl and writes unit files under UserDir.
+func NewManager() Manager {
+	return systemManager{}
+}
+
+type systemManager struct{}
+
+func (systemManager) UserDir() (string, error) { return UserDir() }
+
+func (systemManager) WriteUnitFile(name, content string, mode os.FileMode) (string, error) {
+	userDir, err := UserDir()
+	if err != nil {
+		return "", err
+	}
+	// name may be a drop-in, such as foo.service.d/override.conf.
+	path := filepath.Join(userDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("creating systemd directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (systemManager) RemoveUnitFile(name string) (string, error) {
+	userDir, err := UserDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(userDir, name)
+	if err := os.Remove(path); err != nil {
+		return path, err
+	}
+	// An emptied drop-in directory goes with its last file.
+	if dir := filepath.Dir(path); dir != userDir {
+		_ = os.Remove(dir)
+	}
+	return path, nil
+}
+
+func (systemManager) HasUnitFile(name string) bool {
+	userDir, err := UserDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(userDir, name))
+	return err == nil
+}
+
+func (systemManager) DaemonReload() (string, error)                  { return DaemonReload() }
+func (systemManager) Start(unit string) (string, error)              { return Start(unit) }
+func (systemManager) Stop(unit string) (string, error)               { return Stop(unit) }
+func (systemManager) Restart(unit string) (string, error)            { return Restart(unit) }
+func (systemManager) StartMultiple(units []string) (string, error)   { return StartMultiple(units) }
+func (systemManager) StopMultiple(units []string) (string, error)    { return StopMultiple(units) }
+func (systemManager) RestartMultiple(units []string) (string, error) { return RestartMultiple(units) }
+func (systemManager) Enable(unit string) (string, error)             { return Enable(unit) }
+func (systemManager) Disable(unit string) (string, error)            { return Disable(unit) }
+func (systemManager) Status(units ...string) (string, error)         { return Status(units...) }
+func (systemManager) ListTimers(timer string) (string, error)        { return ListTimers(timer) }
+func (systemManager) IsActive(unit string) (bool, error)             { return IsActive(unit) }
+func (systemManager) Journal(unit string, lines int) (string, error) {
+	return Journal(unit, lines)
+}
+func (systemManager) IsActiveMultiple(units []string) ([]bool, error) {
+	return IsActiveMultiple(units)
+}
+func (systemManager) ShowProperties(unit string, properties ...string) (map[string]string, error) {
+	return ShowProperties(unit, properties...)
+}
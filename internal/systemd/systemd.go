@@ -3,7 +3,9 @@ package systemd
 import (
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // runSystemctl executes a systemctl command with the --user flag.
@@ -19,27 +21,42 @@ func runSystemctl(args ...string) (string, error) {
 
 // DaemonReload reloads the systemd user daemon.
 func DaemonReload() (string, error) {
+	if m := dbusBackend(); m != nil {
+		return "", m.reload()
+	}
 	return runSystemctl("daemon-reload")
 }
 
 // Start starts a systemd user unit.
 func Start(unit string) (string, error) {
+	if m := dbusBackend(); m != nil {
+		return "", m.runJobs("StartUnit", []string{unit})
+	}
 	return runSystemctl("start", unit)
 }
 
 // Stop stops a systemd user unit.
 func Stop(unit string) (string, error) {
+	if m := dbusBackend(); m != nil {
+		return "", m.runJobs("StopUnit", []string{unit})
+	}
 	return runSystemctl("stop", unit)
 }
 
 // StopMultiple stops multiple systemd user units.
 func StopMultiple(units []string) (string, error) {
+	if m := dbusBackend(); m != nil {
+		return "", m.runJobs("StopUnit", units)
+	}
 	args := append([]string{"stop"}, units...)
 	return runSystemctl(args...)
 }
 
 // StartMultiple starts multiple systemd user units.
 func StartMultiple(units []string) (string, error) {
+	if m := dbusBackend(); m != nil {
+		return "", m.runJobs("StartUnit", units)
+	}
 	args := append([]string{"start"}, units...)
 	return runSystemctl(args...)
 }
@@ -56,11 +73,17 @@ func Disable(unit string) (string, error) {
 
 // Restart restarts a systemd user unit.
 func Restart(unit string) (string, error) {
+	if m := dbusBackend(); m != nil {
+		return "", m.runJobs("RestartUnit", []string{unit})
+	}
 	return runSystemctl("restart", unit)
 }
 
 // RestartMultiple restarts multiple systemd user units.
 func RestartMultiple(units []string) (string, error) {
+	if m := dbusBackend(); m != nil {
+		return "", m.runJobs("RestartUnit", units)
+	}
 	args := append([]string{"restart"}, units...)
 	return runSystemctl(args...)
 }
@@ -83,8 +106,22 @@ func ListTimers(timer string) (string, error) {
 	return string(output), err
 }
 
+// Journal returns the last lines of a user unit's journal.
+func Journal(unit string, lines int) (string, error) {
+	cmd := exec.Command("journalctl", "--user", "-u", unit, "-n", strconv.Itoa(lines), "--no-pager")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("error reading journal of %s: %w", unit, err)
+	}
+	return string(output), nil
+}
+
 // IsActive checks if a systemd user unit is active.
 func IsActive(unit string) (bool, error) {
+	if m := dbusBackend(); m != nil {
+		props, err := m.properties(unit, []string{"ActiveState"})
+		return parseIsActiveResult(unit, props["ActiveState"], err)
+	}
 	allArgs := []string{"--user", "is-active", unit}
 	cmd := exec.Command("systemctl", allArgs...)
 	output, err := cmd.CombinedOutput()
@@ -118,6 +155,18 @@ func IsActiveMultiple(units []string) ([]bool, error) {
 		return nil, nil
 	}
 
+	if m := dbusBackend(); m != nil {
+		result := make([]bool, len(units))
+		for i, unit := range units {
+			active, err := IsActive(unit)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = active
+		}
+		return result, nil
+	}
+
 	allArgs := append([]string{"--user", "is-active"}, units...)
 	cmd := exec.Command("systemctl", allArgs...)
 	output, err := cmd.CombinedOutput()
@@ -179,8 +228,76 @@ func ListActiveServices() ([]string, error) {
 
 // Show gets properties of a systemd user unit.
 func Show(unit, property string) (string, error) {
+	if m := dbusBackend(); m != nil {
+		props, err := m.properties(unit, []string{property})
+		if err != nil {
+			return "", err
+		}
+		return props[property] + "\n", nil
+	}
 	allArgs := []string{"--user", "show", unit, "--property=" + property, "--value"}
 	cmd := exec.Command("systemctl", allArgs...)
 	output, err := cmd.CombinedOutput()
 	return string(output), err
 }
+
+// ShowProperties gets several properties of a systemd user unit at once.
+// Timestamps are reported in Unix form ("@1700000000"); see ParseTimestamp.
+func ShowProperties(unit string, properties ...string) (map[string]string, error) {
+	if m := dbusBackend(); m != nil {
+		return m.properties(unit, properties)
+	}
+	output, err := runSystemctl("show", unit, "--timestamp=unix", "--property="+strings.Join(properties, ","))
+	if err != nil {
+		if strings.TrimSpace(output) != "" {
+			return nil, fmt.Errorf("%w\n%s", err, strings.TrimSpace(output))
+		}
+		return nil, err
+	}
+	return parseShowOutput(output), nil
+}
+
+// parseShowOutput parses the KEY=VALUE lines printed by systemctl show.
+func parseShowOutput(output string) map[string]string {
+	props := make(map[string]string)
+	for line := range strings.SplitSeq(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimRight(line, "\r"), "=")
+		if ok && key != "" {
+			props[key] = value
+		}
+	}
+	return props
+}
+
+// ParseTimestamp parses a Unix timestamp printed by ShowProperties. It reports
+// false for timestamps that are unset, such as a timer that never ran.
+func ParseTimestamp(value string) (time.Time, bool) {
+	seconds, ok := strings.CutPrefix(strings.TrimSpace(value), "@")
+	if !ok {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(n, 0), true
+}
+
+// Escape escapes a string for use in a unit name, such as a template
+// instance, the way systemd-escape does. %I in the unit undoes it.
+func Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '/':
+			b.WriteByte('-')
+		case c == '.' && i == 0,
+			!(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == ':' || c == '_' || c == '.'):
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -2,8 +2,10 @@ package systemd
 
 import (
 	"errors"
+	"maps"
 	"slices"
 	"testing"
+	"time"
 )
 
 func TestParseIsActiveResult(t *testing.T) {
@@ -77,3 +79,55 @@ func TestParseIsActiveMultipleResult(t *testing.T) {
 		})
 	}
 }
+
+func TestParseShowOutput(t *testing.T) {
+	output := "ActiveState=active\nSubState=running\nExecMainStatus=0\nDescription=a=b\n\n"
+	want := map[string]string{
+		"ActiveState":    "active",
+		"SubState":       "running",
+		"ExecMainStatus": "0",
+		"Description":    "a=b",
+	}
+	if got := parseShowOutput(output); !maps.Equal(got, want) {
+		t.Fatalf("parseShowOutput() = %v, want %v", got, want)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "unix", value: "@1700000000", want: time.Unix(1700000000, 0), wantOK: true},
+		{name: "unset", value: ""},
+		{name: "not applicable", value: "n/a"},
+		{name: "zero", value: "@0"},
+		{name: "pretty", value: "Thu 2026-10-15 03:00:00 UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTimestamp(tt.value)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Fatalf("ParseTimestamp(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := map[string]string{
+		"immich":        "immich",
+		"photos_2024.1": "photos_2024.1",
+		"my-backup":     `my\x2dbackup`,
+		"a b/c":         `a\x20b-c`,
+		".hidden":       `\x2ehidden`,
+	}
+	for in, want := range tests {
+		if got := Escape(in); got != want {
+			t.Errorf("Escape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Package systemdtest sets up the fake systemd and containers directory that
+// command tests run against.
+package systemdtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mufeedali/quadlet-helper/internal/systemd"
+	"github.com/spf13/viper"
+)
+
+// UseFake points manager, a command package's systemd manager, at an
+// in-memory systemd for the rest of the test.
+func UseFake(t testing.TB, manager *systemd.Manager) *systemd.Fake {
+	t.Helper()
+	fake := systemd.NewFake(t.TempDir())
+	old := *manager
+	*manager = fake
+	t.Cleanup(func() { *manager = old })
+	return fake
+}
+
+// UseContainersDir creates the given quadlet files in a temporary
+// --containers-path for the rest of the test, and returns its path.
+func UseContainersDir(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	old := viper.GetString("containers-path")
+	viper.Set("containers-path", dir)
+	t.Cleanup(func() { viper.Set("containers-path", old) })
+	return dir
+}
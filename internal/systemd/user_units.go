@@ -1,7 +1,9 @@
 package systemd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 )
@@ -25,19 +27,14 @@ func UserDir() (string, error) {
 	return filepath.Join(home, ".config", "systemd", "user"), nil
 }
 
-func InstallUserUnits(files []UserUnitFile, activateUnits []string) ([]string, error) {
-	userDir, err := UserDir()
-	if err != nil {
-		return nil, err
-	}
-	if err := os.MkdirAll(userDir, 0755); err != nil {
-		return nil, fmt.Errorf("creating systemd directory: %w", err)
-	}
-
+// InstallUserUnits writes the unit files, reloads systemd and enables and
+// starts activateUnits. Written files are removed again if any step fails.
+func InstallUserUnits(m Manager, files []UserUnitFile, activateUnits []string) ([]string, error) {
 	paths := make([]string, 0, len(files))
+	written := make([]string, 0, len(files))
 	removeWritten := func() {
-		for _, path := range paths {
-			_ = os.Remove(path)
+		for _, name := range written {
+			_, _ = m.RemoveUnitFile(name)
 		}
 	}
 
@@ -46,27 +43,28 @@ func InstallUserUnits(files []UserUnitFile, activateUnits []string) ([]string, e
 		if mode == 0 {
 			mode = 0644
 		}
-		path := filepath.Join(userDir, file.Name)
-		if err := os.WriteFile(path, []byte(file.Content), mode); err != nil {
+		path, err := m.WriteUnitFile(file.Name, file.Content, mode)
+		if err != nil {
 			removeWritten()
 			return nil, fmt.Errorf("writing %s: %w", file.Name, err)
 		}
 		paths = append(paths, path)
+		written = append(written, file.Name)
 	}
 
-	if _, err := DaemonReload(); err != nil {
+	if _, err := m.DaemonReload(); err != nil {
 		removeWritten()
 		return nil, fmt.Errorf("reloading systemd: %w", err)
 	}
 	for _, unit := range activateUnits {
-		if _, err := Enable(unit); err != nil {
+		if _, err := m.Enable(unit); err != nil {
 			removeWritten()
-			_, _ = DaemonReload()
+			_, _ = m.DaemonReload()
 			return nil, fmt.Errorf("enabling %s: %w", unit, err)
 		}
-		if _, err := Start(unit); err != nil {
+		if _, err := m.Start(unit); err != nil {
 			removeWritten()
-			_, _ = DaemonReload()
+			_, _ = m.DaemonReload()
 			return nil, fmt.Errorf("starting %s: %w", unit, err)
 		}
 	}
@@ -74,34 +72,31 @@ func InstallUserUnits(files []UserUnitFile, activateUnits []string) ([]string, e
 	return paths, nil
 }
 
-func UninstallUserUnits(stopUnits []string, disableUnits []string, removeFiles []string) (*UninstallResult, error) {
-	userDir, err := UserDir()
-	if err != nil {
-		return nil, err
-	}
-
+// UninstallUserUnits stops and disables units and removes unit files. Failures
+// are collected as warnings so the rest of the cleanup still happens.
+func UninstallUserUnits(m Manager, stopUnits []string, disableUnits []string, removeFiles []string) (*UninstallResult, error) {
 	result := &UninstallResult{}
 	for _, unit := range stopUnits {
-		if _, err := Stop(unit); err != nil {
+		if _, err := m.Stop(unit); err != nil {
 			result.Warnings = append(result.Warnings, fmt.Errorf("stopping %s: %w", unit, err))
 		}
 	}
 	for _, unit := range disableUnits {
-		if _, err := Disable(unit); err != nil {
+		if _, err := m.Disable(unit); err != nil {
 			result.Warnings = append(result.Warnings, fmt.Errorf("disabling %s: %w", unit, err))
 		}
 	}
 	for _, fileName := range removeFiles {
-		path := filepath.Join(userDir, fileName)
-		if err := os.Remove(path); err != nil {
-			if !os.IsNotExist(err) {
+		path, err := m.RemoveUnitFile(fileName)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
 				result.Warnings = append(result.Warnings, fmt.Errorf("removing %s: %w", fileName, err))
 			}
 			continue
 		}
 		result.RemovedPaths = append(result.RemovedPaths, path)
 	}
-	if _, err := DaemonReload(); err != nil {
+	if _, err := m.DaemonReload(); err != nil {
 		result.Warnings = append(result.Warnings, fmt.Errorf("reloading systemd: %w", err))
 	}
 
@@ -0,0 +1,74 @@
+package systemd
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestInstallUserUnits(t *testing.T) {
+	fake := NewFake("/home/user/.config/systemd/user")
+	paths, err := InstallUserUnits(fake, []UserUnitFile{
+		{Name: "demo.service", Content: "[Service]\n"},
+		{Name: "demo.timer", Content: "[Timer]\n"},
+	}, []string{"demo.timer"})
+	if err != nil {
+		t.Fatalf("InstallUserUnits() error = %v", err)
+	}
+
+	wantPaths := []string{"/home/user/.config/systemd/user/demo.service", "/home/user/.config/systemd/user/demo.timer"}
+	if !slices.Equal(paths, wantPaths) {
+		t.Errorf("InstallUserUnits() paths = %v, want %v", paths, wantPaths)
+	}
+	if got := fake.UnitFiles(); !slices.Equal(got, []string{"demo.service", "demo.timer"}) {
+		t.Errorf("unit files = %v", got)
+	}
+	if !fake.Enabled["demo.timer"] || !fake.Active["demo.timer"] {
+		t.Errorf("demo.timer enabled = %v, active = %v, want both", fake.Enabled["demo.timer"], fake.Active["demo.timer"])
+	}
+	if fake.Reloads != 1 {
+		t.Errorf("Reloads = %d, want 1", fake.Reloads)
+	}
+}
+
+func TestInstallUserUnitsRollsBack(t *testing.T) {
+	fake := NewFake("/units")
+	fake.Fail["start demo.timer"] = errors.New("exit status 1")
+
+	_, err := InstallUserUnits(fake, []UserUnitFile{
+		{Name: "demo.service", Content: "[Service]\n"},
+		{Name: "demo.timer", Content: "[Timer]\n"},
+	}, []string{"demo.timer"})
+	if err == nil {
+		t.Fatal("InstallUserUnits() error = nil, want start failure")
+	}
+	if got := fake.UnitFiles(); len(got) != 0 {
+		t.Errorf("unit files after failed install = %v, want none", got)
+	}
+	if fake.Reloads != 2 {
+		t.Errorf("Reloads = %d, want 2", fake.Reloads)
+	}
+}
+
+func TestUninstallUserUnits(t *testing.T) {
+	fake := NewFake("/units")
+	if _, err := InstallUserUnits(fake, []UserUnitFile{{Name: "demo.timer", Content: "[Timer]\n"}}, []string{"demo.timer"}); err != nil {
+		t.Fatalf("InstallUserUnits() error = %v", err)
+	}
+	fake.Fail["stop demo.service"] = errors.New("exit status 5")
+
+	result, err := UninstallUserUnits(fake, []string{"demo.timer", "demo.service"}, []string{"demo.timer"}, []string{"demo.timer", "missing.service"})
+	if err != nil {
+		t.Fatalf("UninstallUserUnits() error = %v", err)
+	}
+	if !slices.Equal(result.RemovedPaths, []string{"/units/demo.timer"}) {
+		t.Errorf("RemovedPaths = %v", result.RemovedPaths)
+	}
+	// The failed stop is a warning; the missing file is not.
+	if len(result.Warnings) != 1 {
+		t.Errorf("Warnings = %v, want one", result.Warnings)
+	}
+	if fake.Enabled["demo.timer"] || fake.Active["demo.timer"] || fake.HasUnitFile("demo.timer") {
+		t.Error("demo.timer is still enabled, active or installed")
+	}
+}